@@ -0,0 +1,5 @@
+-- migrate:up
+CREATE INDEX rentals_camper_period_idx ON rentals (camper_id, start_date, end_date) WHERE status <> 'cancelled' AND deleted_at IS NULL;
+
+-- migrate:down
+DROP INDEX IF EXISTS rentals_camper_period_idx;
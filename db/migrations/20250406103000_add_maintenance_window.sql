@@ -0,0 +1,17 @@
+-- migrate:up
+CREATE TABLE maintenance_windows (
+    id VARCHAR(255) PRIMARY KEY,
+    camper_id VARCHAR(255) NOT NULL REFERENCES campers(id) ON DELETE CASCADE,
+    start_date DATE NOT NULL,
+    end_date DATE NOT NULL,
+    reason TEXT,
+    created_at TIMESTAMP,
+    updated_at TIMESTAMP,
+
+    CONSTRAINT maintenance_windows_period_check CHECK (end_date > start_date)
+);
+
+CREATE INDEX maintenance_windows_camper_period_idx ON maintenance_windows (camper_id, start_date, end_date);
+
+-- migrate:down
+DROP TABLE IF EXISTS maintenance_windows;
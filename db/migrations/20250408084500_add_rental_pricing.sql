@@ -0,0 +1,20 @@
+-- migrate:up
+ALTER TABLE drivers ADD COLUMN daily_rate DECIMAL(10, 2) NOT NULL DEFAULT 0;
+
+CREATE TABLE rental_line_items (
+    id VARCHAR(255) PRIMARY KEY,
+    rental_id VARCHAR(255) NOT NULL REFERENCES rentals(id) ON DELETE CASCADE,
+    kind VARCHAR(50) NOT NULL,
+    reference_id VARCHAR(255),
+    description TEXT NOT NULL,
+    quantity INT NOT NULL DEFAULT 1,
+    unit_price DECIMAL(10, 2) NOT NULL,
+    amount DECIMAL(10, 2) NOT NULL,
+    created_at TIMESTAMP
+);
+
+CREATE INDEX rental_line_items_rental_id_idx ON rental_line_items (rental_id);
+
+-- migrate:down
+DROP TABLE IF EXISTS rental_line_items;
+ALTER TABLE drivers DROP COLUMN IF EXISTS daily_rate;
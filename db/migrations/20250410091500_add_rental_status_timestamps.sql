@@ -0,0 +1,13 @@
+-- migrate:up
+ALTER TABLE rentals
+    ADD COLUMN confirmed_at TIMESTAMP,
+    ADD COLUMN started_at TIMESTAMP,
+    ADD COLUMN completed_at TIMESTAMP,
+    ADD COLUMN cancelled_at TIMESTAMP;
+
+-- migrate:down
+ALTER TABLE rentals
+    DROP COLUMN IF EXISTS confirmed_at,
+    DROP COLUMN IF EXISTS started_at,
+    DROP COLUMN IF EXISTS completed_at,
+    DROP COLUMN IF EXISTS cancelled_at;
@@ -0,0 +1,37 @@
+-- migrate:up
+CREATE TABLE cancellation_policies (
+    id VARCHAR(255) PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    camper_id VARCHAR(255) UNIQUE REFERENCES campers(id) ON DELETE CASCADE,
+    created_at TIMESTAMP,
+    updated_at TIMESTAMP
+);
+
+CREATE UNIQUE INDEX cancellation_policies_global_idx ON cancellation_policies ((camper_id IS NULL)) WHERE camper_id IS NULL;
+
+CREATE TABLE cancellation_tiers (
+    policy_id VARCHAR(255) NOT NULL REFERENCES cancellation_policies(id) ON DELETE CASCADE,
+    min_hours_before_start INT NOT NULL,
+    refund_rate DECIMAL(5, 4) NOT NULL,
+    PRIMARY KEY (policy_id, min_hours_before_start)
+);
+
+ALTER TABLE rentals
+    ADD COLUMN cancelled_by VARCHAR(255),
+    ADD COLUMN cancellation_reason TEXT,
+    ADD COLUMN cancellation_policy_id VARCHAR(255),
+    ADD COLUMN refund_amount DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    ADD COLUMN cancellation_fee DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    ADD COLUMN refund_override_reason TEXT;
+
+-- migrate:down
+ALTER TABLE rentals
+    DROP COLUMN IF EXISTS cancelled_by,
+    DROP COLUMN IF EXISTS cancellation_reason,
+    DROP COLUMN IF EXISTS cancellation_policy_id,
+    DROP COLUMN IF EXISTS refund_amount,
+    DROP COLUMN IF EXISTS cancellation_fee,
+    DROP COLUMN IF EXISTS refund_override_reason;
+
+DROP TABLE IF EXISTS cancellation_tiers;
+DROP TABLE IF EXISTS cancellation_policies;
@@ -0,0 +1,24 @@
+-- migrate:up
+ALTER TABLE rentals ADD COLUMN deposit_due DECIMAL(10, 2) NOT NULL DEFAULT 0;
+
+CREATE TABLE payments (
+    id VARCHAR(255) PRIMARY KEY,
+    rental_id VARCHAR(255) NOT NULL REFERENCES rentals(id),
+    type VARCHAR(50) NOT NULL,
+    status VARCHAR(50) NOT NULL,
+    amount DECIMAL(10, 2) NOT NULL,
+    provider VARCHAR(50) NOT NULL,
+    provider_ref VARCHAR(255),
+    note TEXT,
+    created_by VARCHAR(255) REFERENCES users(id),
+    paid_at TIMESTAMP,
+    created_at TIMESTAMP,
+    updated_at TIMESTAMP
+);
+
+CREATE INDEX payments_rental_id_idx ON payments (rental_id);
+CREATE INDEX payments_provider_ref_idx ON payments (provider, provider_ref);
+
+-- migrate:down
+DROP TABLE IF EXISTS payments;
+ALTER TABLE rentals DROP COLUMN IF EXISTS deposit_due;
@@ -0,0 +1,29 @@
+-- migrate:up
+ALTER TABLE campers ADD COLUMN security_deposit DECIMAL(10, 2) NOT NULL DEFAULT 0;
+
+ALTER TABLE rentals
+    ADD COLUMN security_deposit_status VARCHAR(50) NOT NULL DEFAULT 'none',
+    ADD COLUMN security_deposit_held DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    ADD COLUMN security_deposit_captured DECIMAL(10, 2) NOT NULL DEFAULT 0;
+
+CREATE TABLE security_deposit_events (
+    id VARCHAR(255) PRIMARY KEY,
+    rental_id VARCHAR(255) NOT NULL REFERENCES rentals(id),
+    type VARCHAR(50) NOT NULL,
+    amount DECIMAL(10, 2) NOT NULL,
+    reason TEXT,
+    created_by VARCHAR(255) REFERENCES users(id),
+    created_at TIMESTAMP
+);
+
+CREATE INDEX security_deposit_events_rental_id_idx ON security_deposit_events (rental_id);
+
+-- migrate:down
+DROP TABLE IF EXISTS security_deposit_events;
+
+ALTER TABLE rentals
+    DROP COLUMN IF EXISTS security_deposit_status,
+    DROP COLUMN IF EXISTS security_deposit_held,
+    DROP COLUMN IF EXISTS security_deposit_captured;
+
+ALTER TABLE campers DROP COLUMN IF EXISTS security_deposit;
@@ -0,0 +1,24 @@
+-- migrate:up
+CREATE TABLE invoice_counters (
+    name VARCHAR(255) PRIMARY KEY,
+    last_value INT NOT NULL
+);
+
+CREATE TABLE invoices (
+    id VARCHAR(255) PRIMARY KEY,
+    number VARCHAR(50) NOT NULL UNIQUE,
+    type VARCHAR(50) NOT NULL,
+    rental_id VARCHAR(255) NOT NULL REFERENCES rentals(id),
+    payment_id VARCHAR(255) UNIQUE REFERENCES payments(id),
+    reference_number VARCHAR(50),
+    total DECIMAL(10, 2) NOT NULL,
+    document JSONB NOT NULL,
+    issued_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP
+);
+
+CREATE INDEX invoices_rental_id_idx ON invoices (rental_id);
+
+-- migrate:down
+DROP TABLE IF EXISTS invoices;
+DROP TABLE IF EXISTS invoice_counters;
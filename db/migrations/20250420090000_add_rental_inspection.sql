@@ -0,0 +1,22 @@
+-- migrate:up
+CREATE TABLE rental_inspections (
+    id VARCHAR(255) PRIMARY KEY,
+    rental_id VARCHAR(255) NOT NULL REFERENCES rentals(id),
+    camper_id VARCHAR(255) NOT NULL REFERENCES campers(id),
+    type VARCHAR(50) NOT NULL,
+    odometer INT NOT NULL,
+    fuel_level INT NOT NULL CHECK (fuel_level BETWEEN 0 AND 100),
+    cleanliness VARCHAR(50) NOT NULL,
+    condition VARCHAR(255),
+    checklist JSONB,
+    photos JSONB,
+    notes TEXT,
+    inspected_by VARCHAR(255) REFERENCES users(id),
+    created_at TIMESTAMP,
+    UNIQUE (rental_id, type)
+);
+
+CREATE INDEX rental_inspections_camper_id_idx ON rental_inspections (camper_id);
+
+-- migrate:down
+DROP TABLE IF EXISTS rental_inspections;
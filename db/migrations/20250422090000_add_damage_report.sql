@@ -0,0 +1,27 @@
+-- migrate:up
+CREATE TABLE damage_reports (
+    id VARCHAR(255) PRIMARY KEY,
+    rental_id VARCHAR(255) NOT NULL REFERENCES rentals(id),
+    camper_id VARCHAR(255) NOT NULL REFERENCES campers(id),
+    equipment_id VARCHAR(255) REFERENCES equipments(id),
+    description TEXT NOT NULL,
+    severity VARCHAR(50) NOT NULL,
+    photos JSONB,
+    estimated_cost DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    actual_cost DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    status VARCHAR(50) NOT NULL,
+    blocks_camper BOOLEAN NOT NULL DEFAULT FALSE,
+    payment_id VARCHAR(255) REFERENCES payments(id),
+    reported_by VARCHAR(255) REFERENCES users(id),
+    assessed_at TIMESTAMP,
+    charged_at TIMESTAMP,
+    repaired_at TIMESTAMP,
+    created_at TIMESTAMP,
+    updated_at TIMESTAMP
+);
+
+CREATE INDEX damage_reports_rental_id_idx ON damage_reports (rental_id);
+CREATE INDEX damage_reports_open_camper_idx ON damage_reports (camper_id) WHERE blocks_camper AND status <> 'repaired';
+
+-- migrate:down
+DROP TABLE IF EXISTS damage_reports;
@@ -0,0 +1,21 @@
+-- migrate:up
+ALTER TABLE campers
+    ADD COLUMN late_fee_hourly DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    ADD COLUMN late_fee_daily DECIMAL(10, 2) NOT NULL DEFAULT 0;
+
+ALTER TABLE rentals
+    ADD COLUMN overdue_since TIMESTAMP,
+    ADD COLUMN late_fee DECIMAL(10, 2) NOT NULL DEFAULT 0;
+
+CREATE INDEX rentals_active_end_date_idx ON rentals (end_date) WHERE status = 'active';
+
+-- migrate:down
+DROP INDEX IF EXISTS rentals_active_end_date_idx;
+
+ALTER TABLE rentals
+    DROP COLUMN IF EXISTS overdue_since,
+    DROP COLUMN IF EXISTS late_fee;
+
+ALTER TABLE campers
+    DROP COLUMN IF EXISTS late_fee_hourly,
+    DROP COLUMN IF EXISTS late_fee_daily;
@@ -0,0 +1,17 @@
+-- migrate:up
+CREATE TABLE rental_date_changes (
+    id VARCHAR(255) PRIMARY KEY,
+    rental_id VARCHAR(255) NOT NULL REFERENCES rentals(id),
+    type VARCHAR(50) NOT NULL,
+    previous_end_date DATE NOT NULL,
+    new_end_date DATE NOT NULL,
+    price_delta DECIMAL(10, 2) NOT NULL,
+    payment_id VARCHAR(255) REFERENCES payments(id),
+    created_by VARCHAR(255) REFERENCES users(id),
+    created_at TIMESTAMP
+);
+
+CREATE INDEX rental_date_changes_rental_id_idx ON rental_date_changes (rental_id);
+
+-- migrate:down
+DROP TABLE IF EXISTS rental_date_changes;
@@ -0,0 +1,8 @@
+-- migrate:up
+ALTER TABLE rentals ADD COLUMN hold_expires_at TIMESTAMP;
+
+CREATE INDEX rentals_held_expiry_idx ON rentals (hold_expires_at) WHERE status = 'held';
+
+-- migrate:down
+DROP INDEX IF EXISTS rentals_held_expiry_idx;
+ALTER TABLE rentals DROP COLUMN IF EXISTS hold_expires_at;
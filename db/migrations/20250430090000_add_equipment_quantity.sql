@@ -0,0 +1,7 @@
+-- migrate:up
+ALTER TABLE rental_equipments ADD COLUMN quantity INT NOT NULL DEFAULT 1 CHECK (quantity > 0);
+ALTER TABLE camper_equipments ADD COLUMN quantity INT NOT NULL DEFAULT 1 CHECK (quantity > 0);
+
+-- migrate:down
+ALTER TABLE camper_equipments DROP COLUMN IF EXISTS quantity;
+ALTER TABLE rental_equipments DROP COLUMN IF EXISTS quantity;
@@ -0,0 +1,30 @@
+-- migrate:up
+CREATE TABLE equipment_units (
+    id VARCHAR(255) PRIMARY KEY,
+    equipment_id VARCHAR(255) NOT NULL REFERENCES equipments(id) ON DELETE CASCADE,
+    serial_number VARCHAR(255) NOT NULL,
+    asset_tag VARCHAR(255) NOT NULL UNIQUE,
+    condition VARCHAR(255) NOT NULL,
+    status VARCHAR(32) NOT NULL DEFAULT 'available',
+    notes TEXT,
+    created_at TIMESTAMP,
+    updated_at TIMESTAMP,
+    UNIQUE (equipment_id, serial_number)
+);
+
+CREATE TABLE rental_equipment_units (
+    rental_id VARCHAR(255) NOT NULL,
+    equipment_id VARCHAR(255) NOT NULL,
+    unit_id VARCHAR(255) NOT NULL REFERENCES equipment_units(id) ON DELETE CASCADE,
+    assigned_by VARCHAR(255) NOT NULL,
+    assigned_at TIMESTAMP NOT NULL,
+    returned_at TIMESTAMP,
+    PRIMARY KEY (rental_id, unit_id),
+    FOREIGN KEY (rental_id, equipment_id) REFERENCES rental_equipments(rental_id, equipment_id) ON DELETE CASCADE
+);
+
+CREATE INDEX rental_equipment_units_open_idx ON rental_equipment_units (rental_id) WHERE returned_at IS NULL;
+
+-- migrate:down
+DROP TABLE IF EXISTS rental_equipment_units;
+DROP TABLE IF EXISTS equipment_units;
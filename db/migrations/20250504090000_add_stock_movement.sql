@@ -0,0 +1,23 @@
+-- migrate:up
+CREATE TABLE stock_movements (
+    id VARCHAR(255) PRIMARY KEY,
+    equipment_id VARCHAR(255) NOT NULL REFERENCES equipments(id) ON DELETE CASCADE,
+    type VARCHAR(32) NOT NULL,
+    quantity INT NOT NULL CHECK (quantity <> 0),
+    balance_after INT NOT NULL CHECK (balance_after >= 0),
+    reason TEXT NOT NULL,
+    reference VARCHAR(255),
+    created_by VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX stock_movements_equipment_idx ON stock_movements (equipment_id, created_at);
+
+-- Existing stock becomes the opening balance of each equipment's ledger.
+INSERT INTO stock_movements (id, equipment_id, type, quantity, balance_after, reason, created_by, created_at)
+SELECT 'opening-' || id, id, 'adjustment', stock, stock, 'opening balance', 'system', NOW()
+FROM equipments
+WHERE stock > 0;
+
+-- migrate:down
+DROP TABLE IF EXISTS stock_movements;
@@ -0,0 +1,28 @@
+-- migrate:up
+CREATE TABLE equipment_bundles (
+    id VARCHAR(255) PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    price DECIMAL(10, 2) NOT NULL,
+    created_at TIMESTAMP,
+    updated_at TIMESTAMP
+);
+
+CREATE TABLE equipment_bundle_items (
+    bundle_id VARCHAR(255) NOT NULL REFERENCES equipment_bundles(id) ON DELETE CASCADE,
+    equipment_id VARCHAR(255) NOT NULL REFERENCES equipments(id) ON DELETE CASCADE,
+    quantity INT NOT NULL DEFAULT 1 CHECK (quantity > 0),
+    PRIMARY KEY (bundle_id, equipment_id)
+);
+
+CREATE TABLE rental_bundles (
+    rental_id VARCHAR(255) NOT NULL REFERENCES rentals(id) ON DELETE CASCADE,
+    bundle_id VARCHAR(255) NOT NULL REFERENCES equipment_bundles(id),
+    quantity INT NOT NULL DEFAULT 1 CHECK (quantity > 0),
+    PRIMARY KEY (rental_id, bundle_id)
+);
+
+-- migrate:down
+DROP TABLE IF EXISTS rental_bundles;
+DROP TABLE IF EXISTS equipment_bundle_items;
+DROP TABLE IF EXISTS equipment_bundles;
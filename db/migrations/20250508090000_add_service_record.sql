@@ -0,0 +1,23 @@
+-- migrate:up
+CREATE TABLE service_records (
+    id VARCHAR(255) PRIMARY KEY,
+    camper_id VARCHAR(255) NOT NULL REFERENCES campers(id) ON DELETE CASCADE,
+    type VARCHAR(32) NOT NULL,
+    status VARCHAR(32) NOT NULL DEFAULT 'scheduled',
+    due_date TIMESTAMP,
+    due_odometer INT,
+    workshop VARCHAR(255),
+    cost DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    maintenance_window_id VARCHAR(255) REFERENCES maintenance_windows(id) ON DELETE SET NULL,
+    completed_at TIMESTAMP,
+    completion_notes TEXT,
+    created_by VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP,
+    updated_at TIMESTAMP
+);
+
+CREATE INDEX service_records_camper_idx ON service_records (camper_id);
+CREATE INDEX service_records_due_idx ON service_records (due_date) WHERE status = 'scheduled';
+
+-- migrate:down
+DROP TABLE IF EXISTS service_records;
@@ -0,0 +1,33 @@
+-- migrate:up
+ALTER TABLE campers ADD COLUMN odometer INT NOT NULL DEFAULT 0;
+
+ALTER TABLE rentals ADD COLUMN distance INT NOT NULL DEFAULT 0;
+ALTER TABLE rentals ADD COLUMN mileage_fee DECIMAL(10, 2) NOT NULL DEFAULT 0;
+
+CREATE TABLE odometer_readings (
+    id VARCHAR(255) PRIMARY KEY,
+    camper_id VARCHAR(255) NOT NULL REFERENCES campers(id) ON DELETE CASCADE,
+    rental_id VARCHAR(255) REFERENCES rentals(id) ON DELETE SET NULL,
+    service_record_id VARCHAR(255) REFERENCES service_records(id) ON DELETE SET NULL,
+    source VARCHAR(32) NOT NULL,
+    reading INT NOT NULL CHECK (reading >= 0),
+    recorded_by VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX odometer_readings_camper_idx ON odometer_readings (camper_id, created_at);
+
+-- Seed each camper's odometer from the latest inspection recorded for it.
+UPDATE campers SET odometer = latest.odometer
+FROM (
+    SELECT camper_id, MAX(odometer) AS odometer
+    FROM rental_inspections
+    GROUP BY camper_id
+) AS latest
+WHERE latest.camper_id = campers.id;
+
+-- migrate:down
+DROP TABLE IF EXISTS odometer_readings;
+ALTER TABLE rentals DROP COLUMN IF EXISTS mileage_fee;
+ALTER TABLE rentals DROP COLUMN IF EXISTS distance;
+ALTER TABLE campers DROP COLUMN IF EXISTS odometer;
@@ -0,0 +1,18 @@
+-- migrate:up
+CREATE TABLE camper_images (
+    id VARCHAR(255) PRIMARY KEY,
+    camper_id VARCHAR(255) NOT NULL REFERENCES campers(id) ON DELETE CASCADE,
+    storage_key VARCHAR(512) NOT NULL UNIQUE,
+    url TEXT NOT NULL,
+    content_type VARCHAR(64) NOT NULL,
+    size INT NOT NULL,
+    position INT NOT NULL DEFAULT 0,
+    is_primary BOOLEAN NOT NULL DEFAULT FALSE,
+    created_by VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX camper_images_camper_idx ON camper_images (camper_id, position);
+
+-- migrate:down
+DROP TABLE IF EXISTS camper_images;
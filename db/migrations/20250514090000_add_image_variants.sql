@@ -0,0 +1,18 @@
+-- migrate:up
+ALTER TABLE campers ADD COLUMN image_variants JSONB NOT NULL DEFAULT '{}';
+
+ALTER TABLE camper_images ADD COLUMN variants JSONB NOT NULL DEFAULT '{}';
+
+ALTER TABLE equipments ADD COLUMN image_key VARCHAR(512) NOT NULL DEFAULT '';
+ALTER TABLE equipments ADD COLUMN image_variants JSONB NOT NULL DEFAULT '{}';
+
+ALTER TABLE drivers ADD COLUMN photo_key VARCHAR(512) NOT NULL DEFAULT '';
+ALTER TABLE drivers ADD COLUMN photo_variants JSONB NOT NULL DEFAULT '{}';
+
+-- migrate:down
+ALTER TABLE drivers DROP COLUMN IF EXISTS photo_variants;
+ALTER TABLE drivers DROP COLUMN IF EXISTS photo_key;
+ALTER TABLE equipments DROP COLUMN IF EXISTS image_variants;
+ALTER TABLE equipments DROP COLUMN IF EXISTS image_key;
+ALTER TABLE camper_images DROP COLUMN IF EXISTS variants;
+ALTER TABLE campers DROP COLUMN IF EXISTS image_variants;
@@ -0,0 +1,33 @@
+-- migrate:up
+-- Search vectors use the 'simple' configuration, which does not stem, so
+-- names, plates and phone numbers match as written. Plates and phone
+-- numbers are also indexed without their spaces and dashes.
+ALTER TABLE campers ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('simple', coalesce(name, '')), 'A') ||
+    setweight(to_tsvector('simple', license_plate || ' ' || regexp_replace(license_plate, '[^[:alnum:]]', '', 'g')), 'A') ||
+    setweight(to_tsvector('simple', coalesce(description, '')), 'C')
+) STORED;
+
+ALTER TABLE equipments ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('simple', coalesce(name, '')), 'A') ||
+    setweight(to_tsvector('simple', coalesce(category, '')), 'B') ||
+    setweight(to_tsvector('simple', coalesce(description, '')), 'C')
+) STORED;
+
+ALTER TABLE drivers ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('simple', coalesce(name, '')), 'A') ||
+    setweight(to_tsvector('simple', license_number || ' ' || regexp_replace(license_number, '[^[:alnum:]]', '', 'g')), 'A') ||
+    setweight(to_tsvector('simple', phone || ' ' || regexp_replace(phone, '[^0-9]', '', 'g')), 'B')
+) STORED;
+
+CREATE INDEX campers_search_idx ON campers USING GIN (search_vector);
+CREATE INDEX equipments_search_idx ON equipments USING GIN (search_vector);
+CREATE INDEX drivers_search_idx ON drivers USING GIN (search_vector);
+
+-- migrate:down
+DROP INDEX IF EXISTS drivers_search_idx;
+DROP INDEX IF EXISTS equipments_search_idx;
+DROP INDEX IF EXISTS campers_search_idx;
+ALTER TABLE drivers DROP COLUMN IF EXISTS search_vector;
+ALTER TABLE equipments DROP COLUMN IF EXISTS search_vector;
+ALTER TABLE campers DROP COLUMN IF EXISTS search_vector;
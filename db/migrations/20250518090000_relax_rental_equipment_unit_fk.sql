@@ -0,0 +1,17 @@
+-- migrate:up
+ALTER TABLE rental_equipment_units
+    DROP CONSTRAINT rental_equipment_units_rental_id_equipment_id_fkey,
+    ADD CONSTRAINT rental_equipment_units_rental_id_fkey FOREIGN KEY (rental_id) REFERENCES rentals(id) ON DELETE CASCADE,
+    ADD CONSTRAINT rental_equipment_units_equipment_id_fkey FOREIGN KEY (equipment_id) REFERENCES equipments(id) ON DELETE CASCADE;
+
+-- migrate:down
+DELETE FROM rental_equipment_units u
+WHERE NOT EXISTS (
+    SELECT 1 FROM rental_equipments e
+    WHERE e.rental_id = u.rental_id AND e.equipment_id = u.equipment_id
+);
+
+ALTER TABLE rental_equipment_units
+    DROP CONSTRAINT IF EXISTS rental_equipment_units_equipment_id_fkey,
+    DROP CONSTRAINT IF EXISTS rental_equipment_units_rental_id_fkey,
+    ADD FOREIGN KEY (rental_id, equipment_id) REFERENCES rental_equipments(rental_id, equipment_id) ON DELETE CASCADE;
@@ -0,0 +1,151 @@
+package imaging
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"strings"
+
+	"github.com/notblessy/rms/model"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// Widths are the widths, in pixels, every uploaded image is resized to.
+var Widths = []int{320, 800, 1600}
+
+// JPEGQuality is the quality opaque images are re-encoded at.
+const JPEGQuality = 85
+
+// maxPixels guards against decompression bombs: a small file that decodes
+// to an enormous picture.
+const maxPixels = 50_000_000
+
+// Image is an encoded picture ready to be stored.
+type Image struct {
+	ContentType string
+	Ext         string
+	Data        []byte
+	Width       int
+	Height      int
+}
+
+// Result is an upload normalised for the web. Original keeps the full size
+// and Variants are keyed by width; all of them are re-encoded, which drops
+// EXIF and any other metadata, after the EXIF orientation is applied.
+type Result struct {
+	Original Image
+	Variants map[int]Image
+}
+
+// Process decodes a JPEG, PNG or WebP upload and produces its normalised
+// original and resized variants. Opaque images become JPEG and images with
+// transparency become PNG. Images narrower than a variant are not upscaled;
+// that variant is the image at its own size.
+func Process(content []byte) (Result, error) {
+	config, _, err := image.DecodeConfig(bytes.NewReader(content))
+	if err != nil {
+		return Result{}, model.ErrInvalidImage
+	}
+
+	if config.Width*config.Height > maxPixels {
+		return Result{}, model.ErrImageTooLarge
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(content))
+	if err != nil {
+		return Result{}, model.ErrInvalidImage
+	}
+
+	img := orient(toNRGBA(src), orientation(content))
+
+	original, err := encode(img)
+	if err != nil {
+		return Result{}, err
+	}
+
+	result := Result{
+		Original: original,
+		Variants: make(map[int]Image, len(Widths)),
+	}
+
+	for _, width := range Widths {
+		variant, err := encode(resize(img, width))
+		if err != nil {
+			return Result{}, err
+		}
+
+		result.Variants[width] = variant
+	}
+
+	return result, nil
+}
+
+// VariantKey is the storage key of an image's variant, derived from the key
+// of its original so the variants can be found again to delete them.
+func VariantKey(key string, width int) string {
+	ext := ""
+	if i := strings.LastIndex(key, "."); i > strings.LastIndex(key, "/") {
+		key, ext = key[:i], key[i:]
+	}
+
+	return fmt.Sprintf("%s_w%d%s", key, width, ext)
+}
+
+func toNRGBA(src image.Image) *image.NRGBA {
+	if img, ok := src.(*image.NRGBA); ok && img.Rect.Min == (image.Point{}) {
+		return img
+	}
+
+	bounds := src.Bounds()
+	img := image.NewNRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(img, img.Bounds(), src, bounds.Min, draw.Src)
+	return img
+}
+
+func resize(img *image.NRGBA, width int) *image.NRGBA {
+	bounds := img.Bounds()
+	if bounds.Dx() <= width {
+		return img
+	}
+
+	height := (bounds.Dy()*width + bounds.Dx()/2) / bounds.Dx()
+	if height < 1 {
+		height = 1
+	}
+
+	dst := image.NewNRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, bounds, draw.Src, nil)
+	return dst
+}
+
+func encode(img *image.NRGBA) (Image, error) {
+	var buf bytes.Buffer
+
+	result := Image{
+		ContentType: "image/jpeg",
+		Ext:         ".jpg",
+		Width:       img.Bounds().Dx(),
+		Height:      img.Bounds().Dy(),
+	}
+
+	if img.Opaque() {
+		err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: JPEGQuality})
+		if err != nil {
+			return Image{}, err
+		}
+	} else {
+		result.ContentType = "image/png"
+		result.Ext = ".png"
+
+		err := (&png.Encoder{CompressionLevel: png.BestCompression}).Encode(&buf, img)
+		if err != nil {
+			return Image{}, err
+		}
+	}
+
+	result.Data = buf.Bytes()
+	return result, nil
+}
@@ -0,0 +1,125 @@
+package imaging
+
+import (
+	"encoding/binary"
+	"image"
+)
+
+// orientation reads the EXIF orientation tag of a JPEG, 1 to 8, returning 1
+// (upright) when the image is not a JPEG or carries no orientation.
+func orientation(content []byte) int {
+	if len(content) < 4 || content[0] != 0xFF || content[1] != 0xD8 {
+		return 1
+	}
+
+	for i := 2; i+4 <= len(content); {
+		if content[i] != 0xFF {
+			return 1
+		}
+
+		marker := content[i+1]
+		size := int(binary.BigEndian.Uint16(content[i+2:]))
+		if size < 2 || i+2+size > len(content) {
+			return 1
+		}
+
+		segment := content[i+4 : i+2+size]
+
+		switch {
+		case marker == 0xE1 && len(segment) > 6 && string(segment[:6]) == "Exif\x00\x00":
+			return exifOrientation(segment[6:])
+		case marker == 0xDA:
+			// Image data follows; metadata segments all come before it.
+			return 1
+		}
+
+		i += 2 + size
+	}
+
+	return 1
+}
+
+// exifOrientation finds tag 0x0112 in the first IFD of a TIFF header.
+func exifOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+
+	offset := int(order.Uint32(tiff[4:]))
+	if offset+2 > len(tiff) {
+		return 1
+	}
+
+	entries := int(order.Uint16(tiff[offset:]))
+	for n := 0; n < entries; n++ {
+		entry := offset + 2 + n*12
+		if entry+12 > len(tiff) {
+			return 1
+		}
+
+		if order.Uint16(tiff[entry:]) == 0x0112 {
+			value := int(order.Uint16(tiff[entry+8:]))
+			if value < 1 || value > 8 {
+				return 1
+			}
+
+			return value
+		}
+	}
+
+	return 1
+}
+
+// orient turns the decoded pixels so the image is upright, as a viewer
+// honouring the EXIF orientation would show it.
+func orient(img *image.NRGBA, orientation int) *image.NRGBA {
+	if orientation <= 1 || orientation > 8 {
+		return img
+	}
+
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+
+	dst := image.NewNRGBA(image.Rect(0, 0, dw, dh))
+
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var dx, dy int
+
+			switch orientation {
+			case 2:
+				dx, dy = w-1-x, y
+			case 3:
+				dx, dy = w-1-x, h-1-y
+			case 4:
+				dx, dy = x, h-1-y
+			case 5:
+				dx, dy = y, x
+			case 6:
+				dx, dy = h-1-y, x
+			case 7:
+				dx, dy = h-1-y, w-1-x
+			case 8:
+				dx, dy = y, w-1-x
+			}
+
+			dst.SetNRGBA(dx, dy, img.NRGBAAt(x, y))
+		}
+	}
+
+	return dst
+}
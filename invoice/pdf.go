@@ -0,0 +1,178 @@
+package invoice
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/go-pdf/fpdf"
+	"github.com/notblessy/rms/model"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	pageMargin = 15.0
+	lineHeight = 6.0
+)
+
+type renderer struct {
+	pdf *fpdf.Fpdf
+	tr  func(string) string
+}
+
+// RenderPDF writes an issued invoice or credit note as an A4 PDF. Everything
+// printed comes from the invoice's stored document, never from live data.
+func RenderPDF(w io.Writer, invoice model.Invoice) error {
+	doc := invoice.Document
+
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(pageMargin, pageMargin, pageMargin)
+	pdf.SetTitle(invoice.Number, true)
+	pdf.AddPage()
+
+	// The core fonts only cover cp1252, so names and addresses are
+	// translated from UTF-8 before they are printed.
+	r := renderer{pdf: pdf, tr: pdf.UnicodeTranslatorFromDescriptor("")}
+
+	title, reference := "INVOICE", ""
+	switch invoice.Type {
+	case model.InvoiceTypeCreditNote:
+		title, reference = "CREDIT NOTE", "Credits invoice"
+	case model.InvoiceTypeDebitNote:
+		title, reference = "DEBIT NOTE", "Adds to invoice"
+	}
+
+	pdf.SetFont("Helvetica", "B", 18)
+	pdf.CellFormat(0, 10, title, "", 1, "R", false, 0, "")
+
+	pdf.SetFont("Helvetica", "B", 12)
+	pdf.CellFormat(0, lineHeight, r.tr(doc.Company.Name), "", 1, "L", false, 0, "")
+
+	pdf.SetFont("Helvetica", "", 9)
+	r.writeLines(doc.Company.Address, doc.Company.Email, doc.Company.Phone, taxID(doc.Company.TaxID))
+	pdf.Ln(4)
+
+	r.writeField("Number", invoice.Number)
+	r.writeField("Issued", invoice.IssuedAt.Format("2 January 2006"))
+	if invoice.ReferenceNumber != "" {
+		r.writeField(reference, invoice.ReferenceNumber)
+	}
+	r.writeField("Rental", doc.Rental.ID)
+	pdf.Ln(4)
+
+	pdf.SetFont("Helvetica", "B", 10)
+	pdf.CellFormat(0, lineHeight, "Bill to", "", 1, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 9)
+	r.writeLines(doc.Customer.Name, doc.Customer.Email, doc.Customer.Phone, doc.Customer.Address, idNumber(doc.Customer.IDNumber))
+	pdf.Ln(4)
+
+	r.writeField("Camper", fmt.Sprintf("%s (%s)", doc.Camper.Name, doc.Camper.LicensePlate))
+	r.writeField("Period", fmt.Sprintf("%s - %s", doc.Rental.StartDate.Format("2 Jan 2006"), doc.Rental.EndDate.Format("2 Jan 2006")))
+	if doc.Driver != nil {
+		r.writeField("Driver", doc.Driver.Name)
+	}
+	pdf.Ln(4)
+
+	r.writeLineItems(doc.LineItems)
+	pdf.Ln(2)
+
+	r.writeTotal("Discount", doc.Discount.Neg())
+	r.writeTotal("Tax", doc.Tax)
+	r.writeTotal("Total", doc.Total)
+
+	if len(doc.Payments) > 0 {
+		pdf.Ln(4)
+		pdf.SetFont("Helvetica", "B", 10)
+		pdf.CellFormat(0, lineHeight, "Payments", "", 1, "L", false, 0, "")
+		pdf.SetFont("Helvetica", "", 9)
+
+		for _, payment := range doc.Payments {
+			paidAt := ""
+			if payment.PaidAt.Valid {
+				paidAt = payment.PaidAt.Time.Format("2 Jan 2006")
+			}
+
+			pdf.CellFormat(40, lineHeight, paidAt, "", 0, "L", false, 0, "")
+			pdf.CellFormat(100, lineHeight, r.tr(payment.Type+" "+payment.ProviderRef), "", 0, "L", false, 0, "")
+			pdf.CellFormat(0, lineHeight, money(payment.Amount), "", 1, "R", false, 0, "")
+		}
+	}
+
+	if invoice.Type == model.InvoiceTypeInvoice {
+		pdf.Ln(2)
+		r.writeTotal("Amount paid", doc.AmountPaid)
+		r.writeTotal("Balance due", doc.BalanceDue)
+	}
+
+	return pdf.Output(w)
+}
+
+func (r renderer) writeLineItems(items []model.RentalLineItem) {
+	pdf := r.pdf
+
+	pdf.SetFont("Helvetica", "B", 9)
+	pdf.SetFillColor(235, 235, 235)
+	pdf.CellFormat(90, lineHeight, "Description", "B", 0, "L", true, 0, "")
+	pdf.CellFormat(20, lineHeight, "Qty", "B", 0, "R", true, 0, "")
+	pdf.CellFormat(35, lineHeight, "Unit price", "B", 0, "R", true, 0, "")
+	pdf.CellFormat(0, lineHeight, "Amount", "B", 1, "R", true, 0, "")
+
+	pdf.SetFont("Helvetica", "", 9)
+	for _, item := range items {
+		if item.Kind == model.LineItemDiscount || item.Kind == model.LineItemTax {
+			continue
+		}
+
+		pdf.CellFormat(90, lineHeight, r.tr(item.Description), "", 0, "L", false, 0, "")
+		pdf.CellFormat(20, lineHeight, fmt.Sprint(item.Quantity), "", 0, "R", false, 0, "")
+		pdf.CellFormat(35, lineHeight, money(item.UnitPrice), "", 0, "R", false, 0, "")
+		pdf.CellFormat(0, lineHeight, money(item.Amount), "", 1, "R", false, 0, "")
+	}
+}
+
+func (r renderer) writeTotal(label string, amount decimal.Decimal) {
+	pdf := r.pdf
+
+	pdf.SetFont("Helvetica", "B", 9)
+	pdf.CellFormat(145, lineHeight, label, "", 0, "R", false, 0, "")
+	pdf.SetFont("Helvetica", "", 9)
+	pdf.CellFormat(0, lineHeight, money(amount), "", 1, "R", false, 0, "")
+}
+
+func (r renderer) writeField(label, value string) {
+	pdf := r.pdf
+
+	pdf.SetFont("Helvetica", "B", 9)
+	pdf.CellFormat(35, lineHeight, label, "", 0, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 9)
+	pdf.CellFormat(0, lineHeight, r.tr(value), "", 1, "L", false, 0, "")
+}
+
+func (r renderer) writeLines(lines ...string) {
+	for _, line := range lines {
+		if line == "" {
+			continue
+		}
+
+		r.pdf.MultiCell(0, 5, r.tr(line), "", "L", false)
+	}
+}
+
+func taxID(id string) string {
+	if id == "" {
+		return ""
+	}
+
+	return "Tax ID: " + id
+}
+
+func idNumber(id string) string {
+	if id == "" {
+		return ""
+	}
+
+	return "ID: " + id
+}
+
+func money(amount decimal.Decimal) string {
+	return amount.StringFixed(2)
+}
@@ -0,0 +1,29 @@
+package label
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/notblessy/rms/model"
+	"github.com/skip2/go-qrcode"
+)
+
+// DefaultSize is the width and height of a label in pixels.
+const DefaultSize = 256
+
+// UnitURI is what the QR code on a unit's label encodes. Depot scanners
+// resolve it to GET /v1/equipments/:id/units/:unit.
+func UnitURI(unit model.EquipmentUnit) string {
+	return fmt.Sprintf("rms://equipments/%s/units/%s", unit.EquipmentID, unit.ID)
+}
+
+// RenderQR writes a square PNG QR code identifying the unit.
+func RenderQR(w io.Writer, unit model.EquipmentUnit, size int) error {
+	png, err := qrcode.Encode(UnitURI(unit), qrcode.Medium, size)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(png)
+	return err
+}
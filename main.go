@@ -1,14 +1,26 @@
 package main
 
 import (
+	"context"
+	"os"
+	"strconv"
+	"time"
+
 	"github.com/go-playground/validator"
 	"github.com/joho/godotenv"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 	"github.com/notblessy/rms/db"
+	"github.com/notblessy/rms/model"
+	"github.com/notblessy/rms/notification"
+	"github.com/notblessy/rms/payment"
+	"github.com/notblessy/rms/pricing"
 	"github.com/notblessy/rms/repository"
 	"github.com/notblessy/rms/router"
+	"github.com/notblessy/rms/scheduler"
+	"github.com/notblessy/rms/storage"
 	"github.com/notblessy/rms/utils"
+	"github.com/shopspring/decimal"
 	"github.com/sirupsen/logrus"
 )
 
@@ -34,10 +46,64 @@ func main() {
 	e.Use(middleware.CORS())
 	e.Validator = &utils.Ghost{Validator: validator.New()}
 
+	fileStorage := newFileStorage(e)
+
 	userRepo := repository.NewUserRepository(postgres)
-	camperRepo := repository.NewCamperRepository(postgres)
-	equipmentRepo := repository.NewEquipmentRepository(postgres)
-	driverRepo := repository.NewDriverRepository(postgres)
+	camperRepo := repository.NewCamperRepository(postgres, fileStorage)
+	equipmentRepo := repository.NewEquipmentRepository(postgres, fileStorage)
+	driverRepo := repository.NewDriverRepository(postgres, fileStorage)
+	rentalRepo := repository.NewRentalRepository(postgres, durationEnv("RENTAL_HOLD_TTL", 15*time.Minute), model.MileagePolicy{
+		Allowances: map[string]int{
+			model.RentalTypeDaily:   intEnv("MILEAGE_ALLOWANCE_DAILY"),
+			model.RentalTypeWeekly:  intEnv("MILEAGE_ALLOWANCE_WEEKLY"),
+			model.RentalTypeMonthly: intEnv("MILEAGE_ALLOWANCE_MONTHLY"),
+		},
+		OverageRate: decimalEnv("MILEAGE_OVERAGE_RATE"),
+	})
+	cancellationPolicyRepo := repository.NewCancellationPolicyRepository(postgres)
+	paymentRepo := repository.NewPaymentRepository(postgres)
+	securityDepositRepo := repository.NewSecurityDepositRepository(postgres)
+	damageReportRepo := repository.NewDamageReportRepository(postgres)
+	equipmentUnitRepo := repository.NewEquipmentUnitRepository(postgres)
+	equipmentBundleRepo := repository.NewEquipmentBundleRepository(postgres)
+	maintenanceRepo := repository.NewMaintenanceRepository(postgres)
+	odometerRepo := repository.NewOdometerRepository(postgres)
+	camperImageRepo := repository.NewCamperImageRepository(postgres, fileStorage)
+	searchRepo := repository.NewSearchRepository(postgres)
+	invoiceRepo := repository.NewInvoiceRepository(postgres, model.CompanyDetails{
+		Name:    os.Getenv("COMPANY_NAME"),
+		Address: os.Getenv("COMPANY_ADDRESS"),
+		Email:   os.Getenv("COMPANY_EMAIL"),
+		Phone:   os.Getenv("COMPANY_PHONE"),
+		TaxID:   os.Getenv("COMPANY_TAX_ID"),
+	})
+	inspectionRepo := repository.NewInspectionRepository(postgres, model.InspectionRates{
+		FuelPerPercent: decimalEnv("INSPECTION_FUEL_PER_PERCENT"),
+		CleaningFee:    decimalEnv("INSPECTION_CLEANING_FEE"),
+	})
+
+	// The webhook is public, so an empty secret would let anyone sign a
+	// "paid" event.
+	webhookSecret := os.Getenv("PAYMENT_WEBHOOK_SECRET")
+	if webhookSecret == "" {
+		logrus.Fatal("PAYMENT_WEBHOOK_SECRET is not set")
+	}
+
+	paymentGateway := newPaymentGateway(webhookSecret)
+
+	taxRate, err := decimal.NewFromString(os.Getenv("RENTAL_TAX_RATE"))
+	if err != nil {
+		logrus.Warn("RENTAL_TAX_RATE is not set, rentals are priced without tax")
+		taxRate = decimal.Zero
+	}
+
+	depositRate, err := decimal.NewFromString(os.Getenv("RENTAL_DEPOSIT_RATE"))
+	if err != nil {
+		logrus.Warn("RENTAL_DEPOSIT_RATE is not set, rentals can be confirmed without a deposit")
+		depositRate = decimal.Zero
+	}
+
+	pricingEngine := pricing.NewEngine(pricing.DefaultRentalTypes, taxRate, depositRate)
 
 	httpService := router.NewHTTPService()
 	httpService.RegisterDB(postgres)
@@ -45,8 +111,111 @@ func main() {
 	httpService.RegisterCamperRepository(camperRepo)
 	httpService.RegisterEquipmentRepository(equipmentRepo)
 	httpService.RegisterDriverRepository(driverRepo)
+	httpService.RegisterRentalRepository(rentalRepo)
+	httpService.RegisterCancellationPolicyRepository(cancellationPolicyRepo)
+	httpService.RegisterPaymentRepository(paymentRepo)
+	httpService.RegisterPaymentGateway(paymentGateway)
+	httpService.RegisterSecurityDepositRepository(securityDepositRepo)
+	httpService.RegisterInvoiceRepository(invoiceRepo)
+	httpService.RegisterInspectionRepository(inspectionRepo)
+	httpService.RegisterDamageReportRepository(damageReportRepo)
+	httpService.RegisterEquipmentUnitRepository(equipmentUnitRepo)
+	httpService.RegisterEquipmentBundleRepository(equipmentBundleRepo)
+	httpService.RegisterMaintenanceRepository(maintenanceRepo)
+	httpService.RegisterOdometerRepository(odometerRepo)
+	httpService.RegisterCamperImageRepository(camperImageRepo)
+	httpService.RegisterSearchRepository(searchRepo)
+	httpService.RegisterPricingEngine(pricingEngine)
+
+	if fake, ok := paymentGateway.(*payment.FakeGateway); ok {
+		fake.OnEvent(httpService.HandlePaymentEvent)
+	}
+
+	lateReturnInterval := durationEnv("LATE_RETURN_CHECK_INTERVAL", 15*time.Minute)
+	scheduler.NewLateReturnScheduler(rentalRepo, notification.NewLogNotifier(), lateReturnInterval).Start(context.Background())
+	scheduler.NewHoldSweeper(rentalRepo, durationEnv("HOLD_SWEEP_INTERVAL", time.Minute)).Start(context.Background())
 
 	httpService.Routes(e)
 
 	e.Logger.Fatal(e.Start(":3500"))
 }
+
+// newFileStorage keeps uploads in the S3-compatible bucket configured by the
+// S3_* settings when FILE_STORAGE is "s3". Otherwise they go to UPLOAD_DIR on
+// local disk and are served under /uploads, prefixed with UPLOAD_BASE_URL
+// when the API sits behind another host.
+func newFileStorage(e *echo.Echo) model.FileStorage {
+	if os.Getenv("FILE_STORAGE") == "s3" {
+		return storage.NewS3Storage(storage.S3Config{
+			Endpoint:        os.Getenv("S3_ENDPOINT"),
+			Region:          os.Getenv("S3_REGION"),
+			Bucket:          os.Getenv("S3_BUCKET"),
+			AccessKeyID:     os.Getenv("S3_ACCESS_KEY_ID"),
+			SecretAccessKey: os.Getenv("S3_SECRET_ACCESS_KEY"),
+			PublicURL:       os.Getenv("S3_PUBLIC_URL"),
+		})
+	}
+
+	dir := os.Getenv("UPLOAD_DIR")
+	if dir == "" {
+		dir = "uploads"
+	}
+
+	local := storage.NewLocalStorage(dir, os.Getenv("UPLOAD_BASE_URL")+"/uploads")
+	e.Static("/uploads", local.Dir())
+
+	return local
+}
+
+// newPaymentGateway returns the provider named by PAYMENT_PROVIDER. The only
+// one so far is "fake", for development: it reports every payment as paid
+// after PAYMENT_FAKE_SETTLE_DELAY without moving any money, so it is never
+// picked unless asked for and the API does not start without a provider.
+func newPaymentGateway(webhookSecret string) model.PaymentGateway {
+	provider := os.Getenv("PAYMENT_PROVIDER")
+
+	switch provider {
+	case "fake":
+		logrus.Warn("PAYMENT_PROVIDER is fake, payments are settled without charging anyone")
+		return payment.NewFakeGateway(webhookSecret, durationEnv("PAYMENT_FAKE_SETTLE_DELAY", 2*time.Second))
+	case "":
+		logrus.Fatal("PAYMENT_PROVIDER is not set")
+	default:
+		logrus.Fatalf("unsupported PAYMENT_PROVIDER %q", provider)
+	}
+
+	return nil
+}
+
+// decimalEnv reads an optional decimal setting, treating a missing or
+// malformed value as zero.
+func decimalEnv(key string) decimal.Decimal {
+	value, err := decimal.NewFromString(os.Getenv(key))
+	if err != nil {
+		return decimal.Zero
+	}
+
+	return value
+}
+
+// intEnv reads an optional whole number setting, treating a missing or
+// malformed value as zero.
+func intEnv(key string) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return 0
+	}
+
+	return value
+}
+
+// durationEnv reads an optional duration setting such as "15m", falling back
+// to fallback when it is missing or malformed.
+func durationEnv(key string, fallback time.Duration) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(key))
+	if err != nil || value <= 0 {
+		return fallback
+	}
+
+	return value
+}
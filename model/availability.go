@@ -0,0 +1,158 @@
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	AvailabilityFree        = "free"
+	AvailabilityBooked      = "booked"
+	AvailabilityMaintenance = "maintenance"
+)
+
+const (
+	BlockingCamperUnavailable   = "camper_unavailable"
+	BlockingDriverUnavailable   = "driver_unavailable"
+	BlockingEquipmentOutOfStock = "equipment_out_of_stock"
+)
+
+const (
+	DateLayout = "2006-01-02"
+
+	defaultAvailabilityDays = 30
+	maxAvailabilityDays     = 366
+)
+
+type MaintenanceWindow struct {
+	ID        string    `json:"id"`
+	CamperID  string    `json:"camper_id"`
+	StartDate time.Time `json:"start_date"`
+	EndDate   time.Time `json:"end_date"`
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Overlaps reports whether the window blocks any day in [start, end).
+func (m MaintenanceWindow) Overlaps(start, end time.Time) bool {
+	return m.StartDate.Before(end) && m.EndDate.After(start)
+}
+
+// BlockingReason explains why a rental cannot be booked as requested.
+type BlockingReason struct {
+	Code        string `json:"code"`
+	ReferenceID string `json:"reference_id"`
+	Message     string `json:"message"`
+}
+
+// Err converts the reason into the error returned when booking anyway.
+func (b BlockingReason) Err() error {
+	switch b.Code {
+	case BlockingCamperUnavailable:
+		return ErrCamperUnavailable
+	case BlockingDriverUnavailable:
+		return ErrDriverUnavailable
+	case BlockingEquipmentOutOfStock:
+		return fmt.Errorf("%w: %s", ErrEquipmentOutOfStock, b.Message)
+	}
+
+	return fmt.Errorf("%s: %s", b.Code, b.Message)
+}
+
+// AvailabilityRange is a run of consecutive days sharing the same status.
+// From is inclusive and To is exclusive, matching rental periods.
+type AvailabilityRange struct {
+	Status string    `json:"status"`
+	From   time.Time `json:"from"`
+	To     time.Time `json:"to"`
+}
+
+type CamperAvailability struct {
+	CamperID string              `json:"camper_id"`
+	From     time.Time           `json:"from"`
+	To       time.Time           `json:"to"`
+	Ranges   []AvailabilityRange `json:"ranges"`
+}
+
+type AvailabilityQueryInput struct {
+	From string `query:"from"`
+	To   string `query:"to"`
+}
+
+// Period parses the requested dates, defaulting to the next 30 days from
+// today when they are omitted.
+func (a AvailabilityQueryInput) Period(now time.Time) (time.Time, time.Time, error) {
+	from := truncateDay(now)
+	if a.From != "" {
+		parsed, err := time.Parse(DateLayout, a.From)
+		if err != nil {
+			return time.Time{}, time.Time{}, ErrInvalidAvailabilityRange
+		}
+
+		from = parsed
+	}
+
+	to := from.AddDate(0, 0, defaultAvailabilityDays)
+	if a.To != "" {
+		parsed, err := time.Parse(DateLayout, a.To)
+		if err != nil {
+			return time.Time{}, time.Time{}, ErrInvalidAvailabilityRange
+		}
+
+		to = parsed
+	}
+
+	if !to.After(from) || to.Sub(from) > maxAvailabilityDays*24*time.Hour {
+		return time.Time{}, time.Time{}, ErrInvalidAvailabilityRange
+	}
+
+	return from, to, nil
+}
+
+// BuildAvailability walks [from, to) day by day and merges consecutive days
+// with the same status into ranges. Maintenance takes precedence over
+// bookings so staff can see why a booked day is also blocked.
+func BuildAvailability(from, to time.Time, rentals []Rental, windows []MaintenanceWindow) []AvailabilityRange {
+	var ranges []AvailabilityRange
+
+	for day := truncateDay(from); day.Before(to); day = day.AddDate(0, 0, 1) {
+		next := day.AddDate(0, 0, 1)
+		status := AvailabilityFree
+
+		for _, rental := range rentals {
+			if rental.Status != RentalStatusCancelled && rental.Overlaps(day, next) {
+				status = AvailabilityBooked
+				break
+			}
+		}
+
+		for _, window := range windows {
+			if window.Overlaps(day, next) {
+				status = AvailabilityMaintenance
+				break
+			}
+		}
+
+		last := len(ranges) - 1
+		if last >= 0 && ranges[last].Status == status {
+			ranges[last].To = next
+			continue
+		}
+
+		ranges = append(ranges, AvailabilityRange{
+			Status: status,
+			From:   day,
+			To:     next,
+		})
+	}
+
+	return ranges
+}
+
+// truncateDay drops the time of day. Rental and maintenance dates are stored
+// as DATE columns, which come back as UTC midnight.
+func truncateDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -10,61 +10,209 @@ import (
 type CamperRepository interface {
 	FindByID(ctx context.Context, id string) (Camper, error)
 	FindAll(ctx context.Context, query CamperQueryInput) ([]Camper, int64, error)
+	Facets(ctx context.Context, query CamperQueryInput) (CamperFacets, error)
 	Create(ctx context.Context, camper CamperInput) error
 	Update(ctx context.Context, id string, camper CamperInput) error
 	Delete(ctx context.Context, id string) error
+
+	Availability(ctx context.Context, id string, from, to time.Time) (CamperAvailability, error)
+	CreateMaintenanceWindow(ctx context.Context, window MaintenanceWindow) error
+	DeleteMaintenanceWindow(ctx context.Context, camperID, windowID string) error
 }
 
 type Camper struct {
 	ID              string          `json:"id"`
 	ImageUrl        string          `json:"image_url"`
+	ImageVariants   ImageVariants   `json:"image_variants" gorm:"serializer:json"`
 	Name            string          `json:"name"`
 	Description     string          `json:"description"`
 	LicensePlate    string          `json:"license_plate"`
 	Year            int             `json:"year"`
 	Capacity        int             `json:"capacity"`
 	Price           decimal.Decimal `json:"price"`
+	SecurityDeposit decimal.Decimal `json:"security_deposit"`
+	LateFeeHourly   decimal.Decimal `json:"late_fee_hourly"`
+	LateFeeDaily    decimal.Decimal `json:"late_fee_daily"`
 	Condition       string          `json:"condition"`
+	Odometer        int             `json:"odometer"`
 	LastMaintenance NullTime        `json:"last_maintenance"`
 	Transmission    string          `json:"transmission"`
 	FuelType        string          `json:"fuel_type"`
-	Drivetrain      string          `json:"drivetrain"`
+	Drivetrain      string          `json:"drivetrain" gorm:"column:drivetrain_config"`
 	CreatedAt       time.Time       `json:"created_at"`
 	UpdatedAt       time.Time       `json:"updated_at"`
+
+	Images []CamperImage `json:"images,omitempty" gorm:"foreignKey:CamperID"`
 }
 
+// CamperQueryInput filters the camper listing. Filters are combined with
+// AND; the values of a multi-valued filter, given by repeating it, are
+// combined with OR, except for equipment_id, where a camper must include
+// every equipment listed.
 type CamperQueryInput struct {
-	Keyword string `query:"keyword"`
+	Keyword       string   `query:"keyword"`
+	AvailableFrom string   `query:"available_from"`
+	AvailableTo   string   `query:"available_to"`
+	MinCapacity   int      `query:"min_capacity"`
+	MinPrice      string   `query:"min_price"`
+	MaxPrice      string   `query:"max_price"`
+	MinYear       int      `query:"min_year"`
+	MaxYear       int      `query:"max_year"`
+	Transmissions []string `query:"transmission"`
+	FuelTypes     []string `query:"fuel_type"`
+	Drivetrains   []string `query:"drivetrain"`
+	Conditions    []string `query:"condition"`
+	EquipmentIDs  []string `query:"equipment_id"`
 	PaginatedRequest
 }
 
+// PriceRange returns the requested nightly price bounds. A bound that is not
+// given is returned as nil.
+func (c CamperQueryInput) PriceRange() (min, max *decimal.Decimal, err error) {
+	parse := func(value string) (*decimal.Decimal, error) {
+		if value == "" {
+			return nil, nil
+		}
+
+		price, err := decimal.NewFromString(value)
+		if err != nil || price.IsNegative() {
+			return nil, ErrInvalidCamperFilter
+		}
+
+		return &price, nil
+	}
+
+	min, err = parse(c.MinPrice)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	max, err = parse(c.MaxPrice)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if min != nil && max != nil && min.GreaterThan(*max) {
+		return nil, nil, ErrInvalidCamperFilter
+	}
+
+	return min, max, nil
+}
+
+// RequiredEquipmentIDs lists, once each, the equipment a camper must
+// include to match.
+func (c CamperQueryInput) RequiredEquipmentIDs() []string {
+	var ids []string
+	seen := make(map[string]bool, len(c.EquipmentIDs))
+
+	for _, id := range c.EquipmentIDs {
+		if id == "" || seen[id] {
+			continue
+		}
+
+		seen[id] = true
+		ids = append(ids, id)
+	}
+
+	return ids
+}
+
+// ValidateFilters checks the structured filters before they are queried.
+func (c CamperQueryInput) ValidateFilters() error {
+	if c.MinCapacity < 0 || c.MinYear < 0 || c.MaxYear < 0 {
+		return ErrInvalidCamperFilter
+	}
+
+	if c.MinYear > 0 && c.MaxYear > 0 && c.MinYear > c.MaxYear {
+		return ErrInvalidCamperFilter
+	}
+
+	_, _, err := c.PriceRange()
+	return err
+}
+
+// AvailablePeriod returns the requested booking period, or ok=false when the
+// listing is not filtered by availability.
+func (c CamperQueryInput) AvailablePeriod() (from, to time.Time, ok bool, err error) {
+	if c.AvailableFrom == "" && c.AvailableTo == "" {
+		return time.Time{}, time.Time{}, false, nil
+	}
+
+	if c.AvailableFrom == "" || c.AvailableTo == "" {
+		return time.Time{}, time.Time{}, false, ErrInvalidAvailabilityRange
+	}
+
+	from, to, err = AvailabilityQueryInput{From: c.AvailableFrom, To: c.AvailableTo}.Period(time.Now())
+	if err != nil {
+		return time.Time{}, time.Time{}, false, err
+	}
+
+	return from, to, true, nil
+}
+
 type CamperInput struct {
 	Camper
-	EquipmentIDs []string `json:"equipment_ids"`
+	EquipmentIDs     []string          `json:"equipment_ids"`
+	CamperEquipments []CamperEquipment `json:"equipments"`
 }
 
 func (c CamperInput) ToEntity(id string) Camper {
 	return Camper{
-		ID:              c.ID,
+		ID:              id,
 		ImageUrl:        c.ImageUrl,
 		Name:            c.Name,
+		Description:     c.Description,
 		LicensePlate:    c.LicensePlate,
 		Year:            c.Year,
 		Capacity:        c.Capacity,
 		Price:           c.Price,
+		SecurityDeposit: c.SecurityDeposit,
+		LateFeeHourly:   c.LateFeeHourly,
+		LateFeeDaily:    c.LateFeeDaily,
 		Condition:       c.Condition,
 		LastMaintenance: c.LastMaintenance,
+		Transmission:    c.Transmission,
+		FuelType:        c.FuelType,
+		Drivetrain:      c.Drivetrain,
 	}
 }
 
+// HasEquipments reports whether the input lists any equipment.
+func (c CamperInput) HasEquipments() bool {
+	return len(c.EquipmentIDs) > 0 || len(c.CamperEquipments) > 0
+}
+
+// Equipments merges EquipmentIDs, one of each, and the listed equipments
+// into one line per equipment with its quantity.
 func (c CamperInput) Equipments() []CamperEquipment {
-	equipments := make([]CamperEquipment, len(c.EquipmentIDs))
+	var equipments []CamperEquipment
+	index := make(map[string]int)
 
-	for i, id := range c.EquipmentIDs {
-		equipments[i] = CamperEquipment{
-			CamperID:    c.ID,
-			EquipmentID: id,
+	add := func(equipmentID string, quantity int) {
+		if quantity < 1 {
+			quantity = 1
 		}
+
+		if i, ok := index[equipmentID]; ok {
+			equipments[i].Quantity += quantity
+			return
+		}
+
+		index[equipmentID] = len(equipments)
+		equipments = append(equipments, CamperEquipment{
+			CamperID:    c.ID,
+			EquipmentID: equipmentID,
+			Quantity:    quantity,
+		})
+	}
+
+	for _, id := range c.EquipmentIDs {
+		add(id, 1)
 	}
+
+	for _, equipment := range c.CamperEquipments {
+		add(equipment.EquipmentID, equipment.Quantity)
+	}
+
 	return equipments
 }
@@ -0,0 +1,42 @@
+package model
+
+import "github.com/shopspring/decimal"
+
+// Camper facets, named after the query parameter each one counts.
+const (
+	CamperFacetTransmission = "transmission"
+	CamperFacetFuelType     = "fuel_type"
+	CamperFacetDrivetrain   = "drivetrain"
+	CamperFacetCondition    = "condition"
+	CamperFacetYear         = "year"
+	CamperFacetCapacity     = "capacity"
+	CamperFacetPrice        = "price"
+	CamperFacetEquipment    = "equipment_id"
+)
+
+// CamperFacets counts the campers matching each value of a filter, so the
+// listing can show how many results picking that value gives. Every facet
+// is counted with all the other filters applied but not its own, so picking
+// one value does not hide the others.
+type CamperFacets struct {
+	Transmission []FacetCount `json:"transmission"`
+	FuelType     []FacetCount `json:"fuel_type"`
+	Drivetrain   []FacetCount `json:"drivetrain"`
+	Condition    []FacetCount `json:"condition"`
+	Year         []FacetCount `json:"year"`
+	Capacity     []FacetCount `json:"capacity"`
+	Equipment    []FacetCount `json:"equipment"`
+	Price        PriceFacet   `json:"price"`
+}
+
+type FacetCount struct {
+	Value string `json:"value"`
+	Label string `json:"label,omitempty"`
+	Count int64  `json:"count"`
+}
+
+// PriceFacet is the range of nightly prices among the matching campers.
+type PriceFacet struct {
+	Min decimal.Decimal `json:"min"`
+	Max decimal.Decimal `json:"max"`
+}
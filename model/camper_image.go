@@ -0,0 +1,57 @@
+package model
+
+import (
+	"context"
+	"time"
+)
+
+type CamperImageRepository interface {
+	FindByCamperID(ctx context.Context, camperID string) ([]CamperImage, error)
+	Upload(ctx context.Context, camperID string, input ImageUpload) (CamperImage, error)
+	Reorder(ctx context.Context, camperID string, input CamperImageOrderInput) ([]CamperImage, error)
+	SetPrimary(ctx context.Context, camperID, id string) (CamperImage, error)
+	Delete(ctx context.Context, camperID, id string) error
+}
+
+// CamperImage is one photo in a camper's gallery. The gallery is shown by
+// position, and its primary image is also the camper's image_url.
+type CamperImage struct {
+	ID          string        `json:"id"`
+	CamperID    string        `json:"camper_id"`
+	StorageKey  string        `json:"-"`
+	URL         string        `json:"url"`
+	Variants    ImageVariants `json:"variants" gorm:"serializer:json"`
+	ContentType string        `json:"content_type"`
+	Size        int           `json:"size"`
+	Position    int           `json:"position"`
+	IsPrimary   bool          `json:"is_primary"`
+	CreatedBy   string        `json:"created_by"`
+	CreatedAt   time.Time     `json:"created_at"`
+}
+
+type CamperImageOrderInput struct {
+	ImageIDs []string `json:"image_ids"`
+}
+
+// Validate checks the new order lists every image of the gallery exactly
+// once.
+func (o CamperImageOrderInput) Validate(images []CamperImage) error {
+	if len(o.ImageIDs) != len(images) {
+		return ErrInvalidCamperImageOrder
+	}
+
+	gallery := make(map[string]bool, len(images))
+	for _, image := range images {
+		gallery[image.ID] = true
+	}
+
+	for _, id := range o.ImageIDs {
+		if !gallery[id] {
+			return ErrInvalidCamperImageOrder
+		}
+
+		delete(gallery, id)
+	}
+
+	return nil
+}
@@ -0,0 +1,106 @@
+package model
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+type CancellationPolicyRepository interface {
+	FindByID(ctx context.Context, id string) (CancellationPolicy, error)
+	FindAll(ctx context.Context) ([]CancellationPolicy, error)
+	Create(ctx context.Context, policy CancellationPolicy) (CancellationPolicy, error)
+	Update(ctx context.Context, id string, policy CancellationPolicy) error
+	Delete(ctx context.Context, id string) error
+}
+
+// CancellationPolicy decides how much of a rental is refunded when it is
+// cancelled. A policy with a CamperID applies to that camper only; the one
+// without is the global policy used for every other camper.
+type CancellationPolicy struct {
+	ID        string             `json:"id"`
+	Name      string             `json:"name"`
+	CamperID  *string            `json:"camper_id"`
+	Tiers     []CancellationTier `json:"tiers" gorm:"foreignKey:PolicyID"`
+	CreatedAt time.Time          `json:"created_at"`
+	UpdatedAt time.Time          `json:"updated_at"`
+}
+
+// CancellationTier refunds RefundRate (0 to 1) of the rental when it is
+// cancelled at least MinHoursBeforeStart hours before the start date.
+type CancellationTier struct {
+	PolicyID            string          `json:"policy_id"`
+	MinHoursBeforeStart int             `json:"min_hours_before_start"`
+	RefundRate          decimal.Decimal `json:"refund_rate"`
+}
+
+// DefaultCancellationPolicy applies when no global policy is configured:
+// a full refund up to 14 days before the start date, half up to 48 hours
+// before, and nothing after that.
+var DefaultCancellationPolicy = CancellationPolicy{
+	Name: "default",
+	Tiers: []CancellationTier{
+		{MinHoursBeforeStart: 14 * 24, RefundRate: decimal.NewFromInt(1)},
+		{MinHoursBeforeStart: 48, RefundRate: decimal.NewFromFloat(0.5)},
+	},
+}
+
+func (p CancellationPolicy) Validate() error {
+	if p.Name == "" {
+		return ErrInvalidCancellationPolicy
+	}
+
+	seen := make(map[int]bool, len(p.Tiers))
+	for _, tier := range p.Tiers {
+		if tier.MinHoursBeforeStart < 0 || seen[tier.MinHoursBeforeStart] {
+			return ErrInvalidCancellationPolicy
+		}
+
+		if tier.RefundRate.IsNegative() || tier.RefundRate.GreaterThan(decimal.NewFromInt(1)) {
+			return ErrInvalidCancellationPolicy
+		}
+
+		seen[tier.MinHoursBeforeStart] = true
+	}
+
+	return nil
+}
+
+// RefundRate returns the rate of the strictest tier the cancellation still
+// qualifies for, or zero when it is too late for any tier.
+func (p CancellationPolicy) RefundRate(hoursBeforeStart float64) decimal.Decimal {
+	tiers := make([]CancellationTier, len(p.Tiers))
+	copy(tiers, p.Tiers)
+
+	sort.Slice(tiers, func(i, j int) bool {
+		return tiers[i].MinHoursBeforeStart > tiers[j].MinHoursBeforeStart
+	})
+
+	for _, tier := range tiers {
+		if hoursBeforeStart >= float64(tier.MinHoursBeforeStart) {
+			return tier.RefundRate
+		}
+	}
+
+	return decimal.Zero
+}
+
+// Refund splits what the customer has paid into the refunded amount and the
+// cancellation fee kept when cancelling at now. The policy's fee is a share
+// of the grand total, so a rental paid less than that gets nothing back and
+// one not paid for at all keeps no fee either.
+func (p CancellationPolicy) Refund(rental Rental, paid decimal.Decimal, now time.Time) (refund, fee decimal.Decimal) {
+	if !paid.IsPositive() {
+		return decimal.Zero, decimal.Zero
+	}
+
+	rate := p.RefundRate(rental.StartDate.Sub(now).Hours())
+	refund = rental.GrandTotal.Mul(rate).Round(2)
+
+	refund = decimal.Min(refund, paid.Sub(rental.GrandTotal.Sub(refund)))
+	refund = decimal.Max(refund, decimal.Zero)
+
+	return refund, paid.Sub(refund)
+}
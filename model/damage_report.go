@@ -0,0 +1,214 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	DamageStatusReported = "reported"
+	DamageStatusAssessed = "assessed"
+	DamageStatusCharged  = "charged"
+	DamageStatusRepaired = "repaired"
+)
+
+const (
+	DamageSeverityMinor    = "minor"
+	DamageSeverityModerate = "moderate"
+	DamageSeveritySevere   = "severe"
+)
+
+const LineItemDamage = "damage"
+
+type DamageReportRepository interface {
+	FindByID(ctx context.Context, id string) (DamageReport, error)
+	FindByRentalID(ctx context.Context, rentalID string) ([]DamageReport, error)
+	Create(ctx context.Context, rentalID string, input DamageReportInput) (DamageReport, error)
+	Assess(ctx context.Context, id string, input DamageAssessInput) (DamageReport, error)
+	Charge(ctx context.Context, id string, input DamageChargeInput) (DamageReport, Payment, error)
+	Repair(ctx context.Context, id string, input DamageRepairInput) (DamageReport, error)
+}
+
+// DamageReport tracks damage found on a camper, or on equipment rented with
+// it, from the moment it is reported until it has been repaired. While
+// BlocksCamper is set and the report is open the camper cannot be booked.
+type DamageReport struct {
+	ID            string          `json:"id"`
+	RentalID      string          `json:"rental_id"`
+	CamperID      string          `json:"camper_id"`
+	EquipmentID   *string         `json:"equipment_id"`
+	Description   string          `json:"description"`
+	Severity      string          `json:"severity"`
+	Photos        []string        `json:"photos" gorm:"serializer:json"`
+	EstimatedCost decimal.Decimal `json:"estimated_cost"`
+	ActualCost    decimal.Decimal `json:"actual_cost"`
+	Status        string          `json:"status"`
+	BlocksCamper  bool            `json:"blocks_camper"`
+	PaymentID     *string         `json:"payment_id"`
+	ReportedBy    string          `json:"reported_by"`
+	AssessedAt    NullTime        `json:"assessed_at"`
+	ChargedAt     NullTime        `json:"charged_at"`
+	RepairedAt    NullTime        `json:"repaired_at"`
+	CreatedAt     time.Time       `json:"created_at"`
+	UpdatedAt     time.Time       `json:"updated_at"`
+}
+
+type DamageReportInput struct {
+	EquipmentID   *string         `json:"equipment_id"`
+	Description   string          `json:"description"`
+	Severity      string          `json:"severity"`
+	Photos        []string        `json:"photos"`
+	EstimatedCost decimal.Decimal `json:"estimated_cost"`
+	BlocksCamper  bool            `json:"blocks_camper"`
+
+	ActorID string `json:"-"`
+}
+
+type DamageAssessInput struct {
+	Severity      string           `json:"severity"`
+	EstimatedCost *decimal.Decimal `json:"estimated_cost"`
+	BlocksCamper  *bool            `json:"blocks_camper"`
+}
+
+type DamageChargeInput struct {
+	Amount *decimal.Decimal `json:"amount"`
+
+	ActorID  string `json:"-"`
+	Provider string `json:"-"`
+}
+
+type DamageRepairInput struct {
+	ActualCost *decimal.Decimal `json:"actual_cost"`
+}
+
+func validSeverity(severity string) bool {
+	switch severity {
+	case DamageSeverityMinor, DamageSeverityModerate, DamageSeveritySevere:
+		return true
+	}
+
+	return false
+}
+
+// Validate checks the report against its rental. Equipment can only be
+// reported damaged when it was rented with the camper.
+func (d DamageReportInput) Validate(rental Rental) error {
+	if d.Description == "" || !validSeverity(d.Severity) || d.EstimatedCost.IsNegative() {
+		return ErrInvalidDamageReport
+	}
+
+	if d.EquipmentID == nil {
+		return nil
+	}
+
+	for _, equipment := range rental.RentalEquipments {
+		if equipment.EquipmentID == *d.EquipmentID {
+			return nil
+		}
+	}
+
+	return ErrEquipmentNotFound
+}
+
+func (d DamageReportInput) ToEntity(id string, rental Rental) DamageReport {
+	return DamageReport{
+		ID:            id,
+		RentalID:      rental.ID,
+		CamperID:      rental.CamperID,
+		EquipmentID:   d.EquipmentID,
+		Description:   d.Description,
+		Severity:      d.Severity,
+		Photos:        d.Photos,
+		EstimatedCost: d.EstimatedCost,
+		Status:        DamageStatusReported,
+		BlocksCamper:  d.BlocksCamper,
+		ReportedBy:    d.ActorID,
+	}
+}
+
+// Assess records the staff assessment of a reported damage.
+func (d *DamageReport) Assess(input DamageAssessInput, now time.Time) error {
+	if d.Status != DamageStatusReported && d.Status != DamageStatusAssessed {
+		return ErrInvalidDamageTransition
+	}
+
+	if input.Severity != "" {
+		if !validSeverity(input.Severity) {
+			return ErrInvalidDamageReport
+		}
+
+		d.Severity = input.Severity
+	}
+
+	if input.EstimatedCost != nil {
+		if input.EstimatedCost.IsNegative() {
+			return ErrInvalidDamageReport
+		}
+
+		d.EstimatedCost = *input.EstimatedCost
+	}
+
+	if input.BlocksCamper != nil {
+		d.BlocksCamper = *input.BlocksCamper
+	}
+
+	d.Status = DamageStatusAssessed
+	d.AssessedAt = NewNullTime(now)
+
+	return nil
+}
+
+// Charge bills the customer for an assessed damage. The amount defaults to
+// the estimated repair cost. The returned line item is added to the rental so
+// its grand total covers the charge.
+func (d *DamageReport) Charge(input DamageChargeInput, now time.Time) (RentalLineItem, error) {
+	if d.Status != DamageStatusAssessed {
+		return RentalLineItem{}, ErrInvalidDamageTransition
+	}
+
+	amount := d.EstimatedCost
+	if input.Amount != nil {
+		amount = *input.Amount
+	}
+
+	if !amount.IsPositive() {
+		return RentalLineItem{}, ErrInvalidPaymentAmount
+	}
+
+	d.Status = DamageStatusCharged
+	d.ChargedAt = NewNullTime(now)
+
+	return RentalLineItem{
+		RentalID:    d.RentalID,
+		Kind:        LineItemDamage,
+		ReferenceID: d.ID,
+		Description: fmt.Sprintf("Damage: %s", d.Description),
+		Quantity:    1,
+		UnitPrice:   amount,
+		Amount:      amount,
+	}, nil
+}
+
+// Repair closes the report. Damage can be repaired without being charged,
+// e.g. when it is covered by the security deposit or waived.
+func (d *DamageReport) Repair(input DamageRepairInput, now time.Time) error {
+	if d.Status == DamageStatusRepaired {
+		return ErrInvalidDamageTransition
+	}
+
+	if input.ActualCost != nil {
+		if input.ActualCost.IsNegative() {
+			return ErrInvalidDamageReport
+		}
+
+		d.ActualCost = *input.ActualCost
+	}
+
+	d.Status = DamageStatusRepaired
+	d.RepairedAt = NewNullTime(now)
+
+	return nil
+}
@@ -3,6 +3,8 @@ package model
 import (
 	"context"
 	"time"
+
+	"github.com/shopspring/decimal"
 )
 
 type DriverRepository interface {
@@ -11,20 +13,24 @@ type DriverRepository interface {
 	Create(ctx context.Context, driver Driver) error
 	Update(ctx context.Context, id string, driver Driver) error
 	Delete(ctx context.Context, id string) error
+	UploadPhoto(ctx context.Context, id string, input ImageUpload) (Driver, error)
 }
 
 type Driver struct {
-	ID            string    `json:"id"`
-	Photo         string    `json:"photo"`
-	Name          string    `json:"name"`
-	IDNumber      string    `json:"id_number"`
-	LicenseNumber string    `json:"license_number"`
-	LicenseExpiry string    `json:"license_expiry"`
-	Phone         string    `json:"phone"`
-	Status        string    `json:"status"`
-	JoinDate      time.Time `json:"join_date"`
-	CreatedAt     time.Time `json:"created_at"`
-	UpdatedAt     time.Time `json:"updated_at"`
+	ID            string          `json:"id"`
+	Photo         string          `json:"photo"`
+	PhotoKey      string          `json:"-"`
+	PhotoVariants ImageVariants   `json:"photo_variants" gorm:"serializer:json"`
+	Name          string          `json:"name"`
+	IDNumber      string          `json:"id_number"`
+	LicenseNumber string          `json:"license_number"`
+	LicenseExpiry string          `json:"license_expiry"`
+	Phone         string          `json:"phone"`
+	Status        string          `json:"status"`
+	DailyRate     decimal.Decimal `json:"daily_rate"`
+	JoinDate      time.Time       `json:"join_date"`
+	CreatedAt     time.Time       `json:"created_at"`
+	UpdatedAt     time.Time       `json:"updated_at"`
 }
 
 type DriverQueryInput struct {
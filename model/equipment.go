@@ -10,22 +10,33 @@ import (
 type EquipmentRepository interface {
 	FindByID(ctx context.Context, id string) (Equipment, error)
 	FindAll(ctx context.Context, query EquipmentQueryInput) ([]Equipment, int64, error)
-	Create(ctx context.Context, equipment Equipment) error
+	FindByIDs(ctx context.Context, ids []string) ([]Equipment, error)
+	Create(ctx context.Context, equipment Equipment, actorID string) (Equipment, error)
 	Update(ctx context.Context, id string, equipment Equipment) error
 	Delete(ctx context.Context, id string) error
+
+	Stock(ctx context.Context, ids []string, from, to time.Time) ([]EquipmentStock, error)
+	FindMovements(ctx context.Context, id string) ([]StockMovement, error)
+	RecordMovement(ctx context.Context, id string, input StockMovementInput) (StockMovement, error)
+	UploadImage(ctx context.Context, id string, input ImageUpload) (Equipment, error)
 }
 
+// Equipment is a kind of item rented with campers. Stock is kept in step
+// with the sum of its stock movements and is never edited directly, and so
+// are the image's key and variants, which are set by uploading an image.
 type Equipment struct {
-	ID          string          `json:"id"`
-	ImageURL    string          `json:"image_url"`
-	Name        string          `json:"name"`
-	Category    string          `json:"category"`
-	Stock       int             `json:"stock"`
-	Price       decimal.Decimal `json:"price"`
-	Description string          `json:"description"`
-	Condition   string          `json:"condition"`
-	CreatedAt   time.Time       `json:"created_at"`
-	UpdatedAt   time.Time       `json:"updated_at"`
+	ID            string          `json:"id"`
+	ImageURL      string          `json:"image_url"`
+	ImageKey      string          `json:"-"`
+	ImageVariants ImageVariants   `json:"image_variants" gorm:"serializer:json"`
+	Name          string          `json:"name"`
+	Category      string          `json:"category"`
+	Stock         int             `json:"stock"`
+	Price         decimal.Decimal `json:"price"`
+	Description   string          `json:"description"`
+	Condition     string          `json:"condition"`
+	CreatedAt     time.Time       `json:"created_at"`
+	UpdatedAt     time.Time       `json:"updated_at"`
 }
 
 type EquipmentQueryInput struct {
@@ -36,4 +47,31 @@ type EquipmentQueryInput struct {
 type CamperEquipment struct {
 	CamperID    string `json:"camper_id"`
 	EquipmentID string `json:"equipment_id"`
+	Quantity    int    `json:"quantity"`
+}
+
+// EquipmentStock is how much of an equipment is left to rent over a date
+// range once the overlapping rentals and holds are subtracted from its stock.
+type EquipmentStock struct {
+	EquipmentID string `json:"equipment_id"`
+	Name        string `json:"name"`
+	Stock       int    `json:"stock"`
+	Reserved    int    `json:"reserved"`
+	Available   int    `json:"available"`
+}
+
+// NewEquipmentStock :nodoc:
+func NewEquipmentStock(equipment Equipment, reserved int) EquipmentStock {
+	available := equipment.Stock - reserved
+	if available < 0 {
+		available = 0
+	}
+
+	return EquipmentStock{
+		EquipmentID: equipment.ID,
+		Name:        equipment.Name,
+		Stock:       equipment.Stock,
+		Reserved:    reserved,
+		Available:   available,
+	}
 }
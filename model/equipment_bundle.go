@@ -0,0 +1,70 @@
+package model
+
+import (
+	"context"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+const LineItemBundle = "bundle"
+
+type EquipmentBundleRepository interface {
+	FindByID(ctx context.Context, id string) (EquipmentBundle, error)
+	FindAll(ctx context.Context) ([]EquipmentBundle, error)
+	FindByIDs(ctx context.Context, ids []string) ([]EquipmentBundle, error)
+	Create(ctx context.Context, bundle EquipmentBundle) (EquipmentBundle, error)
+	Update(ctx context.Context, id string, bundle EquipmentBundle) error
+	Delete(ctx context.Context, id string) error
+}
+
+// EquipmentBundle is a kit of equipment, such as a kitchen kit, rented as
+// one item at its own nightly price. Booking a bundle reserves the stock of
+// every equipment in it.
+type EquipmentBundle struct {
+	ID          string                `json:"id"`
+	Name        string                `json:"name"`
+	Description string                `json:"description"`
+	Price       decimal.Decimal       `json:"price"`
+	Items       []EquipmentBundleItem `json:"items" gorm:"foreignKey:BundleID"`
+	CreatedAt   time.Time             `json:"created_at"`
+	UpdatedAt   time.Time             `json:"updated_at"`
+}
+
+type EquipmentBundleItem struct {
+	BundleID    string `json:"bundle_id"`
+	EquipmentID string `json:"equipment_id"`
+	Quantity    int    `json:"quantity"`
+}
+
+type RentalBundle struct {
+	RentalID string `json:"rental_id"`
+	BundleID string `json:"bundle_id"`
+	Quantity int    `json:"quantity"`
+}
+
+func (b EquipmentBundle) Validate() error {
+	if b.Name == "" || b.Price.IsNegative() || len(b.Items) == 0 {
+		return ErrInvalidEquipmentBundle
+	}
+
+	seen := make(map[string]bool, len(b.Items))
+	for _, item := range b.Items {
+		if item.EquipmentID == "" || item.Quantity < 1 || seen[item.EquipmentID] {
+			return ErrInvalidEquipmentBundle
+		}
+
+		seen[item.EquipmentID] = true
+	}
+
+	return nil
+}
+
+// EquipmentIDs lists the equipment in the bundle.
+func (b EquipmentBundle) EquipmentIDs() []string {
+	ids := make([]string, len(b.Items))
+	for i, item := range b.Items {
+		ids[i] = item.EquipmentID
+	}
+	return ids
+}
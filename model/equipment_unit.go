@@ -0,0 +1,185 @@
+package model
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	EquipmentUnitStatusAvailable   = "available"
+	EquipmentUnitStatusRented      = "rented"
+	EquipmentUnitStatusMaintenance = "maintenance"
+	EquipmentUnitStatusRetired     = "retired"
+)
+
+type EquipmentUnitRepository interface {
+	FindByID(ctx context.Context, equipmentID, id string) (EquipmentUnit, error)
+	FindByEquipmentID(ctx context.Context, equipmentID string) ([]EquipmentUnit, error)
+	Create(ctx context.Context, equipmentID string, input EquipmentUnitInput) (EquipmentUnit, error)
+	Update(ctx context.Context, equipmentID, id string, input EquipmentUnitInput) (EquipmentUnit, error)
+	Delete(ctx context.Context, equipmentID, id string) error
+
+	FindByRentalID(ctx context.Context, rentalID string) ([]RentalEquipmentUnit, error)
+	Assign(ctx context.Context, rentalID string, input EquipmentUnitAssignInput) ([]RentalEquipmentUnit, error)
+}
+
+// EquipmentUnit is one physical item of an equipment, e.g. a single
+// generator, identified by its serial number and the asset tag stuck on it.
+type EquipmentUnit struct {
+	ID           string    `json:"id"`
+	EquipmentID  string    `json:"equipment_id"`
+	SerialNumber string    `json:"serial_number"`
+	AssetTag     string    `json:"asset_tag"`
+	Condition    string    `json:"condition"`
+	Status       string    `json:"status"`
+	Notes        string    `json:"notes"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+type EquipmentUnitInput struct {
+	SerialNumber string `json:"serial_number"`
+	AssetTag     string `json:"asset_tag"`
+	Condition    string `json:"condition"`
+	Status       string `json:"status"`
+	Notes        string `json:"notes"`
+}
+
+// RentalEquipmentUnit records which unit of an equipment on the rental,
+// listed on its own or in a bundle, went out from check-out until it is
+// returned.
+type RentalEquipmentUnit struct {
+	RentalID    string         `json:"rental_id"`
+	EquipmentID string         `json:"equipment_id"`
+	UnitID      string         `json:"unit_id"`
+	AssignedBy  string         `json:"assigned_by"`
+	AssignedAt  time.Time      `json:"assigned_at"`
+	ReturnedAt  NullTime       `json:"returned_at"`
+	Unit        *EquipmentUnit `json:"unit,omitempty" gorm:"foreignKey:UnitID"`
+}
+
+type EquipmentUnitAssignInput struct {
+	UnitIDs []string `json:"unit_ids"`
+
+	ActorID string `json:"-"`
+}
+
+// RequestedUnitIDs lists each requested unit once.
+func (i EquipmentUnitAssignInput) RequestedUnitIDs() []string {
+	var ids []string
+	seen := make(map[string]bool, len(i.UnitIDs))
+
+	for _, id := range i.UnitIDs {
+		if id == "" || seen[id] {
+			continue
+		}
+
+		seen[id] = true
+		ids = append(ids, id)
+	}
+
+	return ids
+}
+
+// Validate checks a new or updated unit. Units are only marked rented by
+// being assigned to a rental, never directly.
+func (u EquipmentUnitInput) Validate() error {
+	switch u.Status {
+	case "", EquipmentUnitStatusAvailable, EquipmentUnitStatusMaintenance, EquipmentUnitStatusRetired:
+	default:
+		return ErrInvalidEquipmentUnit
+	}
+
+	return nil
+}
+
+// ValidateCreate checks a new unit, which needs its serial number, asset
+// tag and condition.
+func (u EquipmentUnitInput) ValidateCreate() error {
+	if u.SerialNumber == "" || u.AssetTag == "" || u.Condition == "" {
+		return ErrInvalidEquipmentUnit
+	}
+
+	return u.Validate()
+}
+
+func (u EquipmentUnitInput) ToEntity(id, equipmentID string) EquipmentUnit {
+	status := u.Status
+	if status == "" {
+		status = EquipmentUnitStatusAvailable
+	}
+
+	return EquipmentUnit{
+		ID:           id,
+		EquipmentID:  equipmentID,
+		SerialNumber: u.SerialNumber,
+		AssetTag:     u.AssetTag,
+		Condition:    u.Condition,
+		Status:       status,
+		Notes:        u.Notes,
+	}
+}
+
+// Apply copies the fields set in input onto the unit. A rented unit keeps
+// its status until the rental returns it.
+func (u *EquipmentUnit) Apply(input EquipmentUnitInput) error {
+	if input.Status != "" && input.Status != u.Status && u.Status == EquipmentUnitStatusRented {
+		return ErrEquipmentUnitUnavailable
+	}
+
+	if input.SerialNumber != "" {
+		u.SerialNumber = input.SerialNumber
+	}
+
+	if input.AssetTag != "" {
+		u.AssetTag = input.AssetTag
+	}
+
+	if input.Condition != "" {
+		u.Condition = input.Condition
+	}
+
+	if input.Status != "" {
+		u.Status = input.Status
+	}
+
+	if input.Notes != "" {
+		u.Notes = input.Notes
+	}
+
+	return nil
+}
+
+// ValidateUnitAssignment checks that units can go out on the rental at
+// check-out: the rental is confirmed or active, every unit is available and
+// belongs to equipment on the rental, listed on its own or in one of its
+// bundles, and no equipment gets more units than the rental takes from
+// stock, counting units already assigned. items are the contents of the
+// rental's bundles.
+func (r Rental) ValidateUnitAssignment(units []EquipmentUnit, assigned []RentalEquipmentUnit, items []EquipmentBundleItem) error {
+	if r.Status != RentalStatusConfirmed && r.Status != RentalStatusActive {
+		return ErrUnitAssignmentNotAllowed
+	}
+
+	remaining := RentalInput{Rental: r}.RequestedStock(items)
+
+	for _, assignment := range assigned {
+		if !assignment.ReturnedAt.Valid {
+			remaining[assignment.EquipmentID]--
+		}
+	}
+
+	for _, unit := range units {
+		if unit.Status != EquipmentUnitStatusAvailable {
+			return ErrEquipmentUnitUnavailable
+		}
+
+		if remaining[unit.EquipmentID] < 1 {
+			return ErrInvalidUnitAssignment
+		}
+
+		remaining[unit.EquipmentID]--
+	}
+
+	return nil
+}
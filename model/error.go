@@ -8,5 +8,67 @@ var (
 	ErrRegisterRequired  = errors.New("register required")
 	ErrForbidden         = errors.New("forbidden request")
 	ErrDuplicateIDNumber = errors.New("duplicate id number")
-	ErrRentalCancelled   = errors.New("rental cancelled")
+	ErrRentalNotEditable = errors.New("only pending rentals can be edited")
+
+	ErrInvalidRentalTransition = errors.New("invalid rental status transition")
+	ErrHoldExpired             = errors.New("rental hold has expired")
+
+	ErrInvalidCancellationPolicy = errors.New("invalid cancellation policy")
+	ErrOverrideReasonRequired    = errors.New("a reason is required to override the refund")
+	ErrInvalidRefundAmount       = errors.New("refund amount must be between zero and the amount paid")
+
+	ErrInvalidPaymentType      = errors.New("invalid payment type")
+	ErrInvalidPaymentAmount    = errors.New("payment amount must be positive")
+	ErrDepositNotPaid          = errors.New("deposit has not been paid")
+	ErrInvalidWebhookSignature = errors.New("invalid webhook signature")
+	ErrInvalidPaymentEvent     = errors.New("payment events must report the payment as paid or failed")
+
+	ErrInvalidSecurityDepositAction  = errors.New("security deposit cannot be changed in its current state")
+	ErrInvalidSecurityDepositAmount  = errors.New("invalid security deposit amount")
+	ErrSecurityDepositReasonRequired = errors.New("a reason is required to capture the security deposit")
+
+	ErrRentalNotInvoiceable = errors.New("only confirmed, active or completed rentals can be invoiced")
+	ErrNothingToDebit       = errors.New("no charges left to bill on a debit note")
+	ErrNotCreditable        = errors.New("only settled refunds can be credited")
+	ErrCamperUnavailable    = errors.New("camper is not available for the requested dates")
+	ErrDriverUnavailable    = errors.New("driver is not available for the requested dates")
+	ErrEquipmentOutOfStock  = errors.New("equipment is out of stock for the requested dates")
+	ErrInvalidRentalPeriod  = errors.New("rental end date must be after start date")
+	ErrInvalidRentalType    = errors.New("rental type does not match the rental period")
+	ErrCamperNotFound       = errors.New("camper not found")
+	ErrEquipmentNotFound    = errors.New("equipment not found")
+	ErrBundleNotFound       = errors.New("equipment bundle not found")
+	ErrDriverNotFound       = errors.New("driver not found")
+
+	ErrInvalidInspection    = errors.New("invalid inspection")
+	ErrInspectionNotAllowed = errors.New("inspection cannot be recorded in the rental's current state")
+
+	ErrInvalidDamageReport     = errors.New("invalid damage report")
+	ErrInvalidDamageTransition = errors.New("damage report cannot be changed in its current status")
+
+	ErrInvalidEndDateChange = errors.New("invalid end date for this change")
+
+	ErrInvalidEquipmentBundle = errors.New("invalid equipment bundle")
+
+	ErrInvalidStockMovement = errors.New("invalid stock movement")
+	ErrInsufficientStock    = errors.New("stock cannot go below zero")
+
+	ErrInvalidEquipmentUnit     = errors.New("invalid equipment unit")
+	ErrEquipmentUnitNotFound    = errors.New("equipment unit not found")
+	ErrEquipmentUnitUnavailable = errors.New("equipment unit is not available")
+	ErrInvalidUnitAssignment    = errors.New("unit does not match an open equipment line of the rental")
+	ErrUnitAssignmentNotAllowed = errors.New("units can only be assigned to confirmed or active rentals")
+
+	ErrInvalidImage            = errors.New("images must be JPEG, PNG or WebP")
+	ErrImageTooLarge           = errors.New("image is too large")
+	ErrCamperImageNotFound     = errors.New("camper image not found")
+	ErrInvalidCamperImageOrder = errors.New("image order must list every image of the camper once")
+
+	ErrInvalidAvailabilityRange = errors.New("invalid availability range")
+	ErrInvalidCamperFilter      = errors.New("invalid camper filter")
+	ErrInvalidSearchQuery       = errors.New("invalid search query")
+	ErrInvalidMaintenanceWindow = errors.New("maintenance end date must be after start date")
+	ErrInvalidOdometerReading   = errors.New("odometer reading cannot be lower than the camper's last reading")
+	ErrInvalidServiceRecord     = errors.New("invalid service record")
+	ErrServiceAlreadyCompleted  = errors.New("service has already been completed")
 )
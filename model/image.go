@@ -0,0 +1,29 @@
+package model
+
+import (
+	"context"
+	"io"
+)
+
+// MaxImageSize is the largest image file, in bytes, that can be uploaded.
+const MaxImageSize = 10 << 20
+
+// FileStorage keeps uploaded files under a key and serves them from the URL
+// it returns for that key.
+type FileStorage interface {
+	Put(ctx context.Context, key, contentType string, body io.Reader) error
+	Delete(ctx context.Context, key string) error
+	URL(key string) string
+}
+
+// ImageUpload is an image file read from a multipart upload.
+type ImageUpload struct {
+	Filename string
+	Content  []byte
+
+	ActorID string
+}
+
+// ImageVariants are the URLs of an image's resized copies, keyed by their
+// width in pixels, so clients can pick the size they need.
+type ImageVariants map[string]string
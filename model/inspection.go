@@ -0,0 +1,202 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	InspectionTypeCheckOut = "check_out"
+	InspectionTypeCheckIn  = "check_in"
+)
+
+const (
+	CleanlinessClean      = "clean"
+	CleanlinessAcceptable = "acceptable"
+	CleanlinessDirty      = "dirty"
+)
+
+const (
+	LineItemFuel     = "fuel"
+	LineItemCleaning = "cleaning"
+)
+
+type InspectionRepository interface {
+	FindByRentalID(ctx context.Context, rentalID string) (InspectionReport, error)
+	Create(ctx context.Context, rentalID string, input RentalInspectionInput) (RentalInspection, error)
+}
+
+// RentalInspection is the state of a camper recorded by staff when it is
+// handed over (check-out) or received back (check-in).
+type RentalInspection struct {
+	ID          string                `json:"id"`
+	RentalID    string                `json:"rental_id"`
+	CamperID    string                `json:"camper_id"`
+	Type        string                `json:"type"`
+	Odometer    int                   `json:"odometer"`
+	FuelLevel   int                   `json:"fuel_level"`
+	Cleanliness string                `json:"cleanliness"`
+	Condition   string                `json:"condition"`
+	Checklist   []InspectionCheckItem `json:"checklist" gorm:"serializer:json"`
+	Photos      []string              `json:"photos" gorm:"serializer:json"`
+	Notes       string                `json:"notes"`
+	InspectedBy string                `json:"inspected_by"`
+	CreatedAt   time.Time             `json:"created_at"`
+}
+
+type InspectionCheckItem struct {
+	Name string `json:"name"`
+	OK   bool   `json:"ok"`
+	Note string `json:"note,omitempty"`
+}
+
+type RentalInspectionInput struct {
+	Type        string                `json:"type"`
+	Odometer    int                   `json:"odometer"`
+	FuelLevel   int                   `json:"fuel_level"`
+	Cleanliness string                `json:"cleanliness"`
+	Condition   string                `json:"condition"`
+	Checklist   []InspectionCheckItem `json:"checklist"`
+	Photos      []string              `json:"photos"`
+	Notes       string                `json:"notes"`
+
+	ActorID string `json:"-"`
+}
+
+// InspectionRates prices what a returned camper costs the customer on top of
+// the rental: refuelling per percent of tank below the check-out level and a
+// flat fee when it comes back dirty.
+type InspectionRates struct {
+	FuelPerPercent decimal.Decimal
+	CleaningFee    decimal.Decimal
+}
+
+// InspectionComparison is the difference between a rental's check-out and
+// check-in inspections.
+type InspectionComparison struct {
+	DistanceDriven int              `json:"distance_driven"`
+	FuelDifference int              `json:"fuel_difference"`
+	ExtraCharges   []RentalLineItem `json:"extra_charges"`
+	ExtraTotal     decimal.Decimal  `json:"extra_total"`
+}
+
+type InspectionReport struct {
+	CheckOut   *RentalInspection     `json:"check_out"`
+	CheckIn    *RentalInspection     `json:"check_in"`
+	Comparison *InspectionComparison `json:"comparison"`
+}
+
+// NewInspectionReport pairs up a rental's inspections and compares them once
+// both have been recorded.
+func NewInspectionReport(inspections []RentalInspection, rates InspectionRates) InspectionReport {
+	var report InspectionReport
+
+	for i := range inspections {
+		switch inspections[i].Type {
+		case InspectionTypeCheckOut:
+			report.CheckOut = &inspections[i]
+		case InspectionTypeCheckIn:
+			report.CheckIn = &inspections[i]
+		}
+	}
+
+	if report.CheckOut != nil && report.CheckIn != nil {
+		comparison := CompareInspections(*report.CheckOut, *report.CheckIn, rates)
+		report.Comparison = &comparison
+	}
+
+	return report
+}
+
+// Validate checks the input against the rental and its recorded inspection.
+// A camper is checked out once before the rental starts and checked in once
+// while it is active.
+func (i RentalInspectionInput) Validate(rental Rental, report InspectionReport) error {
+	if i.FuelLevel < 0 || i.FuelLevel > 100 || i.Odometer < 0 {
+		return ErrInvalidInspection
+	}
+
+	switch i.Cleanliness {
+	case CleanlinessClean, CleanlinessAcceptable, CleanlinessDirty:
+	default:
+		return ErrInvalidInspection
+	}
+
+	switch i.Type {
+	case InspectionTypeCheckOut:
+		if rental.Status != RentalStatusConfirmed || report.CheckOut != nil {
+			return ErrInspectionNotAllowed
+		}
+	case InspectionTypeCheckIn:
+		if rental.Status != RentalStatusActive || report.CheckOut == nil || report.CheckIn != nil {
+			return ErrInspectionNotAllowed
+		}
+
+		if i.Odometer < report.CheckOut.Odometer {
+			return ErrInvalidInspection
+		}
+	default:
+		return ErrInvalidInspection
+	}
+
+	return nil
+}
+
+func (i RentalInspectionInput) ToEntity(id string, rental Rental) RentalInspection {
+	return RentalInspection{
+		ID:          id,
+		RentalID:    rental.ID,
+		CamperID:    rental.CamperID,
+		Type:        i.Type,
+		Odometer:    i.Odometer,
+		FuelLevel:   i.FuelLevel,
+		Cleanliness: i.Cleanliness,
+		Condition:   i.Condition,
+		Checklist:   i.Checklist,
+		Photos:      i.Photos,
+		Notes:       i.Notes,
+		InspectedBy: i.ActorID,
+	}
+}
+
+// CompareInspections works out the distance driven and fuel used between
+// check-out and check-in, and the extra charges owed for them.
+func CompareInspections(checkOut, checkIn RentalInspection, rates InspectionRates) InspectionComparison {
+	comparison := InspectionComparison{
+		DistanceDriven: checkIn.Odometer - checkOut.Odometer,
+		FuelDifference: checkIn.FuelLevel - checkOut.FuelLevel,
+	}
+
+	if comparison.FuelDifference < 0 && rates.FuelPerPercent.IsPositive() {
+		missing := -comparison.FuelDifference
+		comparison.ExtraCharges = append(comparison.ExtraCharges, RentalLineItem{
+			Kind:        LineItemFuel,
+			ReferenceID: checkIn.ID,
+			Description: fmt.Sprintf("Refuelling %d%% of tank", missing),
+			Quantity:    missing,
+			UnitPrice:   rates.FuelPerPercent,
+			Amount:      rates.FuelPerPercent.Mul(decimal.NewFromInt(int64(missing))).Round(2),
+		})
+	}
+
+	if checkIn.Cleanliness == CleanlinessDirty && rates.CleaningFee.IsPositive() {
+		comparison.ExtraCharges = append(comparison.ExtraCharges, RentalLineItem{
+			Kind:        LineItemCleaning,
+			ReferenceID: checkIn.ID,
+			Description: "Cleaning fee",
+			Quantity:    1,
+			UnitPrice:   rates.CleaningFee,
+			Amount:      rates.CleaningFee,
+		})
+	}
+
+	comparison.ExtraTotal = decimal.Zero
+	for _, item := range comparison.ExtraCharges {
+		comparison.ExtraTotal = comparison.ExtraTotal.Add(item.Amount)
+	}
+
+	return comparison
+}
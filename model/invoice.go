@@ -0,0 +1,216 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	InvoiceTypeInvoice    = "invoice"
+	InvoiceTypeCreditNote = "credit_note"
+	InvoiceTypeDebitNote  = "debit_note"
+)
+
+// LineItemPriceChange is the debit note line billing an increase of the
+// rental's priced total, e.g. after an extension.
+const LineItemPriceChange = "price_change"
+
+type InvoiceRepository interface {
+	FindByRentalID(ctx context.Context, rentalID string) ([]Invoice, error)
+	FindByID(ctx context.Context, id string) (Invoice, error)
+	IssueInvoice(ctx context.Context, rentalID string) (Invoice, error)
+	IssueCreditNote(ctx context.Context, refund Payment) (Invoice, error)
+	IssueDebitNote(ctx context.Context, rentalID string) (Invoice, error)
+}
+
+// CompanyDetails is printed on every invoice and credit note.
+type CompanyDetails struct {
+	Name    string `json:"name"`
+	Address string `json:"address"`
+	Email   string `json:"email"`
+	Phone   string `json:"phone"`
+	TaxID   string `json:"tax_id"`
+}
+
+// Invoice is an issued invoice, credit note or debit note. Its number and
+// Document are fixed when it is issued and never change afterwards, so a
+// re-downloaded PDF always matches the original.
+type Invoice struct {
+	ID              string          `json:"id"`
+	Number          string          `json:"number"`
+	Type            string          `json:"type"`
+	RentalID        string          `json:"rental_id"`
+	PaymentID       *string         `json:"payment_id"`
+	ReferenceNumber string          `json:"reference_number,omitempty"`
+	Total           decimal.Decimal `json:"total"`
+	Document        InvoiceDocument `json:"document" gorm:"serializer:json"`
+	IssuedAt        time.Time       `json:"issued_at"`
+	CreatedAt       time.Time       `json:"created_at"`
+}
+
+// InvoiceDocument is the snapshot of everything printed on an invoice.
+type InvoiceDocument struct {
+	Company    CompanyDetails   `json:"company"`
+	Customer   User             `json:"customer"`
+	Camper     Camper           `json:"camper"`
+	Driver     *Driver          `json:"driver,omitempty"`
+	Rental     Rental           `json:"rental"`
+	LineItems  []RentalLineItem `json:"line_items"`
+	Discount   decimal.Decimal  `json:"discount"`
+	Tax        decimal.Decimal  `json:"tax"`
+	Total      decimal.Decimal  `json:"total"`
+	Payments   []Payment        `json:"payments"`
+	AmountPaid decimal.Decimal  `json:"amount_paid"`
+	BalanceDue decimal.Decimal  `json:"balance_due"`
+}
+
+// IsInvoiceable reports whether the rental is booked firmly enough to be
+// invoiced. Invoice numbers are sequential and never voided, so held,
+// pending and cancelled rentals must not use one up.
+func (r Rental) IsInvoiceable() bool {
+	switch r.Status {
+	case RentalStatusConfirmed, RentalStatusActive, RentalStatusCompleted:
+		return true
+	}
+
+	return false
+}
+
+// InvoiceNumber formats the sequence number of a document issued in year,
+// e.g. INV-2025-000042, CN-2025-000003 or DN-2025-000007.
+func InvoiceNumber(invoiceType string, year, sequence int) string {
+	prefix := "INV"
+	switch invoiceType {
+	case InvoiceTypeCreditNote:
+		prefix = "CN"
+	case InvoiceTypeDebitNote:
+		prefix = "DN"
+	}
+
+	return fmt.Sprintf("%s-%d-%06d", prefix, year, sequence)
+}
+
+// NewInvoiceDocument snapshots a rental for invoicing. Only settled payments
+// are listed as received.
+func NewInvoiceDocument(company CompanyDetails, customer User, camper Camper, driver *Driver, rental Rental, payments []Payment) InvoiceDocument {
+	doc := InvoiceDocument{
+		Company:   company,
+		Customer:  customer,
+		Camper:    camper,
+		Driver:    driver,
+		Rental:    rental,
+		LineItems: rental.LineItems,
+		Discount:  rental.Discount,
+		Total:     rental.GrandTotal,
+	}
+
+	doc.Rental.LineItems = nil
+	doc.Rental.RentalEquipments = nil
+	doc.Rental.RentalBundles = nil
+
+	for _, item := range rental.LineItems {
+		if item.Kind == LineItemTax {
+			doc.Tax = doc.Tax.Add(item.Amount)
+		}
+	}
+
+	for _, payment := range payments {
+		if payment.Status == PaymentStatusPaid && payment.Type != PaymentTypeRefund {
+			doc.Payments = append(doc.Payments, payment)
+		}
+	}
+
+	doc.AmountPaid = NewPaymentLedger(payments).Paid
+	doc.BalanceDue = doc.Total.Sub(doc.AmountPaid)
+
+	return doc
+}
+
+// NewCreditNoteDocument snapshots a refund against an issued invoice.
+func NewCreditNoteDocument(invoice Invoice, refund Payment) InvoiceDocument {
+	doc := invoice.Document
+	doc.LineItems = []RentalLineItem{
+		{
+			Kind:        PaymentTypeRefund,
+			ReferenceID: refund.ID,
+			Description: fmt.Sprintf("Refund against invoice %s", invoice.Number),
+			Quantity:    1,
+			UnitPrice:   refund.Amount.Neg(),
+			Amount:      refund.Amount.Neg(),
+		},
+	}
+	doc.Discount = decimal.Zero
+	doc.Tax = decimal.Zero
+	doc.Total = refund.Amount.Neg()
+	doc.Payments = []Payment{refund}
+	doc.AmountPaid = decimal.Zero
+	doc.BalanceDue = decimal.Zero
+
+	return doc
+}
+
+// UninvoicedCharges lists what the rental has been charged beyond invoices,
+// its invoice and any debit notes: every extra line item, such as a late fee
+// or damage, that none of them lists, and one line for any increase of the
+// priced total. A priced total that went down is settled by a refund and its
+// credit note instead.
+func (r Rental) UninvoicedCharges(invoices []Invoice) []RentalLineItem {
+	billed := make(map[string]bool)
+	pricedBilled := decimal.Zero
+
+	for _, invoice := range invoices {
+		for _, item := range invoice.Document.LineItems {
+			billed[item.ID] = true
+
+			if item.IsPriced() || item.Kind == LineItemPriceChange {
+				pricedBilled = pricedBilled.Add(item.Amount)
+			}
+		}
+	}
+
+	var charges []RentalLineItem
+	for _, item := range r.LineItems {
+		if !item.IsPriced() && !billed[item.ID] {
+			charges = append(charges, item)
+		}
+	}
+
+	delta := r.PricedTotal().Sub(pricedBilled)
+	if delta.IsPositive() {
+		charges = append(charges, RentalLineItem{
+			RentalID:    r.ID,
+			Kind:        LineItemPriceChange,
+			Description: fmt.Sprintf("Rental period changed to %s - %s", r.StartDate.Format("2 Jan 2006"), r.EndDate.Format("2 Jan 2006")),
+			Quantity:    1,
+			UnitPrice:   delta,
+			Amount:      delta,
+		})
+	}
+
+	return charges
+}
+
+// NewDebitNoteDocument snapshots charges billed on top of an issued invoice.
+func NewDebitNoteDocument(invoice Invoice, rental Rental, charges []RentalLineItem) InvoiceDocument {
+	doc := invoice.Document
+	doc.Rental = rental
+	doc.Rental.LineItems = nil
+	doc.Rental.RentalEquipments = nil
+	doc.Rental.RentalBundles = nil
+	doc.LineItems = charges
+	doc.Discount = decimal.Zero
+	doc.Tax = decimal.Zero
+	doc.Total = decimal.Zero
+	doc.Payments = nil
+	doc.AmountPaid = decimal.Zero
+	doc.BalanceDue = decimal.Zero
+
+	for _, item := range charges {
+		doc.Total = doc.Total.Add(item.Amount)
+	}
+
+	return doc
+}
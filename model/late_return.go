@@ -0,0 +1,70 @@
+package model
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+const LineItemLateFee = "late_fee"
+
+// ReturnDueTime is when on its end date a rental is due back.
+const ReturnDueTime = 12 * time.Hour
+
+// LateReturnImpactWindow is how far ahead bookings of the same camper are
+// considered impacted by an overdue rental. They cannot be started, and no
+// new booking may start within it, until the camper has been returned.
+const LateReturnImpactWindow = 24 * time.Hour
+
+// OverdueRental is a rental flagged as overdue by the late return check,
+// together with the bookings of the same camper it puts at risk.
+type OverdueRental struct {
+	Rental   Rental   `json:"rental"`
+	Impacted []Rental `json:"impacted"`
+}
+
+// Notifier tells staff about events that need their attention.
+type Notifier interface {
+	NotifyStaff(ctx context.Context, notification Notification) error
+}
+
+type Notification struct {
+	Subject string `json:"subject"`
+	Message string `json:"message"`
+}
+
+// ReturnDue is the moment the camper is expected back.
+func (r Rental) ReturnDue() time.Time {
+	return r.EndDate.Add(ReturnDueTime)
+}
+
+// IsOverdue reports whether the rental is still out past its return time.
+func (r Rental) IsOverdue(now time.Time) bool {
+	return r.Status == RentalStatusActive && now.After(r.ReturnDue())
+}
+
+// LateFee charges every started hour past the return time at the camper's
+// hourly rate, capping each day at its daily rate. Without a daily rate all
+// hours are charged at the hourly rate.
+func (c Camper) LateFee(rental Rental, returnedAt time.Time) decimal.Decimal {
+	late := returnedAt.Sub(rental.ReturnDue())
+	if late <= 0 {
+		return decimal.Zero
+	}
+
+	hours := int64(math.Ceil(late.Hours()))
+	if !c.LateFeeDaily.IsPositive() {
+		return c.LateFeeHourly.Mul(decimal.NewFromInt(hours)).Round(2)
+	}
+
+	fee := c.LateFeeDaily.Mul(decimal.NewFromInt(hours / 24))
+
+	partial := c.LateFeeHourly.Mul(decimal.NewFromInt(hours % 24))
+	if partial.GreaterThan(c.LateFeeDaily) {
+		partial = c.LateFeeDaily
+	}
+
+	return fee.Add(partial).Round(2)
+}
@@ -0,0 +1,183 @@
+package model
+
+import (
+	"context"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	ServiceStatusScheduled = "scheduled"
+	ServiceStatusCompleted = "completed"
+)
+
+const (
+	ServiceTypeGeneral    = "general"
+	ServiceTypeOilChange  = "oil_change"
+	ServiceTypeTyres      = "tyres"
+	ServiceTypeBrakes     = "brakes"
+	ServiceTypeInspection = "inspection"
+)
+
+// ServicedCondition is the condition a camper is left in after a service
+// when the workshop does not report one.
+const ServicedCondition = "good"
+
+type MaintenanceRepository interface {
+	FindByID(ctx context.Context, id string) (ServiceRecord, error)
+	FindByCamperID(ctx context.Context, camperID string) ([]ServiceRecord, error)
+	Schedule(ctx context.Context, camperID string, input ServiceRecordInput) (ServiceRecord, error)
+	Complete(ctx context.Context, id string, input ServiceCompleteInput) (ServiceRecord, error)
+	Due(ctx context.Context, query MaintenanceDueQueryInput) ([]ServiceRecord, error)
+}
+
+// ServiceRecord is a service planned for a camper and, once completed, the
+// log of what the workshop did. A service is due by date, by odometer or
+// both. When it has a start and end date the camper is booked into the
+// workshop with a maintenance window, which blocks rentals.
+type ServiceRecord struct {
+	ID                  string          `json:"id"`
+	CamperID            string          `json:"camper_id"`
+	Type                string          `json:"type"`
+	Status              string          `json:"status"`
+	DueDate             NullTime        `json:"due_date"`
+	DueOdometer         *int            `json:"due_odometer"`
+	Workshop            string          `json:"workshop"`
+	Cost                decimal.Decimal `json:"cost"`
+	MaintenanceWindowID *string         `json:"maintenance_window_id"`
+	CompletedAt         NullTime        `json:"completed_at"`
+	CompletionNotes     string          `json:"completion_notes"`
+	CreatedBy           string          `json:"created_by"`
+	CreatedAt           time.Time       `json:"created_at"`
+	UpdatedAt           time.Time       `json:"updated_at"`
+
+	MaintenanceWindow *MaintenanceWindow `json:"maintenance_window,omitempty" gorm:"foreignKey:MaintenanceWindowID"`
+}
+
+type ServiceRecordInput struct {
+	Type        string          `json:"type"`
+	DueDate     NullTime        `json:"due_date"`
+	DueOdometer *int            `json:"due_odometer"`
+	Workshop    string          `json:"workshop"`
+	Cost        decimal.Decimal `json:"cost"`
+	StartDate   NullTime        `json:"start_date"`
+	EndDate     NullTime        `json:"end_date"`
+
+	ActorID string `json:"-"`
+}
+
+type ServiceCompleteInput struct {
+	Cost      *decimal.Decimal `json:"cost"`
+	Condition string           `json:"condition"`
+	Notes     string           `json:"notes"`
+	Odometer  *int             `json:"odometer"`
+
+	ActorID string `json:"-"`
+}
+
+type MaintenanceDueQueryInput struct {
+	WithinDays int `query:"within_days"`
+	WithinKm   int `query:"within_km"`
+}
+
+// DefaultMaintenanceDueDays and DefaultMaintenanceDueKm are how far ahead
+// the due report looks when within_days or within_km is not given.
+const (
+	DefaultMaintenanceDueDays = 30
+	DefaultMaintenanceDueKm   = 1000
+)
+
+func validServiceType(serviceType string) bool {
+	switch serviceType {
+	case ServiceTypeGeneral, ServiceTypeOilChange, ServiceTypeTyres, ServiceTypeBrakes, ServiceTypeInspection:
+		return true
+	}
+
+	return false
+}
+
+// Validate checks a service to be scheduled. It must say when it is due,
+// and a workshop booking needs both its dates in order.
+func (s ServiceRecordInput) Validate() error {
+	if !validServiceType(s.Type) || s.Cost.IsNegative() {
+		return ErrInvalidServiceRecord
+	}
+
+	if !s.DueDate.Valid && s.DueOdometer == nil && !s.StartDate.Valid {
+		return ErrInvalidServiceRecord
+	}
+
+	if s.DueOdometer != nil && *s.DueOdometer < 0 {
+		return ErrInvalidServiceRecord
+	}
+
+	if s.StartDate.Valid != s.EndDate.Valid {
+		return ErrInvalidServiceRecord
+	}
+
+	if s.StartDate.Valid && !s.EndDate.Time.After(s.StartDate.Time) {
+		return ErrInvalidMaintenanceWindow
+	}
+
+	return nil
+}
+
+// Window is the workshop booking of the service, if it has one.
+func (s ServiceRecordInput) Window(camperID string) (MaintenanceWindow, bool) {
+	if !s.StartDate.Valid {
+		return MaintenanceWindow{}, false
+	}
+
+	return MaintenanceWindow{
+		CamperID:  camperID,
+		StartDate: s.StartDate.Time,
+		EndDate:   s.EndDate.Time,
+		Reason:    "service: " + s.Type,
+	}, true
+}
+
+func (s ServiceRecordInput) ToEntity(id, camperID string) ServiceRecord {
+	dueDate := s.DueDate
+	if !dueDate.Valid && s.StartDate.Valid {
+		dueDate = s.StartDate
+	}
+
+	return ServiceRecord{
+		ID:          id,
+		CamperID:    camperID,
+		Type:        s.Type,
+		Status:      ServiceStatusScheduled,
+		DueDate:     dueDate,
+		DueOdometer: s.DueOdometer,
+		Workshop:    s.Workshop,
+		Cost:        s.Cost,
+		CreatedBy:   s.ActorID,
+	}
+}
+
+// Complete logs the finished service and returns the condition the camper
+// is left in.
+func (s *ServiceRecord) Complete(input ServiceCompleteInput, now time.Time) (string, error) {
+	if s.Status != ServiceStatusScheduled {
+		return "", ErrServiceAlreadyCompleted
+	}
+
+	if input.Cost != nil {
+		if input.Cost.IsNegative() {
+			return "", ErrInvalidServiceRecord
+		}
+
+		s.Cost = *input.Cost
+	}
+
+	s.Status = ServiceStatusCompleted
+	s.CompletedAt = NewNullTime(now)
+	s.CompletionNotes = input.Notes
+
+	if input.Condition == "" {
+		return ServicedCondition, nil
+	}
+
+	return input.Condition, nil
+}
@@ -0,0 +1,86 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	OdometerSourceCheckOut = "check_out"
+	OdometerSourceCheckIn  = "check_in"
+	OdometerSourceService  = "service"
+	OdometerSourceManual   = "manual"
+)
+
+const LineItemMileage = "mileage"
+
+type OdometerRepository interface {
+	FindByCamperID(ctx context.Context, camperID string) ([]OdometerReading, error)
+	Record(ctx context.Context, camperID string, input OdometerReadingInput) (OdometerReading, error)
+}
+
+// OdometerReading is one entry in a camper's mileage history, taken at
+// pickup, return, a service or by hand. Readings never go down.
+type OdometerReading struct {
+	ID              string    `json:"id"`
+	CamperID        string    `json:"camper_id"`
+	RentalID        *string   `json:"rental_id"`
+	ServiceRecordID *string   `json:"service_record_id"`
+	Source          string    `json:"source"`
+	Reading         int       `json:"reading"`
+	RecordedBy      string    `json:"recorded_by"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+type OdometerReadingInput struct {
+	Reading int `json:"reading"`
+
+	ActorID string `json:"-"`
+}
+
+// MileagePolicy sets how many kilometres a night of each rental type
+// includes and what every kilometre driven beyond that costs. A rental type
+// without an allowance has unlimited mileage.
+type MileagePolicy struct {
+	Allowances  map[string]int
+	OverageRate decimal.Decimal
+}
+
+// Allowance is the distance included in the rental, or ok=false when its
+// mileage is unlimited.
+func (p MileagePolicy) Allowance(rental Rental) (int, bool) {
+	perNight, ok := p.Allowances[rental.RentalType]
+	if !ok || perNight <= 0 {
+		return 0, false
+	}
+
+	nights := rental.Nights()
+	if nights < 1 {
+		nights = 1
+	}
+
+	return perNight * nights, true
+}
+
+// Overage prices the kilometres the rental was driven beyond its allowance.
+// It returns a zero line item when nothing is owed.
+func (p MileagePolicy) Overage(rental Rental) RentalLineItem {
+	allowance, ok := p.Allowance(rental)
+	if !ok || !p.OverageRate.IsPositive() || rental.Distance <= allowance {
+		return RentalLineItem{}
+	}
+
+	excess := rental.Distance - allowance
+
+	return RentalLineItem{
+		RentalID:    rental.ID,
+		Kind:        LineItemMileage,
+		Description: fmt.Sprintf("%d km over the %d km allowance", excess, allowance),
+		Quantity:    excess,
+		UnitPrice:   p.OverageRate,
+		Amount:      p.OverageRate.Mul(decimal.NewFromInt(int64(excess))).Round(2),
+	}
+}
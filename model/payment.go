@@ -0,0 +1,136 @@
+package model
+
+import (
+	"context"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	PaymentTypeDeposit = "deposit"
+	PaymentTypeBalance = "balance"
+	PaymentTypeCharge  = "charge"
+	PaymentTypeRefund  = "refund"
+)
+
+const (
+	PaymentStatusPending = "pending"
+	PaymentStatusPaid    = "paid"
+	PaymentStatusFailed  = "failed"
+)
+
+type PaymentRepository interface {
+	FindByRentalID(ctx context.Context, rentalID string) ([]Payment, error)
+	Create(ctx context.Context, payment Payment) (Payment, error)
+	SetProviderRef(ctx context.Context, id, providerRef string) error
+	ApplyEvent(ctx context.Context, event PaymentEvent) (Payment, error)
+}
+
+// PaymentGateway moves money through a payment provider. Charges and refunds
+// start out pending and return the provider's reference; the provider reports
+// the outcome later through a webhook, which ParseWebhook turns into a
+// PaymentEvent carrying our payment ID.
+type PaymentGateway interface {
+	Name() string
+	Charge(ctx context.Context, payment Payment) (string, error)
+	Refund(ctx context.Context, payment Payment) (string, error)
+	ParseWebhook(signature string, payload []byte) (PaymentEvent, error)
+}
+
+// Payment is one entry of a rental's payment ledger. Refunds are stored with
+// a positive amount and subtracted when summing the ledger.
+type Payment struct {
+	ID          string          `json:"id"`
+	RentalID    string          `json:"rental_id"`
+	Type        string          `json:"type"`
+	Status      string          `json:"status"`
+	Amount      decimal.Decimal `json:"amount"`
+	Provider    string          `json:"provider"`
+	ProviderRef string          `json:"provider_ref"`
+	Note        string          `json:"note"`
+	CreatedBy   string          `json:"created_by"`
+	PaidAt      NullTime        `json:"paid_at"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+}
+
+type PaymentInput struct {
+	Type   string           `json:"type"`
+	Amount *decimal.Decimal `json:"amount"`
+	Note   string           `json:"note"`
+}
+
+// PaymentEvent is a provider callback reporting the outcome of a payment.
+type PaymentEvent struct {
+	PaymentID   string    `json:"payment_id"`
+	ProviderRef string    `json:"provider_ref"`
+	Status      string    `json:"status"`
+	OccurredAt  time.Time `json:"occurred_at"`
+}
+
+// Validate checks the payment type is one the actor may create. Customers can
+// only pay their deposit and balance; charges and refunds are staff actions.
+func (p PaymentInput) Validate(actor string) error {
+	switch p.Type {
+	case PaymentTypeDeposit, PaymentTypeBalance:
+	case PaymentTypeCharge, PaymentTypeRefund:
+		if actor != ActorStaff {
+			return ErrForbidden
+		}
+
+		if p.Amount == nil {
+			return ErrInvalidPaymentAmount
+		}
+	default:
+		return ErrInvalidPaymentType
+	}
+
+	if p.Amount != nil && !p.Amount.IsPositive() {
+		return ErrInvalidPaymentAmount
+	}
+
+	return nil
+}
+
+// PaymentLedger summarises the paid entries of a rental's payments.
+type PaymentLedger struct {
+	Deposit  decimal.Decimal `json:"deposit"`
+	Paid     decimal.Decimal `json:"paid"`
+	Refunded decimal.Decimal `json:"refunded"`
+	Net      decimal.Decimal `json:"net"`
+}
+
+func NewPaymentLedger(payments []Payment) PaymentLedger {
+	ledger := PaymentLedger{}
+
+	for _, payment := range payments {
+		if payment.Status != PaymentStatusPaid {
+			continue
+		}
+
+		switch payment.Type {
+		case PaymentTypeRefund:
+			ledger.Refunded = ledger.Refunded.Add(payment.Amount)
+		case PaymentTypeDeposit:
+			ledger.Deposit = ledger.Deposit.Add(payment.Amount)
+			ledger.Paid = ledger.Paid.Add(payment.Amount)
+		default:
+			ledger.Paid = ledger.Paid.Add(payment.Amount)
+		}
+	}
+
+	ledger.Net = ledger.Paid.Sub(ledger.Refunded)
+	return ledger
+}
+
+// AmountFor returns the amount to collect for a deposit or balance payment
+// when the caller does not set one: the deposit due, or whatever remains of
+// the grand total.
+func (l PaymentLedger) AmountFor(paymentType string, rental Rental) decimal.Decimal {
+	if paymentType == PaymentTypeDeposit {
+		return rental.DepositDue.Sub(l.Deposit)
+	}
+
+	return rental.GrandTotal.Sub(l.Net)
+}
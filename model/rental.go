@@ -8,43 +8,167 @@ import (
 )
 
 const (
+	RentalStatusHeld      = "held"
 	RentalStatusPending   = "pending"
 	RentalStatusConfirmed = "confirmed"
+	RentalStatusActive    = "active"
 	RentalStatusCancelled = "cancelled"
 	RentalStatusCompleted = "completed"
 )
 
+const (
+	RentalTypeDaily   = "daily"
+	RentalTypeWeekly  = "weekly"
+	RentalTypeMonthly = "monthly"
+)
+
+const (
+	LineItemCamper    = "camper"
+	LineItemEquipment = "equipment"
+	LineItemDriver    = "driver"
+	LineItemDiscount  = "discount"
+	LineItemTax       = "tax"
+)
+
 type RentalRepository interface {
 	FindByID(ctx context.Context, id string) (Rental, error)
 	FindAll(ctx context.Context, query RentalQueryInput) ([]Rental, int64, error)
 	Create(ctx context.Context, rental RentalInput) error
 	Update(ctx context.Context, id string, rental RentalInput) error
+	Transition(ctx context.Context, id string, input RentalTransitionInput) (Rental, error)
+	Cancel(ctx context.Context, id string, input RentalCancelInput) (RentalCancelResult, error)
+	ChangeEndDate(ctx context.Context, id string, input RentalDateChangeInput, reprice RentalRepricer) (RentalDateChangeResult, error)
+
+	CheckAvailability(ctx context.Context, rental RentalInput, excludeID string) ([]BlockingReason, error)
+	FlagOverdue(ctx context.Context, now time.Time) ([]OverdueRental, error)
+	ReleaseExpiredHolds(ctx context.Context, now time.Time) (int64, error)
 }
 
 type Rental struct {
-	ID         string          `json:"id"`
-	CustomerID string          `json:"customer_id"`
-	StartDate  time.Time       `json:"start_date"`
-	EndDate    time.Time       `json:"end_date"`
-	RentalType string          `json:"rental_type"`
-	CamperID   string          `json:"camper_id"`
-	DriverID   string          `json:"driver_id"`
-	Status     string          `json:"status"`
-	GrandTotal decimal.Decimal `json:"grand_total"`
-	Discount   decimal.Decimal `json:"discount"`
-	CreatedAt  time.Time       `json:"created_at"`
-	UpdatedAt  time.Time       `json:"updated_at"`
-	DeletedAt  NullTime        `json:"deleted_at"`
+	ID          string          `json:"id"`
+	CustomerID  string          `json:"customer_id"`
+	StartDate   time.Time       `json:"start_date"`
+	EndDate     time.Time       `json:"end_date"`
+	RentalType  string          `json:"rental_type"`
+	CamperID    string          `json:"camper_id"`
+	DriverID    string          `json:"driver_id"`
+	Status      string          `json:"status"`
+	GrandTotal  decimal.Decimal `json:"grand_total"`
+	Discount    decimal.Decimal `json:"discount"`
+	DepositDue  decimal.Decimal `json:"deposit_due"`
+	ConfirmedAt NullTime        `json:"confirmed_at"`
+	StartedAt   NullTime        `json:"started_at"`
+	CompletedAt NullTime        `json:"completed_at"`
+	CancelledAt NullTime        `json:"cancelled_at"`
+
+	// HoldExpiresAt is when a held rental stops reserving the camper, driver
+	// and equipment unless it has been confirmed.
+	HoldExpiresAt NullTime `json:"hold_expires_at"`
+
+	CancelledBy          string          `json:"cancelled_by,omitempty"`
+	CancellationReason   string          `json:"cancellation_reason,omitempty"`
+	CancellationPolicyID string          `json:"cancellation_policy_id,omitempty"`
+	RefundAmount         decimal.Decimal `json:"refund_amount"`
+	CancellationFee      decimal.Decimal `json:"cancellation_fee"`
+	RefundOverrideReason string          `json:"refund_override_reason,omitempty"`
+
+	SecurityDepositStatus   string          `json:"security_deposit_status"`
+	SecurityDepositHeld     decimal.Decimal `json:"security_deposit_held"`
+	SecurityDepositCaptured decimal.Decimal `json:"security_deposit_captured"`
+
+	OverdueSince NullTime        `json:"overdue_since"`
+	LateFee      decimal.Decimal `json:"late_fee"`
+
+	// Distance is how far the camper was driven, from the check-out and
+	// check-in odometer readings, once the rental is completed.
+	Distance   int             `json:"distance"`
+	MileageFee decimal.Decimal `json:"mileage_fee"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	DeletedAt NullTime  `json:"deleted_at"`
+
+	LineItems        []RentalLineItem   `json:"line_items,omitempty" gorm:"foreignKey:RentalID"`
+	RentalEquipments []RentalEquipment  `json:"equipments,omitempty" gorm:"foreignKey:RentalID"`
+	RentalBundles    []RentalBundle     `json:"bundles,omitempty" gorm:"foreignKey:RentalID"`
+	DateChanges      []RentalDateChange `json:"date_changes,omitempty" gorm:"foreignKey:RentalID"`
+}
+
+// Nights is the number of nights billed for the rental period.
+func (r Rental) Nights() int {
+	return int(r.EndDate.Sub(r.StartDate).Hours() / 24)
+}
+
+// Overlaps reports whether the rental occupies any day in [start, end).
+// A rental ending on the day another one starts does not overlap it, so
+// campers can be handed over on the same day.
+func (r Rental) Overlaps(start, end time.Time) bool {
+	return r.StartDate.Before(end) && r.EndDate.After(start)
 }
 
 type RentalQueryInput struct {
 	Keyword string `query:"keyword"`
+	Overdue bool   `query:"overdue"`
+
+	// CustomerID scopes the listing to one customer's rentals. It is set
+	// from the session, never from the query string.
+	CustomerID string `query:"-"`
 	PaginatedRequest
 }
 
+// RentalInput is a booking request. Equipment can be listed in
+// EquipmentIDs, one of each, or in the embedded rental's equipments with a
+// quantity, and bundles likewise in BundleIDs or the rental's bundles.
 type RentalInput struct {
 	Rental
 	EquipmentIDs []string `json:"equipment_ids"`
+	BundleIDs    []string `json:"bundle_ids"`
+
+	// Hold books the rental as a temporary hold instead of a pending
+	// rental, so the customer cannot lose it while paying the deposit.
+	Hold bool `json:"hold"`
+}
+
+// WithDefaults fills the fields left empty in an update from the existing
+// rental, mirroring how Updates skips zero values.
+func (r RentalInput) WithDefaults(existing Rental) RentalInput {
+	if r.CamperID == "" {
+		r.CamperID = existing.CamperID
+	}
+
+	if r.DriverID == "" {
+		r.DriverID = existing.DriverID
+	}
+
+	if r.RentalType == "" {
+		r.RentalType = existing.RentalType
+	}
+
+	if r.StartDate.IsZero() {
+		r.StartDate = existing.StartDate
+	}
+
+	if r.EndDate.IsZero() {
+		r.EndDate = existing.EndDate
+	}
+
+	if !r.HasEquipments() {
+		r.RentalEquipments = existing.RentalEquipments
+	}
+
+	if !r.HasBundles() {
+		r.RentalBundles = existing.RentalBundles
+	}
+
+	return r
+}
+
+func (r RentalInput) ValidatePeriod() error {
+	if !r.EndDate.After(r.StartDate) {
+		return ErrInvalidRentalPeriod
+	}
+
+	return nil
 }
 
 func (r RentalInput) ToEntity(id string) Rental {
@@ -56,24 +180,176 @@ func (r RentalInput) ToEntity(id string) Rental {
 		RentalType: r.RentalType,
 		CamperID:   r.CamperID,
 		DriverID:   r.DriverID,
-		Status:     r.Status,
 		GrandTotal: r.GrandTotal,
 		Discount:   r.Discount,
+		DepositDue: r.DepositDue,
 	}
 }
 
+// HasEquipments reports whether the input lists any equipment.
+func (r RentalInput) HasEquipments() bool {
+	return len(r.EquipmentIDs) > 0 || len(r.RentalEquipments) > 0
+}
+
+// Equipments merges EquipmentIDs and the listed equipments into one line per
+// equipment, summing the quantities of repeated items. A listed equipment
+// without a quantity counts as one.
 func (r RentalInput) Equipments() []RentalEquipment {
 	var rentalEquipments []RentalEquipment
-	for _, equipmentID := range r.EquipmentIDs {
+	index := make(map[string]int)
+
+	add := func(equipmentID string, quantity int) {
+		if quantity < 1 {
+			quantity = 1
+		}
+
+		if i, ok := index[equipmentID]; ok {
+			rentalEquipments[i].Quantity += quantity
+			return
+		}
+
+		index[equipmentID] = len(rentalEquipments)
 		rentalEquipments = append(rentalEquipments, RentalEquipment{
 			RentalID:    r.ID,
 			EquipmentID: equipmentID,
+			Quantity:    quantity,
 		})
 	}
+
+	for _, equipmentID := range r.EquipmentIDs {
+		add(equipmentID, 1)
+	}
+
+	for _, equipment := range r.RentalEquipments {
+		add(equipment.EquipmentID, equipment.Quantity)
+	}
+
 	return rentalEquipments
 }
 
+// EquipmentQuantities maps every requested equipment to its quantity.
+func (r RentalInput) EquipmentQuantities() map[string]int {
+	quantities := make(map[string]int)
+	for _, equipment := range r.Equipments() {
+		quantities[equipment.EquipmentID] = equipment.Quantity
+	}
+	return quantities
+}
+
+// RequestedEquipmentIDs lists each requested equipment once.
+func (r RentalInput) RequestedEquipmentIDs() []string {
+	var ids []string
+	for _, equipment := range r.Equipments() {
+		ids = append(ids, equipment.EquipmentID)
+	}
+	return ids
+}
+
+// HasBundles reports whether the input lists any equipment bundle.
+func (r RentalInput) HasBundles() bool {
+	return len(r.BundleIDs) > 0 || len(r.RentalBundles) > 0
+}
+
+// Bundles merges BundleIDs and the listed bundles into one line per bundle,
+// the same way Equipments does for equipment.
+func (r RentalInput) Bundles() []RentalBundle {
+	var bundles []RentalBundle
+	index := make(map[string]int)
+
+	add := func(bundleID string, quantity int) {
+		if quantity < 1 {
+			quantity = 1
+		}
+
+		if i, ok := index[bundleID]; ok {
+			bundles[i].Quantity += quantity
+			return
+		}
+
+		index[bundleID] = len(bundles)
+		bundles = append(bundles, RentalBundle{
+			RentalID: r.ID,
+			BundleID: bundleID,
+			Quantity: quantity,
+		})
+	}
+
+	for _, bundleID := range r.BundleIDs {
+		add(bundleID, 1)
+	}
+
+	for _, bundle := range r.RentalBundles {
+		add(bundle.BundleID, bundle.Quantity)
+	}
+
+	return bundles
+}
+
+// BundleQuantities maps every requested bundle to its quantity.
+func (r RentalInput) BundleQuantities() map[string]int {
+	quantities := make(map[string]int)
+	for _, bundle := range r.Bundles() {
+		quantities[bundle.BundleID] = bundle.Quantity
+	}
+	return quantities
+}
+
+// RequestedBundleIDs lists each requested bundle once.
+func (r RentalInput) RequestedBundleIDs() []string {
+	var ids []string
+	for _, bundle := range r.Bundles() {
+		ids = append(ids, bundle.BundleID)
+	}
+	return ids
+}
+
+// RequestedStock is how many of each equipment the rental takes from stock:
+// the equipment listed on its own plus the contents of every bundle, given
+// the items of the requested bundles.
+func (r RentalInput) RequestedStock(items []EquipmentBundleItem) map[string]int {
+	requested := r.EquipmentQuantities()
+
+	bundles := r.BundleQuantities()
+	for _, item := range items {
+		requested[item.EquipmentID] += item.Quantity * bundles[item.BundleID]
+	}
+
+	return requested
+}
+
+// ApplyQuote replaces any client-supplied totals with the priced ones.
+func (r *RentalInput) ApplyQuote(grandTotal, discount, depositDue decimal.Decimal, lineItems []RentalLineItem) {
+	r.GrandTotal = grandTotal
+	r.Discount = discount
+	r.DepositDue = depositDue
+	r.LineItems = lineItems
+}
+
+func (r RentalInput) PricedLineItems() []RentalLineItem {
+	lineItems := make([]RentalLineItem, len(r.LineItems))
+	for i, item := range r.LineItems {
+		item.RentalID = r.ID
+		lineItems[i] = item
+	}
+	return lineItems
+}
+
 type RentalEquipment struct {
 	RentalID    string `json:"rental_id"`
 	EquipmentID string `json:"equipment_id"`
+	Quantity    int    `json:"quantity"`
+}
+
+// RentalLineItem is one row of the server-computed price breakdown. Discount
+// lines carry a negative amount so the items always sum to the grand total.
+type RentalLineItem struct {
+	ID          string          `json:"id"`
+	RentalID    string          `json:"rental_id"`
+	Kind        string          `json:"kind"`
+	ReferenceID string          `json:"reference_id,omitempty"`
+	Description string          `json:"description"`
+	Quantity    int             `json:"quantity"`
+	UnitPrice   decimal.Decimal `json:"unit_price"`
+	Amount      decimal.Decimal `json:"amount"`
+	CreatedAt   time.Time       `json:"created_at"`
 }
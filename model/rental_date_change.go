@@ -0,0 +1,140 @@
+package model
+
+import (
+	"slices"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	RentalDateChangeExtend      = "extend"
+	RentalDateChangeEarlyReturn = "early_return"
+)
+
+// RentalDateChange records a change of a rental's end date, keeping the
+// dates it replaced and what the change cost or refunded.
+type RentalDateChange struct {
+	ID              string          `json:"id"`
+	RentalID        string          `json:"rental_id"`
+	Type            string          `json:"type"`
+	PreviousEndDate time.Time       `json:"previous_end_date"`
+	NewEndDate      time.Time       `json:"new_end_date"`
+	PriceDelta      decimal.Decimal `json:"price_delta"`
+	PaymentID       *string         `json:"payment_id"`
+	CreatedBy       string          `json:"created_by"`
+	CreatedAt       time.Time       `json:"created_at"`
+}
+
+type RentalDateChangeInput struct {
+	EndDate time.Time `json:"end_date"`
+
+	Type     string `json:"-"`
+	Actor    string `json:"-"`
+	ActorID  string `json:"-"`
+	Provider string `json:"-"`
+}
+
+// RentalPrice is the priced part of a rental: the camper, equipment, bundle,
+// driver, discount and tax line items, without later extras such as damage
+// or late fees.
+type RentalPrice struct {
+	RentalType string
+	LineItems  []RentalLineItem
+	Discount   decimal.Decimal
+	GrandTotal decimal.Decimal
+}
+
+// RentalRepricer prices rental as if it ended on endDate.
+type RentalRepricer func(rental Rental, endDate time.Time) (RentalPrice, error)
+
+// RentalDateChangeResult is the rental after its end date changed, the
+// history entry recording it and the pending charge or refund for the
+// difference, if any.
+type RentalDateChangeResult struct {
+	Rental  Rental           `json:"rental"`
+	Change  RentalDateChange `json:"change"`
+	Payment *Payment         `json:"payment"`
+}
+
+// PricedLineItemKinds are the kinds of line item that come from pricing the
+// rental period rather than being charged on top of it. Repricing replaces
+// exactly these.
+var PricedLineItemKinds = []string{
+	LineItemCamper,
+	LineItemEquipment,
+	LineItemBundle,
+	LineItemDriver,
+	LineItemDiscount,
+	LineItemTax,
+}
+
+// IsPriced reports whether the line item comes from pricing the rental
+// period rather than being charged on top of it.
+func (i RentalLineItem) IsPriced() bool {
+	return slices.Contains(PricedLineItemKinds, i.Kind)
+}
+
+// PricedTotal sums the rental's priced line items.
+func (r Rental) PricedTotal() decimal.Decimal {
+	total := decimal.Zero
+	for _, item := range r.LineItems {
+		if item.IsPriced() {
+			total = total.Add(item.Amount)
+		}
+	}
+
+	return total
+}
+
+// ValidateEndDateChange checks that actor may move the rental's end date as
+// requested. Confirmed and active rentals can be extended; only active ones
+// can be returned early, and not to a day already past.
+func (r Rental) ValidateEndDateChange(input RentalDateChangeInput, now time.Time) error {
+	if input.Actor == ActorCustomer && r.CustomerID != input.ActorID {
+		return ErrForbidden
+	}
+
+	if !input.EndDate.After(r.StartDate) {
+		return ErrInvalidRentalPeriod
+	}
+
+	switch input.Type {
+	case RentalDateChangeExtend:
+		if r.Status != RentalStatusConfirmed && r.Status != RentalStatusActive {
+			return ErrInvalidRentalTransition
+		}
+
+		if !input.EndDate.After(r.EndDate) {
+			return ErrInvalidEndDateChange
+		}
+	case RentalDateChangeEarlyReturn:
+		if r.Status != RentalStatusActive {
+			return ErrInvalidRentalTransition
+		}
+
+		if !input.EndDate.Before(r.EndDate) || input.EndDate.Before(truncateDay(now)) {
+			return ErrInvalidEndDateChange
+		}
+	default:
+		return ErrInvalidEndDateChange
+	}
+
+	return nil
+}
+
+// DateChangePayment decides how the price difference of an end date change
+// is settled. A higher price is charged; a lower one is refunded as far as
+// the customer has already paid more than the new grand total.
+func DateChangePayment(delta, newGrandTotal decimal.Decimal, ledger PaymentLedger) (string, decimal.Decimal) {
+	if delta.IsPositive() {
+		return PaymentTypeCharge, delta
+	}
+
+	refund := decimal.Min(delta.Neg(), ledger.Net.Sub(newGrandTotal))
+	if !refund.IsPositive() {
+		return "", decimal.Zero
+	}
+
+	return PaymentTypeRefund, refund
+}
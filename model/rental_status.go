@@ -0,0 +1,189 @@
+package model
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	RentalActionConfirm  = "confirm"
+	RentalActionCancel   = "cancel"
+	RentalActionStart    = "start"
+	RentalActionComplete = "complete"
+)
+
+const (
+	ActorCustomer = "customer"
+	ActorStaff    = "staff"
+)
+
+// RentalTransition is one edge of the rental status state machine. Apply
+// records the side effects of the transition on the rental.
+type RentalTransition struct {
+	From   []string
+	To     string
+	Actors []string
+	Apply  func(rental *Rental, now time.Time)
+}
+
+// RentalTransitions lists every allowed status change keyed by action.
+// Customers may only cancel; everything else is done by staff.
+var RentalTransitions = map[string]RentalTransition{
+	RentalActionConfirm: {
+		From:   []string{RentalStatusHeld, RentalStatusPending},
+		To:     RentalStatusConfirmed,
+		Actors: []string{ActorStaff},
+		Apply: func(rental *Rental, now time.Time) {
+			rental.ConfirmedAt = NewNullTime(now)
+		},
+	},
+	RentalActionCancel: {
+		From:   []string{RentalStatusHeld, RentalStatusPending, RentalStatusConfirmed},
+		To:     RentalStatusCancelled,
+		Actors: []string{ActorCustomer, ActorStaff},
+		Apply: func(rental *Rental, now time.Time) {
+			rental.CancelledAt = NewNullTime(now)
+		},
+	},
+	RentalActionStart: {
+		From:   []string{RentalStatusConfirmed},
+		To:     RentalStatusActive,
+		Actors: []string{ActorStaff},
+		Apply: func(rental *Rental, now time.Time) {
+			rental.StartedAt = NewNullTime(now)
+		},
+	},
+	RentalActionComplete: {
+		From:   []string{RentalStatusActive},
+		To:     RentalStatusCompleted,
+		Actors: []string{ActorStaff},
+		Apply: func(rental *Rental, now time.Time) {
+			rental.CompletedAt = NewNullTime(now)
+		},
+	},
+}
+
+type RentalTransitionInput struct {
+	Action  string
+	Actor   string
+	ActorID string
+
+	// DepositPaid is the sum of paid deposit payments, required to cover
+	// the rental's DepositDue before it can be confirmed.
+	DepositPaid decimal.Decimal
+}
+
+// Transition moves the rental to the status reached by action, or returns
+// ErrInvalidRentalTransition when the rental's current status does not allow
+// it and ErrForbidden when actor may not trigger it.
+func (r Rental) Transition(input RentalTransitionInput, now time.Time) (Rental, error) {
+	transition, ok := RentalTransitions[input.Action]
+	if !ok {
+		return Rental{}, fmt.Errorf("%w: unknown action %q", ErrInvalidRentalTransition, input.Action)
+	}
+
+	if !contains(transition.Actors, input.Actor) {
+		return Rental{}, ErrForbidden
+	}
+
+	if input.Actor == ActorCustomer && r.CustomerID != input.ActorID {
+		return Rental{}, ErrForbidden
+	}
+
+	if !contains(transition.From, r.Status) {
+		return Rental{}, fmt.Errorf("%w: cannot %s a %s rental", ErrInvalidRentalTransition, input.Action, r.Status)
+	}
+
+	if input.Action == RentalActionConfirm && r.HoldExpired(now) {
+		return Rental{}, ErrHoldExpired
+	}
+
+	if input.Action == RentalActionConfirm && input.DepositPaid.LessThan(r.DepositDue) {
+		return Rental{}, ErrDepositNotPaid
+	}
+
+	r.Status = transition.To
+	transition.Apply(&r, now)
+
+	return r, nil
+}
+
+// HoldExpired reports whether the rental is a hold that no longer reserves
+// anything.
+func (r Rental) HoldExpired(now time.Time) bool {
+	return r.Status == RentalStatusHeld && (!r.HoldExpiresAt.Valid || !now.Before(r.HoldExpiresAt.Time))
+}
+
+// RentalCancelInput carries the optional cancellation details. Staff may set
+// RefundAmount to override the policy, in which case OverrideReason is
+// required and recorded on the rental.
+type RentalCancelInput struct {
+	Reason         string           `json:"reason"`
+	RefundAmount   *decimal.Decimal `json:"refund_amount"`
+	OverrideReason string           `json:"override_reason"`
+
+	Actor    string `json:"-"`
+	ActorID  string `json:"-"`
+	Provider string `json:"-"`
+}
+
+// RentalCancelResult is the cancelled rental and the pending refund of what
+// the customer gets back, if anything.
+type RentalCancelResult struct {
+	Rental  Rental   `json:"rental"`
+	Payment *Payment `json:"payment"`
+}
+
+// Cancel transitions the rental to cancelled and records the refund decided
+// by policy, or by the staff override when one is given. Either way the
+// refund comes out of what the ledger shows the customer has paid.
+func (r Rental) Cancel(input RentalCancelInput, policy CancellationPolicy, ledger PaymentLedger, now time.Time) (Rental, error) {
+	cancelled, err := r.Transition(RentalTransitionInput{
+		Action:  RentalActionCancel,
+		Actor:   input.Actor,
+		ActorID: input.ActorID,
+	}, now)
+	if err != nil {
+		return Rental{}, err
+	}
+
+	refund, fee := policy.Refund(r, ledger.Net, now)
+
+	if input.RefundAmount != nil {
+		if input.Actor != ActorStaff {
+			return Rental{}, ErrForbidden
+		}
+
+		if input.OverrideReason == "" {
+			return Rental{}, ErrOverrideReasonRequired
+		}
+
+		if input.RefundAmount.IsNegative() || input.RefundAmount.GreaterThan(decimal.Max(ledger.Net, decimal.Zero)) {
+			return Rental{}, ErrInvalidRefundAmount
+		}
+
+		refund = *input.RefundAmount
+		fee = ledger.Net.Sub(refund)
+		cancelled.RefundOverrideReason = input.OverrideReason
+	}
+
+	cancelled.CancelledBy = input.ActorID
+	cancelled.CancellationReason = input.Reason
+	cancelled.CancellationPolicyID = policy.ID
+	cancelled.RefundAmount = refund
+	cancelled.CancellationFee = fee
+
+	return cancelled, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,92 @@
+package model
+
+import (
+	"context"
+	"strings"
+)
+
+const (
+	SearchTypeCamper    = "camper"
+	SearchTypeEquipment = "equipment"
+	SearchTypeDriver    = "driver"
+)
+
+// DefaultSearchLimit and MaxSearchLimit bound how many hits a search
+// returns.
+const (
+	DefaultSearchLimit = 20
+	MaxSearchLimit     = 100
+)
+
+type SearchRepository interface {
+	Search(ctx context.Context, query SearchQueryInput) ([]SearchHit, error)
+}
+
+// SearchQueryInput searches campers, equipment and drivers at once. Type
+// narrows the search to some of them and may be repeated.
+type SearchQueryInput struct {
+	Q     string   `query:"q"`
+	Types []string `query:"type"`
+	Limit int      `query:"limit"`
+}
+
+// SearchHit is one ranked match. Title and subtitle are what a result list
+// shows for it: a camper's name and plate, an equipment's name and category
+// or a driver's name and licence number.
+type SearchHit struct {
+	Type     string  `json:"type"`
+	ID       string  `json:"id"`
+	Title    string  `json:"title"`
+	Subtitle string  `json:"subtitle"`
+	ImageURL string  `json:"image_url"`
+	Rank     float64 `json:"rank"`
+}
+
+func (s SearchQueryInput) Validate() error {
+	if strings.TrimSpace(s.Q) == "" || s.Limit < 0 {
+		return ErrInvalidSearchQuery
+	}
+
+	for _, searchType := range s.Types {
+		switch searchType {
+		case SearchTypeCamper, SearchTypeEquipment, SearchTypeDriver:
+		default:
+			return ErrInvalidSearchQuery
+		}
+	}
+
+	return nil
+}
+
+// SearchTypes is what to search, once each, everything unless narrowed by
+// type.
+func (s SearchQueryInput) SearchTypes() []string {
+	all := []string{SearchTypeCamper, SearchTypeEquipment, SearchTypeDriver}
+	if len(s.Types) == 0 {
+		return all
+	}
+
+	var types []string
+	for _, searchType := range all {
+		for _, requested := range s.Types {
+			if requested == searchType {
+				types = append(types, searchType)
+				break
+			}
+		}
+	}
+
+	return types
+}
+
+func (s SearchQueryInput) LimitOrDefault() int {
+	if s.Limit == 0 {
+		return DefaultSearchLimit
+	}
+
+	if s.Limit > MaxSearchLimit {
+		return MaxSearchLimit
+	}
+
+	return s.Limit
+}
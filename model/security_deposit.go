@@ -0,0 +1,171 @@
+package model
+
+import (
+	"context"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	SecurityDepositNone              = "none"
+	SecurityDepositHeld              = "held"
+	SecurityDepositPartiallyCaptured = "partially_captured"
+	SecurityDepositCaptured          = "captured"
+	SecurityDepositReleased          = "released"
+)
+
+const (
+	SecurityDepositEventHold    = "hold"
+	SecurityDepositEventCapture = "capture"
+	SecurityDepositEventRelease = "release"
+)
+
+type SecurityDepositRepository interface {
+	FindByRentalID(ctx context.Context, rentalID string) (SecurityDeposit, error)
+	Hold(ctx context.Context, rentalID string, input SecurityDepositInput) (SecurityDeposit, error)
+	Capture(ctx context.Context, rentalID string, input SecurityDepositInput) (SecurityDeposit, error)
+	Release(ctx context.Context, rentalID string, input SecurityDepositInput) (SecurityDeposit, error)
+}
+
+// SecurityDepositEvent is one immutable entry of a rental's security deposit
+// history.
+type SecurityDepositEvent struct {
+	ID        string          `json:"id"`
+	RentalID  string          `json:"rental_id"`
+	Type      string          `json:"type"`
+	Amount    decimal.Decimal `json:"amount"`
+	Reason    string          `json:"reason"`
+	CreatedBy string          `json:"created_by"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+type SecurityDepositInput struct {
+	Amount *decimal.Decimal `json:"amount"`
+	Reason string           `json:"reason"`
+
+	ActorID string `json:"-"`
+}
+
+// SecurityDeposit is the refundable deposit held against a rental, derived
+// from its event history.
+type SecurityDeposit struct {
+	RentalID  string                 `json:"rental_id"`
+	Status    string                 `json:"status"`
+	Held      decimal.Decimal        `json:"held"`
+	Captured  decimal.Decimal        `json:"captured"`
+	Released  decimal.Decimal        `json:"released"`
+	Remaining decimal.Decimal        `json:"remaining"`
+	Events    []SecurityDepositEvent `json:"events"`
+}
+
+func NewSecurityDeposit(rentalID string, events []SecurityDepositEvent) SecurityDeposit {
+	deposit := SecurityDeposit{
+		RentalID: rentalID,
+		Status:   SecurityDepositNone,
+		Events:   events,
+	}
+
+	for _, event := range events {
+		switch event.Type {
+		case SecurityDepositEventHold:
+			deposit.Held = deposit.Held.Add(event.Amount)
+		case SecurityDepositEventCapture:
+			deposit.Captured = deposit.Captured.Add(event.Amount)
+		case SecurityDepositEventRelease:
+			deposit.Released = deposit.Released.Add(event.Amount)
+		}
+	}
+
+	deposit.Remaining = deposit.Held.Sub(deposit.Captured).Sub(deposit.Released)
+
+	switch {
+	case deposit.Held.IsZero():
+		deposit.Status = SecurityDepositNone
+	case deposit.Released.IsPositive():
+		deposit.Status = SecurityDepositReleased
+	case deposit.Remaining.IsZero():
+		deposit.Status = SecurityDepositCaptured
+	case deposit.Captured.IsPositive():
+		deposit.Status = SecurityDepositPartiallyCaptured
+	default:
+		deposit.Status = SecurityDepositHeld
+	}
+
+	return deposit
+}
+
+// Hold places the deposit once the rental is confirmed. amount defaults to the
+// camper's security deposit when the input leaves it empty.
+func (d SecurityDeposit) Hold(rental Rental, amount decimal.Decimal, input SecurityDepositInput) (SecurityDepositEvent, error) {
+	if rental.Status != RentalStatusConfirmed && rental.Status != RentalStatusActive {
+		return SecurityDepositEvent{}, ErrInvalidSecurityDepositAction
+	}
+
+	if d.Status != SecurityDepositNone {
+		return SecurityDepositEvent{}, ErrInvalidSecurityDepositAction
+	}
+
+	if input.Amount != nil {
+		amount = *input.Amount
+	}
+
+	if !amount.IsPositive() {
+		return SecurityDepositEvent{}, ErrInvalidSecurityDepositAmount
+	}
+
+	return d.event(SecurityDepositEventHold, amount, input), nil
+}
+
+// Capture keeps part of the held deposit against damage or late-return
+// charges. It can be called several times until the deposit is used up.
+func (d SecurityDeposit) Capture(rental Rental, input SecurityDepositInput) (SecurityDepositEvent, error) {
+	if !d.settleable(rental) {
+		return SecurityDepositEvent{}, ErrInvalidSecurityDepositAction
+	}
+
+	if input.Reason == "" {
+		return SecurityDepositEvent{}, ErrSecurityDepositReasonRequired
+	}
+
+	if input.Amount == nil || !input.Amount.IsPositive() || input.Amount.GreaterThan(d.Remaining) {
+		return SecurityDepositEvent{}, ErrInvalidSecurityDepositAmount
+	}
+
+	return d.event(SecurityDepositEventCapture, *input.Amount, input), nil
+}
+
+// Release returns whatever was not captured to the customer and closes the
+// deposit. A deposit held on a rental that is then cancelled is released in
+// full.
+func (d SecurityDeposit) Release(rental Rental, input SecurityDepositInput) (SecurityDepositEvent, error) {
+	releasable := d.settleable(rental) || rental.Status == RentalStatusCancelled && d.isOpen()
+	if !releasable || !d.Remaining.IsPositive() {
+		return SecurityDepositEvent{}, ErrInvalidSecurityDepositAction
+	}
+
+	return d.event(SecurityDepositEventRelease, d.Remaining, input), nil
+}
+
+func (d SecurityDeposit) settleable(rental Rental) bool {
+	if rental.Status != RentalStatusActive && rental.Status != RentalStatusCompleted {
+		return false
+	}
+
+	return d.isOpen()
+}
+
+// isOpen reports whether part of the deposit is still held.
+func (d SecurityDeposit) isOpen() bool {
+	return d.Status == SecurityDepositHeld || d.Status == SecurityDepositPartiallyCaptured
+}
+
+func (d SecurityDeposit) event(eventType string, amount decimal.Decimal, input SecurityDepositInput) SecurityDepositEvent {
+	return SecurityDepositEvent{
+		RentalID:  d.RentalID,
+		Type:      eventType,
+		Amount:    amount,
+		Reason:    input.Reason,
+		CreatedBy: input.ActorID,
+	}
+}
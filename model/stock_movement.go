@@ -0,0 +1,89 @@
+package model
+
+import "time"
+
+const (
+	StockMovementPurchase   = "purchase"
+	StockMovementWriteOff   = "write_off"
+	StockMovementLoss       = "loss"
+	StockMovementAdjustment = "adjustment"
+	StockMovementTransfer   = "transfer"
+)
+
+// StockMovement is one entry in an equipment's stock ledger. Quantity is
+// signed, and an equipment's stock is the sum of its movements.
+type StockMovement struct {
+	ID           string    `json:"id"`
+	EquipmentID  string    `json:"equipment_id"`
+	Type         string    `json:"type"`
+	Quantity     int       `json:"quantity"`
+	BalanceAfter int       `json:"balance_after"`
+	Reason       string    `json:"reason"`
+	Reference    string    `json:"reference,omitempty"`
+	CreatedBy    string    `json:"created_by"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// StockMovementInput records a change of stock. Purchases, write-offs and
+// losses take a positive quantity and the type decides the direction;
+// adjustments and transfers are signed, so a transfer to another depot is
+// negative and one received from it is positive. Reference points at what
+// caused the movement, e.g. a damage report or the other depot.
+type StockMovementInput struct {
+	Type      string `json:"type"`
+	Quantity  int    `json:"quantity"`
+	Reason    string `json:"reason"`
+	Reference string `json:"reference"`
+
+	ActorID string `json:"-"`
+}
+
+// Delta is the signed change the movement makes to the stock.
+func (s StockMovementInput) Delta() (int, error) {
+	if s.Reason == "" || s.Quantity == 0 {
+		return 0, ErrInvalidStockMovement
+	}
+
+	switch s.Type {
+	case StockMovementPurchase:
+		if s.Quantity < 0 {
+			return 0, ErrInvalidStockMovement
+		}
+
+		return s.Quantity, nil
+	case StockMovementWriteOff, StockMovementLoss:
+		if s.Quantity < 0 {
+			return 0, ErrInvalidStockMovement
+		}
+
+		return -s.Quantity, nil
+	case StockMovementAdjustment, StockMovementTransfer:
+		return s.Quantity, nil
+	}
+
+	return 0, ErrInvalidStockMovement
+}
+
+// ToEntity turns the input into a ledger entry for an equipment whose stock
+// was balance before the movement.
+func (s StockMovementInput) ToEntity(id, equipmentID string, balance int) (StockMovement, error) {
+	delta, err := s.Delta()
+	if err != nil {
+		return StockMovement{}, err
+	}
+
+	if balance+delta < 0 {
+		return StockMovement{}, ErrInsufficientStock
+	}
+
+	return StockMovement{
+		ID:           id,
+		EquipmentID:  equipmentID,
+		Type:         s.Type,
+		Quantity:     delta,
+		BalanceAfter: balance + delta,
+		Reason:       s.Reason,
+		Reference:    s.Reference,
+		CreatedBy:    s.ActorID,
+	}, nil
+}
@@ -10,6 +10,10 @@ type NullTime struct {
 	sql.NullTime
 }
 
+func NewNullTime(t time.Time) NullTime {
+	return NullTime{sql.NullTime{Time: t, Valid: true}}
+}
+
 func (nt *NullTime) UnmarshalJSON(data []byte) error {
 	if string(data) == "null" {
 		nt.NullTime = sql.NullTime{Valid: false}
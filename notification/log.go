@@ -0,0 +1,22 @@
+package notification
+
+import (
+	"context"
+
+	"github.com/notblessy/rms/model"
+	"github.com/sirupsen/logrus"
+)
+
+// LogNotifier writes staff notifications to the application log. It stands
+// in until notifications are delivered by email or chat.
+type LogNotifier struct{}
+
+// NewLogNotifier :nodoc:
+func NewLogNotifier() *LogNotifier {
+	return &LogNotifier{}
+}
+
+func (l *LogNotifier) NotifyStaff(ctx context.Context, notification model.Notification) error {
+	logrus.WithField("subject", notification.Subject).Warn(notification.Message)
+	return nil
+}
@@ -0,0 +1,104 @@
+package payment
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	gonanoid "github.com/matoous/go-nanoid/v2"
+	"github.com/notblessy/rms/model"
+	"github.com/sirupsen/logrus"
+)
+
+// EventHandler receives the events a gateway reports for payments.
+type EventHandler func(ctx context.Context, event model.PaymentEvent) error
+
+// FakeGateway is an in-process payment provider for development and tests.
+// Every charge and refund succeeds: after settleDelay the gateway reports it
+// as paid to the handler registered with OnEvent, the same way a real
+// provider would call our webhook.
+type FakeGateway struct {
+	secret      []byte
+	settleDelay time.Duration
+	onEvent     EventHandler
+}
+
+// NewFakeGateway :nodoc:
+func NewFakeGateway(secret string, settleDelay time.Duration) *FakeGateway {
+	return &FakeGateway{
+		secret:      []byte(secret),
+		settleDelay: settleDelay,
+	}
+}
+
+// OnEvent registers the handler settled payments are reported to. Without
+// one, payments stay pending until a signed webhook is posted.
+func (f *FakeGateway) OnEvent(handler EventHandler) {
+	f.onEvent = handler
+}
+
+func (f *FakeGateway) Name() string {
+	return "fake"
+}
+
+func (f *FakeGateway) Charge(ctx context.Context, payment model.Payment) (string, error) {
+	return f.accept("ch_", payment)
+}
+
+func (f *FakeGateway) Refund(ctx context.Context, payment model.Payment) (string, error) {
+	return f.accept("re_", payment)
+}
+
+// Sign returns the signature ParseWebhook expects for payload, so webhooks
+// can be posted by hand during development.
+func (f *FakeGateway) Sign(payload []byte) string {
+	mac := hmac.New(sha256.New, f.secret)
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (f *FakeGateway) ParseWebhook(signature string, payload []byte) (model.PaymentEvent, error) {
+	if !hmac.Equal([]byte(signature), []byte(f.Sign(payload))) {
+		return model.PaymentEvent{}, model.ErrInvalidWebhookSignature
+	}
+
+	var event model.PaymentEvent
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return model.PaymentEvent{}, err
+	}
+
+	return event, nil
+}
+
+func (f *FakeGateway) accept(prefix string, payment model.Payment) (string, error) {
+	id, err := gonanoid.New()
+	if err != nil {
+		return "", err
+	}
+
+	ref := "fake_" + prefix + id
+
+	if f.onEvent != nil {
+		go f.settle(payment.ID, ref)
+	}
+
+	return ref, nil
+}
+
+func (f *FakeGateway) settle(paymentID, ref string) {
+	time.Sleep(f.settleDelay)
+
+	event := model.PaymentEvent{
+		PaymentID:   paymentID,
+		ProviderRef: ref,
+		Status:      model.PaymentStatusPaid,
+		OccurredAt:  time.Now(),
+	}
+
+	if err := f.onEvent(context.Background(), event); err != nil {
+		logrus.WithField("event", event).Errorf("Error settling fake payment: %v", err)
+	}
+}
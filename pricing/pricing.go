@@ -0,0 +1,239 @@
+package pricing
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/notblessy/rms/model"
+	"github.com/shopspring/decimal"
+)
+
+// RentalTypeRule describes the discount granted for a rental type and the
+// minimum number of nights needed to qualify for it.
+type RentalTypeRule struct {
+	MinNights    int
+	DiscountRate decimal.Decimal
+}
+
+// DefaultRentalTypes :nodoc:
+var DefaultRentalTypes = map[string]RentalTypeRule{
+	model.RentalTypeDaily:   {MinNights: 1, DiscountRate: decimal.Zero},
+	model.RentalTypeWeekly:  {MinNights: 7, DiscountRate: decimal.NewFromFloat(0.10)},
+	model.RentalTypeMonthly: {MinNights: 28, DiscountRate: decimal.NewFromFloat(0.20)},
+}
+
+// Input is everything the engine needs to price a rental. The caller loads
+// the camper, equipment and driver so prices always come from the database.
+type Input struct {
+	StartDate  time.Time
+	EndDate    time.Time
+	RentalType string
+	Camper     model.Camper
+	Equipments []EquipmentLine
+	Bundles    []BundleLine
+	Driver     *model.Driver
+}
+
+// EquipmentLine is an equipment and how many of it are rented.
+type EquipmentLine struct {
+	Equipment model.Equipment
+	Quantity  int
+}
+
+// BundleLine is an equipment bundle and how many of it are rented.
+type BundleLine struct {
+	Bundle   model.EquipmentBundle
+	Quantity int
+}
+
+type Quote struct {
+	RentalType string                 `json:"rental_type"`
+	Nights     int                    `json:"nights"`
+	LineItems  []model.RentalLineItem `json:"line_items"`
+	Subtotal   decimal.Decimal        `json:"subtotal"`
+	Discount   decimal.Decimal        `json:"discount"`
+	Tax        decimal.Decimal        `json:"tax"`
+	GrandTotal decimal.Decimal        `json:"grand_total"`
+	DepositDue decimal.Decimal        `json:"deposit_due"`
+}
+
+type Engine struct {
+	rentalTypes map[string]RentalTypeRule
+	taxRate     decimal.Decimal
+	depositRate decimal.Decimal
+}
+
+// NewEngine :nodoc:
+func NewEngine(rentalTypes map[string]RentalTypeRule, taxRate, depositRate decimal.Decimal) *Engine {
+	return &Engine{
+		rentalTypes: rentalTypes,
+		taxRate:     taxRate,
+		depositRate: depositRate,
+	}
+}
+
+// Calculate prices the camper, every rented equipment unit and bundle per
+// night, adds the driver's daily rate, applies the rental type discount to
+// the camper, equipment and bundles, and taxes the discounted total. The
+// deposit due before the rental can be confirmed is a share of the grand
+// total.
+func (e *Engine) Calculate(in Input) (Quote, error) {
+	rental := model.Rental{StartDate: in.StartDate, EndDate: in.EndDate}
+	if !in.EndDate.After(in.StartDate) {
+		return Quote{}, model.ErrInvalidRentalPeriod
+	}
+
+	nights := rental.Nights()
+	if nights < 1 {
+		nights = 1
+	}
+
+	rule, ok := e.rentalTypes[in.RentalType]
+	if !ok || nights < rule.MinNights {
+		return Quote{}, model.ErrInvalidRentalType
+	}
+
+	var lineItems []model.RentalLineItem
+
+	lineItems = append(lineItems, lineItem(model.LineItemCamper, in.Camper.ID, in.Camper.Name, nights, in.Camper.Price))
+	discountable := lineItems[0].Amount
+
+	for _, line := range in.Equipments {
+		quantity := line.Quantity
+		if quantity < 1 {
+			quantity = 1
+		}
+
+		equipment := line.Equipment
+		item := lineItem(model.LineItemEquipment, equipment.ID, equipment.Name, nights*quantity, equipment.Price)
+		lineItems = append(lineItems, item)
+		discountable = discountable.Add(item.Amount)
+	}
+
+	for _, line := range in.Bundles {
+		quantity := line.Quantity
+		if quantity < 1 {
+			quantity = 1
+		}
+
+		bundle := line.Bundle
+		item := lineItem(model.LineItemBundle, bundle.ID, bundle.Name, nights*quantity, bundle.Price)
+		lineItems = append(lineItems, item)
+		discountable = discountable.Add(item.Amount)
+	}
+
+	subtotal := discountable
+	if in.Driver != nil {
+		item := lineItem(model.LineItemDriver, in.Driver.ID, in.Driver.Name, nights, in.Driver.DailyRate)
+		lineItems = append(lineItems, item)
+		subtotal = subtotal.Add(item.Amount)
+	}
+
+	discount := discountable.Mul(rule.DiscountRate).Round(2)
+	if discount.IsPositive() {
+		lineItems = append(lineItems, model.RentalLineItem{
+			Kind:        model.LineItemDiscount,
+			Description: fmt.Sprintf("%s rental discount", in.RentalType),
+			Quantity:    1,
+			UnitPrice:   discount.Neg(),
+			Amount:      discount.Neg(),
+		})
+	}
+
+	tax := subtotal.Sub(discount).Mul(e.taxRate).Round(2)
+	if tax.IsPositive() {
+		lineItems = append(lineItems, model.RentalLineItem{
+			Kind:        model.LineItemTax,
+			Description: fmt.Sprintf("Tax %s%%", e.taxRate.Mul(decimal.NewFromInt(100)).String()),
+			Quantity:    1,
+			UnitPrice:   tax,
+			Amount:      tax,
+		})
+	}
+
+	grandTotal := subtotal.Sub(discount).Add(tax)
+
+	return Quote{
+		RentalType: in.RentalType,
+		Nights:     nights,
+		LineItems:  lineItems,
+		Subtotal:   subtotal,
+		Discount:   discount,
+		Tax:        tax,
+		GrandTotal: grandTotal,
+		DepositDue: grandTotal.Mul(e.depositRate).Round(2),
+	}, nil
+}
+
+// Reprice prices rental as if it ended on endDate, at the rates stored on its
+// line items rather than today's prices. When the new period is too short for
+// the rental's type, the longest type it still qualifies for is used.
+func (e *Engine) Reprice(rental model.Rental, endDate time.Time) (Quote, error) {
+	in := Input{
+		StartDate:  rental.StartDate,
+		EndDate:    endDate,
+		RentalType: rental.RentalType,
+	}
+
+	quantities := make(map[string]int)
+	for _, equipment := range rental.RentalEquipments {
+		quantities[equipment.EquipmentID] = equipment.Quantity
+	}
+
+	bundles := make(map[string]int)
+	for _, bundle := range rental.RentalBundles {
+		bundles[bundle.BundleID] = bundle.Quantity
+	}
+
+	for _, item := range rental.LineItems {
+		switch item.Kind {
+		case model.LineItemCamper:
+			in.Camper = model.Camper{ID: item.ReferenceID, Name: item.Description, Price: item.UnitPrice}
+		case model.LineItemEquipment:
+			in.Equipments = append(in.Equipments, EquipmentLine{
+				Equipment: model.Equipment{ID: item.ReferenceID, Name: item.Description, Price: item.UnitPrice},
+				Quantity:  quantities[item.ReferenceID],
+			})
+		case model.LineItemBundle:
+			in.Bundles = append(in.Bundles, BundleLine{
+				Bundle:   model.EquipmentBundle{ID: item.ReferenceID, Name: item.Description, Price: item.UnitPrice},
+				Quantity: bundles[item.ReferenceID],
+			})
+		case model.LineItemDriver:
+			in.Driver = &model.Driver{ID: item.ReferenceID, Name: item.Description, DailyRate: item.UnitPrice}
+		}
+	}
+
+	quote, err := e.Calculate(in)
+	if err == nil || !errors.Is(err, model.ErrInvalidRentalType) {
+		return quote, err
+	}
+
+	nights := model.Rental{StartDate: rental.StartDate, EndDate: endDate}.Nights()
+
+	best := ""
+	for rentalType, rule := range e.rentalTypes {
+		if nights >= rule.MinNights && (best == "" || rule.MinNights > e.rentalTypes[best].MinNights) {
+			best = rentalType
+		}
+	}
+
+	if best == "" {
+		return Quote{}, err
+	}
+
+	in.RentalType = best
+	return e.Calculate(in)
+}
+
+func lineItem(kind, referenceID, description string, quantity int, unitPrice decimal.Decimal) model.RentalLineItem {
+	return model.RentalLineItem{
+		Kind:        kind,
+		ReferenceID: referenceID,
+		Description: description,
+		Quantity:    quantity,
+		UnitPrice:   unitPrice,
+		Amount:      unitPrice.Mul(decimal.NewFromInt(int64(quantity))).Round(2),
+	}
+}
@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/notblessy/rms/model"
+	"gorm.io/gorm"
+)
+
+// bookedCamperIDs selects campers holding a non-cancelled rental or an
+// unexpired hold that overlaps [start, end).
+func bookedCamperIDs(db *gorm.DB, start, end time.Time) *gorm.DB {
+	return db.Model(&model.Rental{}).
+		Select("camper_id").
+		Where("status <> ? AND deleted_at IS NULL", model.RentalStatusCancelled).
+		Where("status <> ? OR hold_expires_at > ?", model.RentalStatusHeld, time.Now()).
+		Where("start_date < ? AND end_date > ?", end, start)
+}
+
+// bookedDriverIDs selects drivers assigned to a non-cancelled rental or an
+// unexpired hold that overlaps [start, end).
+func bookedDriverIDs(db *gorm.DB, start, end time.Time) *gorm.DB {
+	return db.Model(&model.Rental{}).
+		Select("driver_id").
+		Where("status <> ? AND deleted_at IS NULL", model.RentalStatusCancelled).
+		Where("status <> ? OR hold_expires_at > ?", model.RentalStatusHeld, time.Now()).
+		Where("start_date < ? AND end_date > ?", end, start)
+}
+
+// maintainedCamperIDs selects campers with a maintenance window that
+// overlaps [start, end).
+func maintainedCamperIDs(db *gorm.DB, start, end time.Time) *gorm.DB {
+	return db.Model(&model.MaintenanceWindow{}).
+		Select("camper_id").
+		Where("start_date < ? AND end_date > ?", end, start)
+}
+
+// damagedCamperIDs selects campers with an open damage report that keeps
+// them off the road until they are repaired.
+func damagedCamperIDs(db *gorm.DB) *gorm.DB {
+	return db.Model(&model.DamageReport{}).
+		Select("camper_id").
+		Where("blocks_camper AND status <> ?", model.DamageStatusRepaired)
+}
+
+// overdueRentals selects active rentals that are past their return time.
+func overdueRentals(db *gorm.DB, now time.Time) *gorm.DB {
+	return db.Model(&model.Rental{}).
+		Where("status = ? AND deleted_at IS NULL", model.RentalStatusActive).
+		Where("end_date < ?", now.Add(-model.ReturnDueTime))
+}
+
+type equipmentReservation struct {
+	EquipmentID string
+	Reserved    int
+}
+
+// reservedEquipment sums, per equipment, the quantity booked by non-cancelled
+// rentals and unexpired holds overlapping [start, end), whether rented on its
+// own or as part of a bundle. excludeID skips the rental being updated.
+func reservedEquipment(db *gorm.DB, equipmentIDs []string, start, end time.Time, excludeID string) (map[string]int, error) {
+	overlapping := func(table string, qb *gorm.DB) *gorm.DB {
+		qb = qb.Joins("JOIN rentals ON rentals.id = "+table+".rental_id").
+			Where("rentals.status <> ? AND rentals.deleted_at IS NULL", model.RentalStatusCancelled).
+			Where("rentals.status <> ? OR rentals.hold_expires_at > ?", model.RentalStatusHeld, time.Now()).
+			Where("rentals.start_date < ? AND rentals.end_date > ?", end, start)
+
+		if excludeID != "" {
+			qb = qb.Where("rentals.id <> ?", excludeID)
+		}
+
+		return qb
+	}
+
+	direct := overlapping("rental_equipments", db.Table("rental_equipments").
+		Select("rental_equipments.equipment_id, rental_equipments.quantity").
+		Where("rental_equipments.equipment_id IN ?", equipmentIDs))
+
+	bundled := overlapping("rental_bundles", db.Table("rental_bundles").
+		Select("equipment_bundle_items.equipment_id, rental_bundles.quantity * equipment_bundle_items.quantity AS quantity").
+		Joins("JOIN equipment_bundle_items ON equipment_bundle_items.bundle_id = rental_bundles.bundle_id").
+		Where("equipment_bundle_items.equipment_id IN ?", equipmentIDs))
+
+	qb := db.Table("(? UNION ALL ?) AS reservations", direct, bundled).
+		Select("equipment_id, SUM(quantity) AS reserved").
+		Group("equipment_id")
+
+	var reservations []equipmentReservation
+	if err := qb.Scan(&reservations).Error; err != nil {
+		return nil, err
+	}
+
+	reserved := make(map[string]int, len(reservations))
+	for _, reservation := range reservations {
+		reserved[reservation.EquipmentID] = reservation.Reserved
+	}
+
+	return reserved, nil
+}
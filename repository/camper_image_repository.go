@@ -0,0 +1,307 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	gonanoid "github.com/matoous/go-nanoid/v2"
+	"github.com/notblessy/rms/model"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type camperImageRepository struct {
+	db      *gorm.DB
+	storage model.FileStorage
+}
+
+// NewCamperImageRepository :nodoc:
+func NewCamperImageRepository(d *gorm.DB, storage model.FileStorage) model.CamperImageRepository {
+	return &camperImageRepository{
+		db:      d,
+		storage: storage,
+	}
+}
+
+func (c *camperImageRepository) FindByCamperID(ctx context.Context, camperID string) ([]model.CamperImage, error) {
+	logger := logrus.WithField("camper_id", camperID)
+
+	images, err := galleryOf(c.db.WithContext(ctx), camperID)
+	if err != nil {
+		logger.Errorf("Error querying camper images: %v", err)
+		return nil, err
+	}
+
+	return images, nil
+}
+
+// Upload stores the photo, with its resized variants, and appends it to the
+// camper's gallery. The first photo of a gallery becomes its primary image.
+func (c *camperImageRepository) Upload(ctx context.Context, camperID string, input model.ImageUpload) (model.CamperImage, error) {
+	logger := logrus.WithFields(logrus.Fields{
+		"camper_id": camperID,
+		"filename":  input.Filename,
+		"size":      len(input.Content),
+	})
+
+	tx := c.db.WithContext(ctx).Begin()
+
+	images, err := lockGallery(tx, camperID)
+	if err != nil {
+		tx.Rollback()
+		logger.Errorf("Error querying camper images: %v", err)
+		return model.CamperImage{}, err
+	}
+
+	id, err := gonanoid.New()
+	if err != nil {
+		tx.Rollback()
+		logger.Errorf("Error generating ID: %v", err)
+		return model.CamperImage{}, err
+	}
+
+	stored, err := storeImage(ctx, c.storage, fmt.Sprintf("campers/%s/%s", camperID, id), input.Content)
+	if err != nil {
+		tx.Rollback()
+		logger.Errorf("Error storing camper image: %v", err)
+		return model.CamperImage{}, err
+	}
+
+	image := model.CamperImage{
+		ID:          id,
+		CamperID:    camperID,
+		StorageKey:  stored.Key,
+		URL:         stored.URL,
+		Variants:    stored.Variants,
+		ContentType: stored.ContentType,
+		Size:        stored.Size,
+		IsPrimary:   len(images) == 0,
+		CreatedBy:   input.ActorID,
+	}
+
+	if len(images) > 0 {
+		image.Position = images[len(images)-1].Position + 1
+	}
+
+	err = tx.Create(&image).Error
+	if err != nil {
+		tx.Rollback()
+		logger.Errorf("Error creating camper image: %v", err)
+		removeStoredFiles(ctx, c.storage, imageKeys(image.StorageKey)...)
+		return model.CamperImage{}, err
+	}
+
+	if image.IsPrimary {
+		err = syncCamperImage(tx, image)
+		if err != nil {
+			tx.Rollback()
+			logger.Errorf("Error updating camper image url: %v", err)
+			removeStoredFiles(ctx, c.storage, imageKeys(image.StorageKey)...)
+			return model.CamperImage{}, err
+		}
+	}
+
+	err = tx.Commit().Error
+	if err != nil {
+		logger.Errorf("Error committing camper image: %v", err)
+		removeStoredFiles(ctx, c.storage, imageKeys(image.StorageKey)...)
+		return model.CamperImage{}, err
+	}
+
+	return image, nil
+}
+
+// Reorder moves the gallery's images into the given order.
+func (c *camperImageRepository) Reorder(ctx context.Context, camperID string, input model.CamperImageOrderInput) ([]model.CamperImage, error) {
+	logger := logrus.WithFields(logrus.Fields{
+		"camper_id": camperID,
+		"image_ids": input.ImageIDs,
+	})
+
+	tx := c.db.WithContext(ctx).Begin()
+
+	images, err := lockGallery(tx, camperID)
+	if err != nil {
+		tx.Rollback()
+		logger.Errorf("Error querying camper images: %v", err)
+		return nil, err
+	}
+
+	err = input.Validate(images)
+	if err != nil {
+		tx.Rollback()
+		logger.Errorf("Error validating image order: %v", err)
+		return nil, err
+	}
+
+	for position, id := range input.ImageIDs {
+		err = tx.Model(&model.CamperImage{}).Where("id = ?", id).Update("position", position).Error
+		if err != nil {
+			tx.Rollback()
+			logger.Errorf("Error updating image position: %v", err)
+			return nil, err
+		}
+	}
+
+	images, err = galleryOf(tx, camperID)
+	if err != nil {
+		tx.Rollback()
+		logger.Errorf("Error querying camper images: %v", err)
+		return nil, err
+	}
+
+	tx.Commit()
+	return images, nil
+}
+
+// SetPrimary makes the image the gallery's primary image and the camper's
+// image_url.
+func (c *camperImageRepository) SetPrimary(ctx context.Context, camperID, id string) (model.CamperImage, error) {
+	logger := logrus.WithFields(logrus.Fields{
+		"camper_id": camperID,
+		"id":        id,
+	})
+
+	tx := c.db.WithContext(ctx).Begin()
+
+	images, err := lockGallery(tx, camperID)
+	if err != nil {
+		tx.Rollback()
+		logger.Errorf("Error querying camper images: %v", err)
+		return model.CamperImage{}, err
+	}
+
+	image, ok := findImage(images, id)
+	if !ok {
+		tx.Rollback()
+		logger.Errorf("Camper image not found")
+		return model.CamperImage{}, model.ErrCamperImageNotFound
+	}
+
+	err = tx.Model(&model.CamperImage{}).Where("camper_id = ?", camperID).Update("is_primary", gorm.Expr("id = ?", id)).Error
+	if err != nil {
+		tx.Rollback()
+		logger.Errorf("Error updating primary image: %v", err)
+		return model.CamperImage{}, err
+	}
+
+	err = syncCamperImage(tx, image)
+	if err != nil {
+		tx.Rollback()
+		logger.Errorf("Error updating camper image url: %v", err)
+		return model.CamperImage{}, err
+	}
+
+	tx.Commit()
+
+	image.IsPrimary = true
+	return image, nil
+}
+
+// Delete removes the image from the gallery and from storage. When it was
+// the primary image, the next image in the gallery takes its place.
+func (c *camperImageRepository) Delete(ctx context.Context, camperID, id string) error {
+	logger := logrus.WithFields(logrus.Fields{
+		"camper_id": camperID,
+		"id":        id,
+	})
+
+	tx := c.db.WithContext(ctx).Begin()
+
+	images, err := lockGallery(tx, camperID)
+	if err != nil {
+		tx.Rollback()
+		logger.Errorf("Error querying camper images: %v", err)
+		return err
+	}
+
+	image, ok := findImage(images, id)
+	if !ok {
+		tx.Rollback()
+		logger.Errorf("Camper image not found")
+		return model.ErrCamperImageNotFound
+	}
+
+	err = tx.Delete(&image).Error
+	if err != nil {
+		tx.Rollback()
+		logger.Errorf("Error deleting camper image: %v", err)
+		return err
+	}
+
+	if image.IsPrimary {
+		err = c.promoteNext(tx, camperID, images, id)
+		if err != nil {
+			tx.Rollback()
+			logger.Errorf("Error promoting primary image: %v", err)
+			return err
+		}
+	}
+
+	tx.Commit()
+
+	removeStoredFiles(ctx, c.storage, imageKeys(image.StorageKey)...)
+	return nil
+}
+
+// promoteNext makes the first image left in the gallery its primary image,
+// or clears the camper's image_url when the gallery is now empty.
+func (c *camperImageRepository) promoteNext(tx *gorm.DB, camperID string, images []model.CamperImage, deletedID string) error {
+	for _, image := range images {
+		if image.ID == deletedID {
+			continue
+		}
+
+		err := tx.Model(&image).Update("is_primary", true).Error
+		if err != nil {
+			return err
+		}
+
+		return syncCamperImage(tx, image)
+	}
+
+	return syncCamperImage(tx, model.CamperImage{CamperID: camperID})
+}
+
+// lockGallery locks the camper for the rest of tx, so concurrent changes to
+// its gallery are applied one at a time, and returns the gallery.
+func lockGallery(tx *gorm.DB, camperID string) ([]model.CamperImage, error) {
+	var camper model.Camper
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").Where("id = ?", camperID).First(&camper).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return galleryOf(tx, camperID)
+}
+
+func galleryOf(db *gorm.DB, camperID string) ([]model.CamperImage, error) {
+	var images []model.CamperImage
+	err := db.Where("camper_id = ?", camperID).Order("position ASC, created_at ASC").Find(&images).Error
+	return images, err
+}
+
+func findImage(images []model.CamperImage, id string) (model.CamperImage, bool) {
+	for _, image := range images {
+		if image.ID == id {
+			return image, true
+		}
+	}
+
+	return model.CamperImage{}, false
+}
+
+// syncCamperImage copies the primary image onto the camper, clearing the
+// camper's image when given an empty one.
+func syncCamperImage(tx *gorm.DB, primary model.CamperImage) error {
+	variants := primary.Variants
+	if variants == nil {
+		variants = model.ImageVariants{}
+	}
+
+	return tx.Model(&model.Camper{}).
+		Where("id = ?", primary.CamperID).
+		Select("image_url", "image_variants").
+		Updates(model.Camper{ImageUrl: primary.URL, ImageVariants: variants}).Error
+}
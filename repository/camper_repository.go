@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	gonanoid "github.com/matoous/go-nanoid/v2"
 	"github.com/notblessy/rms/model"
@@ -11,13 +12,15 @@ import (
 )
 
 type camperRepository struct {
-	db *gorm.DB
+	db      *gorm.DB
+	storage model.FileStorage
 }
 
 // NewCamperRepository :nodoc:
-func NewCamperRepository(d *gorm.DB) model.CamperRepository {
+func NewCamperRepository(d *gorm.DB, storage model.FileStorage) model.CamperRepository {
 	return &camperRepository{
-		db: d,
+		db:      d,
+		storage: storage,
 	}
 }
 
@@ -25,7 +28,12 @@ func (c *camperRepository) FindByID(ctx context.Context, id string) (model.Campe
 	logger := logrus.WithField("id", id)
 
 	var camper model.Camper
-	err := c.db.WithContext(ctx).Where("id = ?", id).First(&camper).Error
+	err := c.db.WithContext(ctx).
+		Preload("Images", func(db *gorm.DB) *gorm.DB {
+			return db.Order("position ASC, created_at ASC")
+		}).
+		Where("id = ?", id).
+		First(&camper).Error
 	if err != nil {
 		logger.Errorf("Error querying camper: %v", err)
 		return model.Camper{}, err
@@ -43,19 +51,19 @@ func (c *camperRepository) FindAll(ctx context.Context, query model.CamperQueryI
 	var campers []model.Camper
 	var total int64
 
-	qb := c.db.WithContext(ctx).Model(&model.Camper{})
-
-	if query.Keyword != "" {
-		qb = qb.Where("name ILIKE ?", "%"+query.Keyword+"%")
+	qb, err := c.filter(c.db.WithContext(ctx), query, "")
+	if err != nil {
+		logger.Errorf("Error parsing camper filters: %v", err)
+		return nil, 0, err
 	}
 
-	err := qb.Count(&total).Error
+	err = qb.Count(&total).Error
 	if err != nil {
 		logger.Errorf("Error counting campers: %v", err)
 		return nil, 0, err
 	}
 
-	err = qb.Scopes(query.Paginated()).Order(query.Sorted()).Find(&campers).Error
+	err = qb.Scopes(query.Paginated()).Order(keywordOrder("campers", query.Keyword, query.PaginatedRequest)).Find(&campers).Error
 	if err != nil {
 		logger.Errorf("Error querying campers: %v", err)
 		return nil, 0, err
@@ -64,6 +72,163 @@ func (c *camperRepository) FindAll(ctx context.Context, query model.CamperQueryI
 	return campers, total, nil
 }
 
+// Facets counts the campers matching the query for every value of each
+// filter.
+func (c *camperRepository) Facets(ctx context.Context, query model.CamperQueryInput) (model.CamperFacets, error) {
+	logger := logrus.WithField("query", utils.Dump(query))
+
+	var facets model.CamperFacets
+
+	columns := []struct {
+		facet  string
+		column string
+		counts *[]model.FacetCount
+	}{
+		{model.CamperFacetTransmission, "campers.transmission", &facets.Transmission},
+		{model.CamperFacetFuelType, "campers.fuel_type", &facets.FuelType},
+		{model.CamperFacetDrivetrain, "campers.drivetrain_config", &facets.Drivetrain},
+		{model.CamperFacetCondition, "campers.condition", &facets.Condition},
+		{model.CamperFacetYear, "campers.year", &facets.Year},
+		{model.CamperFacetCapacity, "campers.capacity", &facets.Capacity},
+	}
+
+	for _, col := range columns {
+		qb, err := c.filter(c.db.WithContext(ctx), query, col.facet)
+		if err != nil {
+			logger.Errorf("Error parsing camper filters: %v", err)
+			return model.CamperFacets{}, err
+		}
+
+		err = qb.Select(col.column + "::text AS value, COUNT(*) AS count").
+			Where("COALESCE(" + col.column + "::text, '') <> ''").
+			Group(col.column).
+			Order(col.column).
+			Scan(col.counts).Error
+		if err != nil {
+			logger.Errorf("Error counting %s facet: %v", col.facet, err)
+			return model.CamperFacets{}, err
+		}
+	}
+
+	qb, err := c.filter(c.db.WithContext(ctx), query, model.CamperFacetEquipment)
+	if err != nil {
+		logger.Errorf("Error parsing camper filters: %v", err)
+		return model.CamperFacets{}, err
+	}
+
+	err = qb.Joins("JOIN camper_equipments ON camper_equipments.camper_id = campers.id").
+		Joins("JOIN equipments ON equipments.id = camper_equipments.equipment_id").
+		Select("equipments.id AS value, equipments.name AS label, COUNT(DISTINCT campers.id) AS count").
+		Group("equipments.id, equipments.name").
+		Order("equipments.name").
+		Scan(&facets.Equipment).Error
+	if err != nil {
+		logger.Errorf("Error counting equipment facet: %v", err)
+		return model.CamperFacets{}, err
+	}
+
+	qb, err = c.filter(c.db.WithContext(ctx), query, model.CamperFacetPrice)
+	if err != nil {
+		logger.Errorf("Error parsing camper filters: %v", err)
+		return model.CamperFacets{}, err
+	}
+
+	err = qb.Select("COALESCE(MIN(campers.price), 0) AS min, COALESCE(MAX(campers.price), 0) AS max").
+		Scan(&facets.Price).Error
+	if err != nil {
+		logger.Errorf("Error querying price facet: %v", err)
+		return model.CamperFacets{}, err
+	}
+
+	return facets, nil
+}
+
+// filter applies the query's filters to the campers, except the one named
+// by skip, so that facet can be counted across all of its values. Columns
+// are qualified because facets join other tables onto the campers.
+func (c *camperRepository) filter(db *gorm.DB, query model.CamperQueryInput, skip string) (*gorm.DB, error) {
+	err := query.ValidateFilters()
+	if err != nil {
+		return nil, err
+	}
+
+	from, to, filterAvailable, err := query.AvailablePeriod()
+	if err != nil {
+		return nil, err
+	}
+
+	minPrice, maxPrice, err := query.PriceRange()
+	if err != nil {
+		return nil, err
+	}
+
+	qb := db.Model(&model.Camper{})
+
+	if query.Keyword != "" {
+		qb = qb.Scopes(matchKeyword("campers", query.Keyword))
+	}
+
+	if filterAvailable {
+		qb = qb.Where("campers.id NOT IN (?)", bookedCamperIDs(c.db, from, to)).
+			Where("campers.id NOT IN (?)", maintainedCamperIDs(c.db, from, to)).
+			Where("campers.id NOT IN (?)", damagedCamperIDs(c.db))
+	}
+
+	if skip != model.CamperFacetCapacity && query.MinCapacity > 0 {
+		qb = qb.Where("campers.capacity >= ?", query.MinCapacity)
+	}
+
+	if skip != model.CamperFacetPrice {
+		if minPrice != nil {
+			qb = qb.Where("campers.price >= ?", *minPrice)
+		}
+
+		if maxPrice != nil {
+			qb = qb.Where("campers.price <= ?", *maxPrice)
+		}
+	}
+
+	if skip != model.CamperFacetYear {
+		if query.MinYear > 0 {
+			qb = qb.Where("campers.year >= ?", query.MinYear)
+		}
+
+		if query.MaxYear > 0 {
+			qb = qb.Where("campers.year <= ?", query.MaxYear)
+		}
+	}
+
+	oneOf := []struct {
+		facet  string
+		column string
+		values []string
+	}{
+		{model.CamperFacetTransmission, "campers.transmission", query.Transmissions},
+		{model.CamperFacetFuelType, "campers.fuel_type", query.FuelTypes},
+		{model.CamperFacetDrivetrain, "campers.drivetrain_config", query.Drivetrains},
+		{model.CamperFacetCondition, "campers.condition", query.Conditions},
+	}
+
+	for _, filter := range oneOf {
+		if skip != filter.facet && len(filter.values) > 0 {
+			qb = qb.Where(filter.column+" IN ?", filter.values)
+		}
+	}
+
+	equipmentIDs := query.RequiredEquipmentIDs()
+	if skip != model.CamperFacetEquipment && len(equipmentIDs) > 0 {
+		equipped := c.db.Model(&model.CamperEquipment{}).
+			Select("camper_id").
+			Where("equipment_id IN ?", equipmentIDs).
+			Group("camper_id").
+			Having("COUNT(DISTINCT equipment_id) = ?", len(equipmentIDs))
+
+		qb = qb.Where("campers.id IN (?)", equipped)
+	}
+
+	return qb, nil
+}
+
 func (c *camperRepository) Create(ctx context.Context, camper model.CamperInput) error {
 	logger := logrus.WithField("camper", utils.Dump(camper))
 
@@ -73,18 +238,19 @@ func (c *camperRepository) Create(ctx context.Context, camper model.CamperInput)
 		return err
 	}
 
+	camper.ID = id
 	payload := camper.ToEntity(id)
 
 	tx := c.db.WithContext(ctx).Begin()
 
-	err = tx.Create(&payload).Error
+	err = tx.Omit("image_variants").Create(&payload).Error
 	if err != nil {
 		tx.Rollback()
 		logger.Errorf("Error creating camper: %v", err)
 		return err
 	}
 
-	if len(camper.EquipmentIDs) > 0 {
+	if camper.HasEquipments() {
 		equipments := camper.Equipments()
 
 		err = tx.Create(&equipments).Error
@@ -103,6 +269,7 @@ func (c *camperRepository) Create(ctx context.Context, camper model.CamperInput)
 func (c *camperRepository) Update(ctx context.Context, id string, camper model.CamperInput) error {
 	logger := logrus.WithField("id", id)
 
+	camper.ID = id
 	payload := camper.ToEntity(id)
 
 	tx := c.db.WithContext(ctx).Begin()
@@ -114,7 +281,7 @@ func (c *camperRepository) Update(ctx context.Context, id string, camper model.C
 		return err
 	}
 
-	if len(camper.EquipmentIDs) > 0 {
+	if camper.HasEquipments() {
 		equipments := camper.Equipments()
 
 		err = tx.Where("camper_id = ?", id).Delete(&model.CamperEquipment{}).Error
@@ -138,14 +305,139 @@ func (c *camperRepository) Update(ctx context.Context, id string, camper model.C
 	return nil
 }
 
+// Delete removes the camper, and with it its gallery, then deletes the
+// gallery's files from storage.
 func (c *camperRepository) Delete(ctx context.Context, id string) error {
 	logger := logrus.WithField("id", id)
 
-	err := c.db.WithContext(ctx).Where("id = ?", id).Delete(&model.Camper{}).Error
+	tx := c.db.WithContext(ctx).Begin()
+
+	images, err := lockGallery(tx, id)
+	if err != nil {
+		tx.Rollback()
+		logger.Errorf("Error querying camper images: %v", err)
+		return err
+	}
+
+	err = tx.Where("id = ?", id).Delete(&model.Camper{}).Error
 	if err != nil {
+		tx.Rollback()
 		logger.Errorf("Error deleting camper: %v", err)
 		return err
 	}
 
+	tx.Commit()
+
+	var keys []string
+	for _, image := range images {
+		keys = append(keys, imageKeys(image.StorageKey)...)
+	}
+
+	removeStoredFiles(ctx, c.storage, keys...)
+	return nil
+}
+
+func (c *camperRepository) Availability(ctx context.Context, id string, from, to time.Time) (model.CamperAvailability, error) {
+	logger := logrus.WithFields(logrus.Fields{
+		"id":   id,
+		"from": from,
+		"to":   to,
+	})
+
+	var camper model.Camper
+	err := c.db.WithContext(ctx).Select("id").Where("id = ?", id).First(&camper).Error
+	if err != nil {
+		logger.Errorf("Error querying camper: %v", err)
+		return model.CamperAvailability{}, err
+	}
+
+	var rentals []model.Rental
+	err = bookedCamperIDs(c.db.WithContext(ctx), from, to).
+		Select("*").
+		Where("camper_id = ?", id).
+		Find(&rentals).Error
+	if err != nil {
+		logger.Errorf("Error querying rentals: %v", err)
+		return model.CamperAvailability{}, err
+	}
+
+	var windows []model.MaintenanceWindow
+	err = maintainedCamperIDs(c.db.WithContext(ctx), from, to).
+		Select("*").
+		Where("camper_id = ?", id).
+		Find(&windows).Error
+	if err != nil {
+		logger.Errorf("Error querying maintenance windows: %v", err)
+		return model.CamperAvailability{}, err
+	}
+
+	var reports []model.DamageReport
+	err = damagedCamperIDs(c.db.WithContext(ctx)).
+		Select("*").
+		Where("camper_id = ?", id).
+		Find(&reports).Error
+	if err != nil {
+		logger.Errorf("Error querying damage reports: %v", err)
+		return model.CamperAvailability{}, err
+	}
+
+	// A damaged camper stays off the road until it is repaired, so it is
+	// shown in maintenance from the day the damage was reported onwards.
+	for _, report := range reports {
+		windows = append(windows, model.MaintenanceWindow{
+			CamperID:  id,
+			StartDate: report.CreatedAt,
+			EndDate:   to,
+			Reason:    report.Description,
+		})
+	}
+
+	return model.CamperAvailability{
+		CamperID: id,
+		From:     from,
+		To:       to,
+		Ranges:   model.BuildAvailability(from, to, rentals, windows),
+	}, nil
+}
+
+func (c *camperRepository) CreateMaintenanceWindow(ctx context.Context, window model.MaintenanceWindow) error {
+	logger := logrus.WithField("window", utils.Dump(window))
+
+	if !window.EndDate.After(window.StartDate) {
+		logger.Errorf("Invalid maintenance window")
+		return model.ErrInvalidMaintenanceWindow
+	}
+
+	id, err := gonanoid.New()
+	if err != nil {
+		logger.Errorf("Error generating ID: %v", err)
+		return err
+	}
+
+	window.ID = id
+
+	err = c.db.WithContext(ctx).Create(&window).Error
+	if err != nil {
+		logger.Errorf("Error creating maintenance window: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func (c *camperRepository) DeleteMaintenanceWindow(ctx context.Context, camperID, windowID string) error {
+	logger := logrus.WithFields(logrus.Fields{
+		"camper_id": camperID,
+		"window_id": windowID,
+	})
+
+	err := c.db.WithContext(ctx).
+		Where("id = ? AND camper_id = ?", windowID, camperID).
+		Delete(&model.MaintenanceWindow{}).Error
+	if err != nil {
+		logger.Errorf("Error deleting maintenance window: %v", err)
+		return err
+	}
+
 	return nil
 }
@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"context"
+
+	gonanoid "github.com/matoous/go-nanoid/v2"
+	"github.com/notblessy/rms/model"
+	"github.com/notblessy/rms/utils"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type cancellationPolicyRepository struct {
+	db *gorm.DB
+}
+
+// NewCancellationPolicyRepository :nodoc:
+func NewCancellationPolicyRepository(d *gorm.DB) model.CancellationPolicyRepository {
+	return &cancellationPolicyRepository{
+		db: d,
+	}
+}
+
+func (c *cancellationPolicyRepository) FindByID(ctx context.Context, id string) (model.CancellationPolicy, error) {
+	logger := logrus.WithField("id", id)
+
+	var policy model.CancellationPolicy
+	err := c.db.WithContext(ctx).Preload("Tiers").Where("id = ?", id).First(&policy).Error
+	if err != nil {
+		logger.Errorf("Error querying cancellation policy: %v", err)
+		return model.CancellationPolicy{}, err
+	}
+
+	return policy, nil
+}
+
+func (c *cancellationPolicyRepository) FindAll(ctx context.Context) ([]model.CancellationPolicy, error) {
+	var policies []model.CancellationPolicy
+	err := c.db.WithContext(ctx).Preload("Tiers").Order("created_at DESC").Find(&policies).Error
+	if err != nil {
+		logrus.Errorf("Error querying cancellation policies: %v", err)
+		return nil, err
+	}
+
+	return policies, nil
+}
+
+func (c *cancellationPolicyRepository) Create(ctx context.Context, policy model.CancellationPolicy) (model.CancellationPolicy, error) {
+	logger := logrus.WithField("policy", utils.Dump(policy))
+
+	if err := policy.Validate(); err != nil {
+		logger.Errorf("Invalid cancellation policy: %v", err)
+		return model.CancellationPolicy{}, err
+	}
+
+	id, err := gonanoid.New()
+	if err != nil {
+		logger.Errorf("Error generating ID: %v", err)
+		return model.CancellationPolicy{}, err
+	}
+
+	policy.ID = id
+	for i := range policy.Tiers {
+		policy.Tiers[i].PolicyID = id
+	}
+
+	err = c.db.WithContext(ctx).Create(&policy).Error
+	if err != nil {
+		logger.Errorf("Error creating cancellation policy: %v", err)
+		return model.CancellationPolicy{}, err
+	}
+
+	return policy, nil
+}
+
+func (c *cancellationPolicyRepository) Update(ctx context.Context, id string, policy model.CancellationPolicy) error {
+	logger := logrus.WithFields(logrus.Fields{
+		"id":     id,
+		"policy": utils.Dump(policy),
+	})
+
+	if err := policy.Validate(); err != nil {
+		logger.Errorf("Invalid cancellation policy: %v", err)
+		return err
+	}
+
+	tx := c.db.WithContext(ctx).Begin()
+
+	err := tx.Model(&model.CancellationPolicy{}).
+		Omit(clause.Associations).
+		Where("id = ?", id).
+		Select("name", "camper_id").
+		Updates(policy).Error
+	if err != nil {
+		tx.Rollback()
+		logger.Errorf("Error updating cancellation policy: %v", err)
+		return err
+	}
+
+	err = tx.Where("policy_id = ?", id).Delete(&model.CancellationTier{}).Error
+	if err != nil {
+		tx.Rollback()
+		logger.Errorf("Error deleting cancellation tiers: %v", err)
+		return err
+	}
+
+	if len(policy.Tiers) > 0 {
+		for i := range policy.Tiers {
+			policy.Tiers[i].PolicyID = id
+		}
+
+		err = tx.Create(&policy.Tiers).Error
+		if err != nil {
+			tx.Rollback()
+			logger.Errorf("Error creating cancellation tiers: %v", err)
+			return err
+		}
+	}
+
+	tx.Commit()
+	return nil
+}
+
+func (c *cancellationPolicyRepository) Delete(ctx context.Context, id string) error {
+	logger := logrus.WithField("id", id)
+
+	err := c.db.WithContext(ctx).Where("id = ?", id).Delete(&model.CancellationPolicy{}).Error
+	if err != nil {
+		logger.Errorf("Error deleting cancellation policy: %v", err)
+		return err
+	}
+
+	return nil
+}
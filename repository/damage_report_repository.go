@@ -0,0 +1,237 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	gonanoid "github.com/matoous/go-nanoid/v2"
+	"github.com/notblessy/rms/model"
+	"github.com/notblessy/rms/utils"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type damageReportRepository struct {
+	db *gorm.DB
+}
+
+// NewDamageReportRepository :nodoc:
+func NewDamageReportRepository(d *gorm.DB) model.DamageReportRepository {
+	return &damageReportRepository{
+		db: d,
+	}
+}
+
+func (d *damageReportRepository) FindByID(ctx context.Context, id string) (model.DamageReport, error) {
+	logger := logrus.WithField("id", id)
+
+	var report model.DamageReport
+	err := d.db.WithContext(ctx).Where("id = ?", id).First(&report).Error
+	if err != nil {
+		logger.Errorf("Error querying damage report: %v", err)
+		return model.DamageReport{}, err
+	}
+
+	return report, nil
+}
+
+func (d *damageReportRepository) FindByRentalID(ctx context.Context, rentalID string) ([]model.DamageReport, error) {
+	logger := logrus.WithField("rental_id", rentalID)
+
+	var reports []model.DamageReport
+	err := d.db.WithContext(ctx).Where("rental_id = ?", rentalID).Order("created_at ASC").Find(&reports).Error
+	if err != nil {
+		logger.Errorf("Error querying damage reports: %v", err)
+		return nil, err
+	}
+
+	return reports, nil
+}
+
+func (d *damageReportRepository) Create(ctx context.Context, rentalID string, input model.DamageReportInput) (model.DamageReport, error) {
+	logger := logrus.WithFields(logrus.Fields{
+		"rental_id": rentalID,
+		"input":     utils.Dump(input),
+	})
+
+	var rental model.Rental
+	err := d.db.WithContext(ctx).Preload("RentalEquipments").Where("id = ?", rentalID).First(&rental).Error
+	if err != nil {
+		logger.Errorf("Error querying rental: %v", err)
+		return model.DamageReport{}, err
+	}
+
+	err = input.Validate(rental)
+	if err != nil {
+		logger.Errorf("Error validating damage report: %v", err)
+		return model.DamageReport{}, err
+	}
+
+	id, err := gonanoid.New()
+	if err != nil {
+		logger.Errorf("Error generating ID: %v", err)
+		return model.DamageReport{}, err
+	}
+
+	report := input.ToEntity(id, rental)
+
+	err = d.db.WithContext(ctx).Create(&report).Error
+	if err != nil {
+		logger.Errorf("Error creating damage report: %v", err)
+		return model.DamageReport{}, err
+	}
+
+	return report, nil
+}
+
+func (d *damageReportRepository) Assess(ctx context.Context, id string, input model.DamageAssessInput) (model.DamageReport, error) {
+	return d.update(ctx, id, input, func(report *model.DamageReport) error {
+		return report.Assess(input, time.Now())
+	})
+}
+
+func (d *damageReportRepository) Repair(ctx context.Context, id string, input model.DamageRepairInput) (model.DamageReport, error) {
+	return d.update(ctx, id, input, func(report *model.DamageReport) error {
+		return report.Repair(input, time.Now())
+	})
+}
+
+// Charge bills an assessed damage to the rental: the charge is added to the
+// rental's line items and grand total, and a pending charge payment is
+// created for the caller to submit to the payment gateway.
+func (d *damageReportRepository) Charge(ctx context.Context, id string, input model.DamageChargeInput) (model.DamageReport, model.Payment, error) {
+	logger := logrus.WithFields(logrus.Fields{
+		"id":    id,
+		"input": utils.Dump(input),
+	})
+
+	tx := d.db.WithContext(ctx).Begin()
+
+	var report model.DamageReport
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&report).Error
+	if err != nil {
+		tx.Rollback()
+		logger.Errorf("Error querying damage report: %v", err)
+		return model.DamageReport{}, model.Payment{}, err
+	}
+
+	var rental model.Rental
+	err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", report.RentalID).First(&rental).Error
+	if err != nil {
+		tx.Rollback()
+		logger.Errorf("Error querying rental: %v", err)
+		return model.DamageReport{}, model.Payment{}, err
+	}
+
+	item, err := report.Charge(input, time.Now())
+	if err != nil {
+		tx.Rollback()
+		logger.Errorf("Error charging damage report: %v", err)
+		return model.DamageReport{}, model.Payment{}, err
+	}
+
+	itemID, err := gonanoid.New()
+	if err != nil {
+		tx.Rollback()
+		logger.Errorf("Error generating ID: %v", err)
+		return model.DamageReport{}, model.Payment{}, err
+	}
+
+	item.ID = itemID
+
+	err = tx.Create(&item).Error
+	if err != nil {
+		tx.Rollback()
+		logger.Errorf("Error creating rental line item: %v", err)
+		return model.DamageReport{}, model.Payment{}, err
+	}
+
+	err = tx.Model(&rental).Omit(clause.Associations).Update("grand_total", rental.GrandTotal.Add(item.Amount)).Error
+	if err != nil {
+		tx.Rollback()
+		logger.Errorf("Error updating rental grand total: %v", err)
+		return model.DamageReport{}, model.Payment{}, err
+	}
+
+	paymentID, err := gonanoid.New()
+	if err != nil {
+		tx.Rollback()
+		logger.Errorf("Error generating ID: %v", err)
+		return model.DamageReport{}, model.Payment{}, err
+	}
+
+	payment := model.Payment{
+		ID:        paymentID,
+		RentalID:  rental.ID,
+		Type:      model.PaymentTypeCharge,
+		Status:    model.PaymentStatusPending,
+		Amount:    item.Amount,
+		Provider:  input.Provider,
+		Note:      fmt.Sprintf("Damage report %s", report.ID),
+		CreatedBy: input.ActorID,
+	}
+
+	err = tx.Create(&payment).Error
+	if err != nil {
+		tx.Rollback()
+		logger.Errorf("Error creating payment: %v", err)
+		return model.DamageReport{}, model.Payment{}, err
+	}
+
+	report.PaymentID = &payment.ID
+
+	err = tx.Select("status", "charged_at", "payment_id").Updates(&report).Error
+	if err != nil {
+		tx.Rollback()
+		logger.Errorf("Error updating damage report: %v", err)
+		return model.DamageReport{}, model.Payment{}, err
+	}
+
+	tx.Commit()
+	return report, payment, nil
+}
+
+// update locks the report, applies action to it and saves the result.
+func (d *damageReportRepository) update(ctx context.Context, id string, input any, action func(report *model.DamageReport) error) (model.DamageReport, error) {
+	logger := logrus.WithFields(logrus.Fields{
+		"id":    id,
+		"input": utils.Dump(input),
+	})
+
+	tx := d.db.WithContext(ctx).Begin()
+
+	var report model.DamageReport
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&report).Error
+	if err != nil {
+		tx.Rollback()
+		logger.Errorf("Error querying damage report: %v", err)
+		return model.DamageReport{}, err
+	}
+
+	err = action(&report)
+	if err != nil {
+		tx.Rollback()
+		logger.Errorf("Error updating damage report: %v", err)
+		return model.DamageReport{}, err
+	}
+
+	err = tx.Select(
+		"severity",
+		"estimated_cost",
+		"actual_cost",
+		"status",
+		"blocks_camper",
+		"assessed_at",
+		"repaired_at",
+	).Updates(&report).Error
+	if err != nil {
+		tx.Rollback()
+		logger.Errorf("Error saving damage report: %v", err)
+		return model.DamageReport{}, err
+	}
+
+	tx.Commit()
+	return report, nil
+}
@@ -2,20 +2,25 @@ package repository
 
 import (
 	"context"
+	"fmt"
 
+	gonanoid "github.com/matoous/go-nanoid/v2"
 	"github.com/notblessy/rms/model"
 	"github.com/notblessy/rms/utils"
 	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type driverRepository struct {
-	db *gorm.DB
+	db      *gorm.DB
+	storage model.FileStorage
 }
 
-func NewDriverRepository(d *gorm.DB) model.DriverRepository {
+func NewDriverRepository(d *gorm.DB, storage model.FileStorage) model.DriverRepository {
 	return &driverRepository{
-		db: d,
+		db:      d,
+		storage: storage,
 	}
 }
 
@@ -44,7 +49,7 @@ func (d *driverRepository) FindAll(ctx context.Context, query model.DriverQueryI
 	qb := d.db.WithContext(ctx).Model(&model.Driver{})
 
 	if query.Keyword != "" {
-		qb = qb.Where("name ILIKE ?", "%"+query.Keyword+"%")
+		qb = qb.Scopes(matchKeyword("drivers", query.Keyword))
 	}
 
 	err := qb.Count(&total).Error
@@ -53,7 +58,7 @@ func (d *driverRepository) FindAll(ctx context.Context, query model.DriverQueryI
 		return nil, 0, err
 	}
 
-	err = qb.Scopes(query.Paginated()).Order(query.Sorted()).Find(&drivers).Error
+	err = qb.Scopes(query.Paginated()).Order(keywordOrder("drivers", query.Keyword, query.PaginatedRequest)).Find(&drivers).Error
 	if err != nil {
 		logger.Errorf("Error querying drivers: %v", err)
 		return nil, 0, err
@@ -65,7 +70,7 @@ func (d *driverRepository) FindAll(ctx context.Context, query model.DriverQueryI
 func (d *driverRepository) Create(ctx context.Context, driver model.Driver) error {
 	logger := logrus.WithField("driver", utils.Dump(driver))
 
-	err := d.db.WithContext(ctx).Create(&driver).Error
+	err := d.db.WithContext(ctx).Omit("photo_key", "photo_variants").Create(&driver).Error
 	if err != nil {
 		logger.Errorf("Error creating driver: %v", err)
 		return err
@@ -80,7 +85,7 @@ func (d *driverRepository) Update(ctx context.Context, id string, driver model.D
 		"driver": utils.Dump(driver),
 	})
 
-	err := d.db.WithContext(ctx).Model(&model.Driver{}).Where("id = ?", id).Updates(driver).Error
+	err := d.db.WithContext(ctx).Model(&model.Driver{}).Where("id = ?", id).Omit("photo_key", "photo_variants").Updates(driver).Error
 	if err != nil {
 		logger.Errorf("Error updating driver: %v", err)
 		return err
@@ -92,11 +97,80 @@ func (d *driverRepository) Update(ctx context.Context, id string, driver model.D
 func (d *driverRepository) Delete(ctx context.Context, id string) error {
 	logger := logrus.WithField("id", id)
 
-	err := d.db.WithContext(ctx).Where("id = ?", id).Delete(&model.Driver{}).Error
+	var keys []string
+	err := d.db.WithContext(ctx).Model(&model.Driver{}).Where("id = ?", id).Pluck("photo_key", &keys).Error
+	if err != nil {
+		logger.Errorf("Error querying driver photo: %v", err)
+		return err
+	}
+
+	err = d.db.WithContext(ctx).Where("id = ?", id).Delete(&model.Driver{}).Error
 	if err != nil {
 		logger.Errorf("Error deleting driver: %v", err)
 		return err
 	}
 
+	for _, key := range keys {
+		removeStoredFiles(ctx, d.storage, imageKeys(key)...)
+	}
+
 	return nil
 }
+
+// UploadPhoto stores the photo, with its resized variants, as the driver's
+// photo and removes the photo it replaces.
+func (d *driverRepository) UploadPhoto(ctx context.Context, id string, input model.ImageUpload) (model.Driver, error) {
+	logger := logrus.WithFields(logrus.Fields{
+		"id":       id,
+		"filename": input.Filename,
+		"size":     len(input.Content),
+	})
+
+	tx := d.db.WithContext(ctx).Begin()
+
+	var driver model.Driver
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&driver).Error
+	if err != nil {
+		tx.Rollback()
+		logger.Errorf("Error querying driver: %v", err)
+		return model.Driver{}, err
+	}
+
+	name, err := gonanoid.New()
+	if err != nil {
+		tx.Rollback()
+		logger.Errorf("Error generating ID: %v", err)
+		return model.Driver{}, err
+	}
+
+	stored, err := storeImage(ctx, d.storage, fmt.Sprintf("drivers/%s/%s", id, name), input.Content)
+	if err != nil {
+		tx.Rollback()
+		logger.Errorf("Error storing driver photo: %v", err)
+		return model.Driver{}, err
+	}
+
+	previous := driver.PhotoKey
+
+	driver.Photo = stored.URL
+	driver.PhotoKey = stored.Key
+	driver.PhotoVariants = stored.Variants
+
+	err = tx.Model(&driver).Select("photo", "photo_key", "photo_variants").Updates(&driver).Error
+	if err != nil {
+		tx.Rollback()
+		logger.Errorf("Error updating driver photo: %v", err)
+		removeStoredFiles(ctx, d.storage, imageKeys(stored.Key)...)
+		return model.Driver{}, err
+	}
+
+	err = tx.Commit().Error
+	if err != nil {
+		logger.Errorf("Error committing driver photo: %v", err)
+		removeStoredFiles(ctx, d.storage, imageKeys(stored.Key)...)
+		return model.Driver{}, err
+	}
+
+	removeStoredFiles(ctx, d.storage, imageKeys(previous)...)
+	return driver, nil
+}
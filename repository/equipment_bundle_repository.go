@@ -0,0 +1,172 @@
+package repository
+
+import (
+	"context"
+
+	gonanoid "github.com/matoous/go-nanoid/v2"
+	"github.com/notblessy/rms/model"
+	"github.com/notblessy/rms/utils"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type equipmentBundleRepository struct {
+	db *gorm.DB
+}
+
+// NewEquipmentBundleRepository :nodoc:
+func NewEquipmentBundleRepository(d *gorm.DB) model.EquipmentBundleRepository {
+	return &equipmentBundleRepository{
+		db: d,
+	}
+}
+
+func (e *equipmentBundleRepository) FindByID(ctx context.Context, id string) (model.EquipmentBundle, error) {
+	logger := logrus.WithField("id", id)
+
+	var bundle model.EquipmentBundle
+	err := e.db.WithContext(ctx).Preload("Items").Where("id = ?", id).First(&bundle).Error
+	if err != nil {
+		logger.Errorf("Error querying equipment bundle: %v", err)
+		return model.EquipmentBundle{}, err
+	}
+
+	return bundle, nil
+}
+
+func (e *equipmentBundleRepository) FindAll(ctx context.Context) ([]model.EquipmentBundle, error) {
+	var bundles []model.EquipmentBundle
+	err := e.db.WithContext(ctx).Preload("Items").Order("name ASC").Find(&bundles).Error
+	if err != nil {
+		logrus.Errorf("Error querying equipment bundles: %v", err)
+		return nil, err
+	}
+
+	return bundles, nil
+}
+
+func (e *equipmentBundleRepository) FindByIDs(ctx context.Context, ids []string) ([]model.EquipmentBundle, error) {
+	logger := logrus.WithField("ids", ids)
+
+	var bundles []model.EquipmentBundle
+	err := e.db.WithContext(ctx).Preload("Items").Where("id IN ?", ids).Find(&bundles).Error
+	if err != nil {
+		logger.Errorf("Error querying equipment bundles: %v", err)
+		return nil, err
+	}
+
+	return bundles, nil
+}
+
+func (e *equipmentBundleRepository) Create(ctx context.Context, bundle model.EquipmentBundle) (model.EquipmentBundle, error) {
+	logger := logrus.WithField("bundle", utils.Dump(bundle))
+
+	if err := bundle.Validate(); err != nil {
+		logger.Errorf("Invalid equipment bundle: %v", err)
+		return model.EquipmentBundle{}, err
+	}
+
+	if err := e.ensureEquipmentExists(ctx, bundle); err != nil {
+		logger.Errorf("Error checking bundle equipment: %v", err)
+		return model.EquipmentBundle{}, err
+	}
+
+	id, err := gonanoid.New()
+	if err != nil {
+		logger.Errorf("Error generating ID: %v", err)
+		return model.EquipmentBundle{}, err
+	}
+
+	bundle.ID = id
+	for i := range bundle.Items {
+		bundle.Items[i].BundleID = id
+	}
+
+	err = e.db.WithContext(ctx).Create(&bundle).Error
+	if err != nil {
+		logger.Errorf("Error creating equipment bundle: %v", err)
+		return model.EquipmentBundle{}, err
+	}
+
+	return bundle, nil
+}
+
+func (e *equipmentBundleRepository) Update(ctx context.Context, id string, bundle model.EquipmentBundle) error {
+	logger := logrus.WithFields(logrus.Fields{
+		"id":     id,
+		"bundle": utils.Dump(bundle),
+	})
+
+	if err := bundle.Validate(); err != nil {
+		logger.Errorf("Invalid equipment bundle: %v", err)
+		return err
+	}
+
+	if err := e.ensureEquipmentExists(ctx, bundle); err != nil {
+		logger.Errorf("Error checking bundle equipment: %v", err)
+		return err
+	}
+
+	tx := e.db.WithContext(ctx).Begin()
+
+	err := tx.Model(&model.EquipmentBundle{}).
+		Omit(clause.Associations).
+		Where("id = ?", id).
+		Select("name", "description", "price").
+		Updates(bundle).Error
+	if err != nil {
+		tx.Rollback()
+		logger.Errorf("Error updating equipment bundle: %v", err)
+		return err
+	}
+
+	err = tx.Where("bundle_id = ?", id).Delete(&model.EquipmentBundleItem{}).Error
+	if err != nil {
+		tx.Rollback()
+		logger.Errorf("Error deleting equipment bundle items: %v", err)
+		return err
+	}
+
+	for i := range bundle.Items {
+		bundle.Items[i].BundleID = id
+	}
+
+	err = tx.Create(&bundle.Items).Error
+	if err != nil {
+		tx.Rollback()
+		logger.Errorf("Error creating equipment bundle items: %v", err)
+		return err
+	}
+
+	tx.Commit()
+	return nil
+}
+
+func (e *equipmentBundleRepository) Delete(ctx context.Context, id string) error {
+	logger := logrus.WithField("id", id)
+
+	err := e.db.WithContext(ctx).Where("id = ?", id).Delete(&model.EquipmentBundle{}).Error
+	if err != nil {
+		logger.Errorf("Error deleting equipment bundle: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func (e *equipmentBundleRepository) ensureEquipmentExists(ctx context.Context, bundle model.EquipmentBundle) error {
+	ids := bundle.EquipmentIDs()
+
+	var found int64
+	err := e.db.WithContext(ctx).Model(&model.Equipment{}).Where("id IN ?", ids).Count(&found).Error
+	if err != nil {
+		return err
+	}
+
+	if int(found) != len(ids) {
+		return model.ErrEquipmentNotFound
+	}
+
+	return nil
+}
@@ -2,20 +2,26 @@ package repository
 
 import (
 	"context"
+	"fmt"
+	"time"
 
+	gonanoid "github.com/matoous/go-nanoid/v2"
 	"github.com/notblessy/rms/model"
 	"github.com/notblessy/rms/utils"
 	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type equipmentRepository struct {
-	db *gorm.DB
+	db      *gorm.DB
+	storage model.FileStorage
 }
 
-func NewEquipmentRepository(d *gorm.DB) model.EquipmentRepository {
+func NewEquipmentRepository(d *gorm.DB, storage model.FileStorage) model.EquipmentRepository {
 	return &equipmentRepository{
-		db: d,
+		db:      d,
+		storage: storage,
 	}
 }
 
@@ -45,7 +51,7 @@ func (e *equipmentRepository) FindAll(ctx context.Context, query model.Equipment
 	qb := e.db.WithContext(ctx).Model(&model.Equipment{})
 
 	if query.Keyword != "" {
-		qb = qb.Where("name ILIKE ?", "%"+query.Keyword+"%")
+		qb = qb.Scopes(matchKeyword("equipments", query.Keyword))
 	}
 
 	err := qb.Count(&total).Error
@@ -54,7 +60,7 @@ func (e *equipmentRepository) FindAll(ctx context.Context, query model.Equipment
 		return nil, 0, err
 	}
 
-	err = qb.Scopes(query.Paginated()).Order(query.Sorted()).Find(&equipments).Error
+	err = qb.Scopes(query.Paginated()).Order(keywordOrder("equipments", query.Keyword, query.PaginatedRequest)).Find(&equipments).Error
 	if err != nil {
 		logger.Errorf("Error querying equipments: %v", err)
 		return nil, 0, err
@@ -63,22 +69,75 @@ func (e *equipmentRepository) FindAll(ctx context.Context, query model.Equipment
 	return equipments, total, nil
 }
 
-func (e *equipmentRepository) Create(ctx context.Context, equipment model.Equipment) error {
+func (e *equipmentRepository) FindByIDs(ctx context.Context, ids []string) ([]model.Equipment, error) {
+	logger := logrus.WithField("ids", ids)
+
+	var equipments []model.Equipment
+	err := e.db.WithContext(ctx).Where("id IN ?", ids).Find(&equipments).Error
+	if err != nil {
+		logger.Errorf("Error querying equipments: %v", err)
+		return nil, err
+	}
+
+	return equipments, nil
+}
+
+// Create adds the equipment with an empty stock and records the stock it
+// comes with as an opening purchase.
+func (e *equipmentRepository) Create(ctx context.Context, equipment model.Equipment, actorID string) (model.Equipment, error) {
 	logger := logrus.WithField("equipment", utils.Dump(equipment))
 
-	err := e.db.WithContext(ctx).Create(&equipment).Error
+	if equipment.Stock < 0 {
+		return model.Equipment{}, model.ErrInsufficientStock
+	}
+
+	id, err := gonanoid.New()
+	if err != nil {
+		logger.Errorf("Error generating ID: %v", err)
+		return model.Equipment{}, err
+	}
+
+	opening := equipment.Stock
+	equipment.ID = id
+	equipment.Stock = 0
+	equipment.ImageKey = ""
+	equipment.ImageVariants = nil
+
+	tx := e.db.WithContext(ctx).Begin()
+
+	err = tx.Omit("image_key", "image_variants").Create(&equipment).Error
 	if err != nil {
+		tx.Rollback()
 		logger.Errorf("Error creating equipment: %v", err)
-		return err
+		return model.Equipment{}, err
 	}
 
-	return nil
+	if opening > 0 {
+		movement, err := e.recordMovement(tx, equipment, model.StockMovementInput{
+			Type:     model.StockMovementPurchase,
+			Quantity: opening,
+			Reason:   "opening stock",
+			ActorID:  actorID,
+		})
+		if err != nil {
+			tx.Rollback()
+			logger.Errorf("Error recording opening stock: %v", err)
+			return model.Equipment{}, err
+		}
+
+		equipment.Stock = movement.BalanceAfter
+	}
+
+	tx.Commit()
+	return equipment, nil
 }
 
+// Update changes the equipment's details. Stock is left alone; it only
+// changes through RecordMovement.
 func (e *equipmentRepository) Update(ctx context.Context, id string, equipment model.Equipment) error {
 	logger := logrus.WithField("id", id)
 
-	err := e.db.WithContext(ctx).Model(&model.Equipment{}).Where("id = ?", id).Updates(equipment).Error
+	err := e.db.WithContext(ctx).Model(&model.Equipment{}).Where("id = ?", id).Omit("stock", "image_key", "image_variants").Updates(equipment).Error
 	if err != nil {
 		logger.Errorf("Error updating equipment: %v", err)
 		return err
@@ -90,11 +149,185 @@ func (e *equipmentRepository) Update(ctx context.Context, id string, equipment m
 func (e *equipmentRepository) Delete(ctx context.Context, id string) error {
 	logger := logrus.WithField("id", id)
 
-	err := e.db.WithContext(ctx).Where("id = ?", id).Delete(&model.Equipment{}).Error
+	var keys []string
+	err := e.db.WithContext(ctx).Model(&model.Equipment{}).Where("id = ?", id).Pluck("image_key", &keys).Error
+	if err != nil {
+		logger.Errorf("Error querying equipment image: %v", err)
+		return err
+	}
+
+	err = e.db.WithContext(ctx).Where("id = ?", id).Delete(&model.Equipment{}).Error
 	if err != nil {
 		logger.Errorf("Error deleting equipment: %v", err)
 		return err
 	}
 
+	for _, key := range keys {
+		removeStoredFiles(ctx, e.storage, imageKeys(key)...)
+	}
+
 	return nil
 }
+
+// UploadImage stores the image, with its resized variants, as the
+// equipment's image and removes the image it replaces.
+func (e *equipmentRepository) UploadImage(ctx context.Context, id string, input model.ImageUpload) (model.Equipment, error) {
+	logger := logrus.WithFields(logrus.Fields{
+		"id":       id,
+		"filename": input.Filename,
+		"size":     len(input.Content),
+	})
+
+	tx := e.db.WithContext(ctx).Begin()
+
+	var equipment model.Equipment
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&equipment).Error
+	if err != nil {
+		tx.Rollback()
+		logger.Errorf("Error querying equipment: %v", err)
+		return model.Equipment{}, err
+	}
+
+	name, err := gonanoid.New()
+	if err != nil {
+		tx.Rollback()
+		logger.Errorf("Error generating ID: %v", err)
+		return model.Equipment{}, err
+	}
+
+	stored, err := storeImage(ctx, e.storage, fmt.Sprintf("equipments/%s/%s", id, name), input.Content)
+	if err != nil {
+		tx.Rollback()
+		logger.Errorf("Error storing equipment image: %v", err)
+		return model.Equipment{}, err
+	}
+
+	previous := equipment.ImageKey
+
+	equipment.ImageURL = stored.URL
+	equipment.ImageKey = stored.Key
+	equipment.ImageVariants = stored.Variants
+
+	err = tx.Model(&equipment).Select("image_url", "image_key", "image_variants").Updates(&equipment).Error
+	if err != nil {
+		tx.Rollback()
+		logger.Errorf("Error updating equipment image: %v", err)
+		removeStoredFiles(ctx, e.storage, imageKeys(stored.Key)...)
+		return model.Equipment{}, err
+	}
+
+	err = tx.Commit().Error
+	if err != nil {
+		logger.Errorf("Error committing equipment image: %v", err)
+		removeStoredFiles(ctx, e.storage, imageKeys(stored.Key)...)
+		return model.Equipment{}, err
+	}
+
+	removeStoredFiles(ctx, e.storage, imageKeys(previous)...)
+	return equipment, nil
+}
+
+// Stock works out how many of each equipment are free to rent over
+// [from, to).
+func (e *equipmentRepository) Stock(ctx context.Context, ids []string, from, to time.Time) ([]model.EquipmentStock, error) {
+	logger := logrus.WithFields(logrus.Fields{
+		"ids":  ids,
+		"from": from,
+		"to":   to,
+	})
+
+	var equipments []model.Equipment
+	err := e.db.WithContext(ctx).Where("id IN ?", ids).Order("name").Find(&equipments).Error
+	if err != nil {
+		logger.Errorf("Error querying equipments: %v", err)
+		return nil, err
+	}
+
+	reserved, err := reservedEquipment(e.db.WithContext(ctx), ids, from, to, "")
+	if err != nil {
+		logger.Errorf("Error querying equipment reservations: %v", err)
+		return nil, err
+	}
+
+	stock := make([]model.EquipmentStock, len(equipments))
+	for i, equipment := range equipments {
+		stock[i] = model.NewEquipmentStock(equipment, reserved[equipment.ID])
+	}
+
+	return stock, nil
+}
+
+func (e *equipmentRepository) FindMovements(ctx context.Context, id string) ([]model.StockMovement, error) {
+	logger := logrus.WithField("id", id)
+
+	var movements []model.StockMovement
+	err := e.db.WithContext(ctx).Where("equipment_id = ?", id).Order("created_at DESC").Find(&movements).Error
+	if err != nil {
+		logger.Errorf("Error querying stock movements: %v", err)
+		return nil, err
+	}
+
+	return movements, nil
+}
+
+func (e *equipmentRepository) RecordMovement(ctx context.Context, id string, input model.StockMovementInput) (model.StockMovement, error) {
+	logger := logrus.WithFields(logrus.Fields{
+		"id":    id,
+		"input": utils.Dump(input),
+	})
+
+	tx := e.db.WithContext(ctx).Begin()
+
+	var equipment model.Equipment
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&equipment).Error
+	if err != nil {
+		tx.Rollback()
+		logger.Errorf("Error querying equipment: %v", err)
+		return model.StockMovement{}, err
+	}
+
+	movement, err := e.recordMovement(tx, equipment, input)
+	if err != nil {
+		tx.Rollback()
+		logger.Errorf("Error recording stock movement: %v", err)
+		return model.StockMovement{}, err
+	}
+
+	tx.Commit()
+	return movement, nil
+}
+
+// recordMovement appends a movement to the ledger of a locked equipment and
+// stores the resulting balance as its stock.
+func (e *equipmentRepository) recordMovement(tx *gorm.DB, equipment model.Equipment, input model.StockMovementInput) (model.StockMovement, error) {
+	var balance int
+	err := tx.Model(&model.StockMovement{}).
+		Select("COALESCE(SUM(quantity), 0)").
+		Where("equipment_id = ?", equipment.ID).
+		Scan(&balance).Error
+	if err != nil {
+		return model.StockMovement{}, err
+	}
+
+	id, err := gonanoid.New()
+	if err != nil {
+		return model.StockMovement{}, err
+	}
+
+	movement, err := input.ToEntity(id, equipment.ID, balance)
+	if err != nil {
+		return model.StockMovement{}, err
+	}
+
+	err = tx.Create(&movement).Error
+	if err != nil {
+		return model.StockMovement{}, err
+	}
+
+	err = tx.Model(&equipment).Update("stock", movement.BalanceAfter).Error
+	if err != nil {
+		return model.StockMovement{}, err
+	}
+
+	return movement, nil
+}
@@ -0,0 +1,309 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	gonanoid "github.com/matoous/go-nanoid/v2"
+	"github.com/notblessy/rms/model"
+	"github.com/notblessy/rms/utils"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type equipmentUnitRepository struct {
+	db *gorm.DB
+}
+
+// NewEquipmentUnitRepository :nodoc:
+func NewEquipmentUnitRepository(d *gorm.DB) model.EquipmentUnitRepository {
+	return &equipmentUnitRepository{
+		db: d,
+	}
+}
+
+func (e *equipmentUnitRepository) FindByID(ctx context.Context, equipmentID, id string) (model.EquipmentUnit, error) {
+	logger := logrus.WithFields(logrus.Fields{
+		"equipment_id": equipmentID,
+		"id":           id,
+	})
+
+	var unit model.EquipmentUnit
+	err := e.db.WithContext(ctx).Where("id = ? AND equipment_id = ?", id, equipmentID).First(&unit).Error
+	if err != nil {
+		logger.Errorf("Error querying equipment unit: %v", err)
+		return model.EquipmentUnit{}, err
+	}
+
+	return unit, nil
+}
+
+func (e *equipmentUnitRepository) FindByEquipmentID(ctx context.Context, equipmentID string) ([]model.EquipmentUnit, error) {
+	logger := logrus.WithField("equipment_id", equipmentID)
+
+	var units []model.EquipmentUnit
+	err := e.db.WithContext(ctx).Where("equipment_id = ?", equipmentID).Order("asset_tag ASC").Find(&units).Error
+	if err != nil {
+		logger.Errorf("Error querying equipment units: %v", err)
+		return nil, err
+	}
+
+	return units, nil
+}
+
+func (e *equipmentUnitRepository) Create(ctx context.Context, equipmentID string, input model.EquipmentUnitInput) (model.EquipmentUnit, error) {
+	logger := logrus.WithFields(logrus.Fields{
+		"equipment_id": equipmentID,
+		"input":        utils.Dump(input),
+	})
+
+	err := input.ValidateCreate()
+	if err != nil {
+		logger.Errorf("Error validating equipment unit: %v", err)
+		return model.EquipmentUnit{}, err
+	}
+
+	err = e.db.WithContext(ctx).Where("id = ?", equipmentID).First(&model.Equipment{}).Error
+	if err != nil {
+		logger.Errorf("Error querying equipment: %v", err)
+		return model.EquipmentUnit{}, err
+	}
+
+	id, err := gonanoid.New()
+	if err != nil {
+		logger.Errorf("Error generating ID: %v", err)
+		return model.EquipmentUnit{}, err
+	}
+
+	unit := input.ToEntity(id, equipmentID)
+
+	err = e.db.WithContext(ctx).Create(&unit).Error
+	if err != nil {
+		logger.Errorf("Error creating equipment unit: %v", err)
+		return model.EquipmentUnit{}, err
+	}
+
+	return unit, nil
+}
+
+func (e *equipmentUnitRepository) Update(ctx context.Context, equipmentID, id string, input model.EquipmentUnitInput) (model.EquipmentUnit, error) {
+	logger := logrus.WithFields(logrus.Fields{
+		"equipment_id": equipmentID,
+		"id":           id,
+		"input":        utils.Dump(input),
+	})
+
+	err := input.Validate()
+	if err != nil {
+		logger.Errorf("Error validating equipment unit: %v", err)
+		return model.EquipmentUnit{}, err
+	}
+
+	tx := e.db.WithContext(ctx).Begin()
+
+	var unit model.EquipmentUnit
+	err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ? AND equipment_id = ?", id, equipmentID).First(&unit).Error
+	if err != nil {
+		tx.Rollback()
+		logger.Errorf("Error querying equipment unit: %v", err)
+		return model.EquipmentUnit{}, err
+	}
+
+	err = unit.Apply(input)
+	if err != nil {
+		tx.Rollback()
+		logger.Errorf("Error updating equipment unit: %v", err)
+		return model.EquipmentUnit{}, err
+	}
+
+	err = tx.Model(&unit).Select(
+		"serial_number",
+		"asset_tag",
+		"condition",
+		"status",
+		"notes",
+	).Updates(unit).Error
+	if err != nil {
+		tx.Rollback()
+		logger.Errorf("Error updating equipment unit: %v", err)
+		return model.EquipmentUnit{}, err
+	}
+
+	tx.Commit()
+	return unit, nil
+}
+
+func (e *equipmentUnitRepository) Delete(ctx context.Context, equipmentID, id string) error {
+	logger := logrus.WithFields(logrus.Fields{
+		"equipment_id": equipmentID,
+		"id":           id,
+	})
+
+	result := e.db.WithContext(ctx).
+		Where("id = ? AND equipment_id = ? AND status <> ?", id, equipmentID, model.EquipmentUnitStatusRented).
+		Delete(&model.EquipmentUnit{})
+	if result.Error != nil {
+		logger.Errorf("Error deleting equipment unit: %v", result.Error)
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		logger.Errorf("Equipment unit not found or rented")
+		return model.ErrEquipmentUnitNotFound
+	}
+
+	return nil
+}
+
+func (e *equipmentUnitRepository) FindByRentalID(ctx context.Context, rentalID string) ([]model.RentalEquipmentUnit, error) {
+	logger := logrus.WithField("rental_id", rentalID)
+
+	var assignments []model.RentalEquipmentUnit
+	err := e.db.WithContext(ctx).Preload("Unit").Where("rental_id = ?", rentalID).Order("assigned_at ASC").Find(&assignments).Error
+	if err != nil {
+		logger.Errorf("Error querying rental equipment units: %v", err)
+		return nil, err
+	}
+
+	return assignments, nil
+}
+
+// Assign hands the given units out on the rental's equipment, including the
+// contents of its bundles, and marks them rented until the rental is
+// completed or cancelled.
+func (e *equipmentUnitRepository) Assign(ctx context.Context, rentalID string, input model.EquipmentUnitAssignInput) ([]model.RentalEquipmentUnit, error) {
+	logger := logrus.WithFields(logrus.Fields{
+		"rental_id": rentalID,
+		"input":     utils.Dump(input),
+	})
+
+	unitIDs := input.RequestedUnitIDs()
+	if len(unitIDs) == 0 {
+		return nil, model.ErrInvalidUnitAssignment
+	}
+
+	tx := e.db.WithContext(ctx).Begin()
+
+	var rental model.Rental
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", rentalID).First(&rental).Error
+	if err != nil {
+		tx.Rollback()
+		logger.Errorf("Error querying rental: %v", err)
+		return nil, err
+	}
+
+	err = tx.Where("rental_id = ?", rentalID).Find(&rental.RentalEquipments).Error
+	if err != nil {
+		tx.Rollback()
+		logger.Errorf("Error querying rental equipments: %v", err)
+		return nil, err
+	}
+
+	err = tx.Where("rental_id = ?", rentalID).Find(&rental.RentalBundles).Error
+	if err != nil {
+		tx.Rollback()
+		logger.Errorf("Error querying rental bundles: %v", err)
+		return nil, err
+	}
+
+	var items []model.EquipmentBundleItem
+	if len(rental.RentalBundles) > 0 {
+		bundleIDs := make([]string, len(rental.RentalBundles))
+		for i, bundle := range rental.RentalBundles {
+			bundleIDs[i] = bundle.BundleID
+		}
+
+		err = tx.Where("bundle_id IN ?", bundleIDs).Find(&items).Error
+		if err != nil {
+			tx.Rollback()
+			logger.Errorf("Error querying bundle items: %v", err)
+			return nil, err
+		}
+	}
+
+	var units []model.EquipmentUnit
+	err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id IN ?", unitIDs).Find(&units).Error
+	if err != nil {
+		tx.Rollback()
+		logger.Errorf("Error querying equipment units: %v", err)
+		return nil, err
+	}
+
+	if len(units) != len(unitIDs) {
+		tx.Rollback()
+		logger.Errorf("Equipment unit not found")
+		return nil, model.ErrEquipmentUnitNotFound
+	}
+
+	var assigned []model.RentalEquipmentUnit
+	err = tx.Where("rental_id = ?", rentalID).Find(&assigned).Error
+	if err != nil {
+		tx.Rollback()
+		logger.Errorf("Error querying rental equipment units: %v", err)
+		return nil, err
+	}
+
+	err = rental.ValidateUnitAssignment(units, assigned, items)
+	if err != nil {
+		tx.Rollback()
+		logger.Errorf("Error validating unit assignment: %v", err)
+		return nil, err
+	}
+
+	now := time.Now()
+
+	assignments := make([]model.RentalEquipmentUnit, len(units))
+	for i, unit := range units {
+		assignments[i] = model.RentalEquipmentUnit{
+			RentalID:    rentalID,
+			EquipmentID: unit.EquipmentID,
+			UnitID:      unit.ID,
+			AssignedBy:  input.ActorID,
+			AssignedAt:  now,
+		}
+	}
+
+	// A unit already returned on this rental goes out again on its
+	// existing row.
+	err = tx.Omit(clause.Associations).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "rental_id"}, {Name: "unit_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"assigned_by", "assigned_at", "returned_at"}),
+	}).Create(&assignments).Error
+	if err != nil {
+		tx.Rollback()
+		logger.Errorf("Error creating rental equipment units: %v", err)
+		return nil, err
+	}
+
+	err = tx.Model(&model.EquipmentUnit{}).
+		Where("id IN ?", unitIDs).
+		Update("status", model.EquipmentUnitStatusRented).Error
+	if err != nil {
+		tx.Rollback()
+		logger.Errorf("Error marking equipment units rented: %v", err)
+		return nil, err
+	}
+
+	tx.Commit()
+	return assignments, nil
+}
+
+// releaseEquipmentUnits returns the units still out on a rental and makes
+// them available again.
+func releaseEquipmentUnits(tx *gorm.DB, rentalID string, now time.Time) error {
+	open := tx.Model(&model.RentalEquipmentUnit{}).
+		Select("unit_id").
+		Where("rental_id = ? AND returned_at IS NULL", rentalID)
+
+	err := tx.Model(&model.EquipmentUnit{}).
+		Where("id IN (?) AND status = ?", open, model.EquipmentUnitStatusRented).
+		Update("status", model.EquipmentUnitStatusAvailable).Error
+	if err != nil {
+		return err
+	}
+
+	return tx.Model(&model.RentalEquipmentUnit{}).
+		Where("rental_id = ? AND returned_at IS NULL", rentalID).
+		Update("returned_at", now).Error
+}
@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"strconv"
+
+	"github.com/notblessy/rms/imaging"
+	"github.com/notblessy/rms/model"
+	"github.com/sirupsen/logrus"
+)
+
+// storedImage is an upload kept in file storage: its normalised original
+// under Key and the resized variants beside it.
+type storedImage struct {
+	Key         string
+	URL         string
+	ContentType string
+	Size        int
+	Variants    model.ImageVariants
+}
+
+// storeImage normalises an uploaded image and puts the original and each of
+// its variants in storage. key is given without an extension, as that
+// depends on the format the image is normalised to. When a file fails to
+// store, the ones already stored are removed again.
+func storeImage(ctx context.Context, storage model.FileStorage, key string, content []byte) (storedImage, error) {
+	if len(content) > model.MaxImageSize {
+		return storedImage{}, model.ErrImageTooLarge
+	}
+
+	result, err := imaging.Process(content)
+	if err != nil {
+		return storedImage{}, err
+	}
+
+	stored := storedImage{
+		Key:         key + result.Original.Ext,
+		ContentType: result.Original.ContentType,
+		Size:        len(result.Original.Data),
+		Variants:    make(model.ImageVariants, len(result.Variants)),
+	}
+	stored.URL = storage.URL(stored.Key)
+
+	err = storage.Put(ctx, stored.Key, result.Original.ContentType, bytes.NewReader(result.Original.Data))
+	if err != nil {
+		return storedImage{}, err
+	}
+
+	for width, variant := range result.Variants {
+		variantKey := imaging.VariantKey(stored.Key, width)
+
+		err = storage.Put(ctx, variantKey, variant.ContentType, bytes.NewReader(variant.Data))
+		if err != nil {
+			removeStoredFiles(ctx, storage, imageKeys(stored.Key)...)
+			return storedImage{}, err
+		}
+
+		stored.Variants[strconv.Itoa(width)] = storage.URL(variantKey)
+	}
+
+	return stored, nil
+}
+
+// imageKeys lists the storage keys of an image stored by storeImage: its
+// original followed by its variants.
+func imageKeys(key string) []string {
+	if key == "" {
+		return nil
+	}
+
+	keys := []string{key}
+	for _, width := range imaging.Widths {
+		keys = append(keys, imaging.VariantKey(key, width))
+	}
+
+	return keys
+}
+
+// removeStoredFiles deletes stored files whose rows are already gone. A file
+// left behind only wastes space, so failures are logged rather than returned.
+func removeStoredFiles(ctx context.Context, storage model.FileStorage, keys ...string) {
+	for _, key := range keys {
+		err := storage.Delete(ctx, key)
+		if err != nil {
+			logrus.WithField("key", key).Errorf("Error deleting stored file: %v", err)
+		}
+	}
+}
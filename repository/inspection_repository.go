@@ -0,0 +1,172 @@
+package repository
+
+import (
+	"context"
+
+	gonanoid "github.com/matoous/go-nanoid/v2"
+	"github.com/notblessy/rms/model"
+	"github.com/notblessy/rms/utils"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type inspectionRepository struct {
+	db    *gorm.DB
+	rates model.InspectionRates
+}
+
+// NewInspectionRepository :nodoc:
+func NewInspectionRepository(d *gorm.DB, rates model.InspectionRates) model.InspectionRepository {
+	return &inspectionRepository{
+		db:    d,
+		rates: rates,
+	}
+}
+
+func (i *inspectionRepository) FindByRentalID(ctx context.Context, rentalID string) (model.InspectionReport, error) {
+	logger := logrus.WithField("rental_id", rentalID)
+
+	var rental model.Rental
+	err := i.db.WithContext(ctx).Select("id").Where("id = ?", rentalID).First(&rental).Error
+	if err != nil {
+		logger.Errorf("Error querying rental: %v", err)
+		return model.InspectionReport{}, err
+	}
+
+	report, err := i.load(i.db.WithContext(ctx), rentalID)
+	if err != nil {
+		logger.Errorf("Error querying inspections: %v", err)
+		return model.InspectionReport{}, err
+	}
+
+	return report, nil
+}
+
+// Create records an inspection, adds its odometer reading to the camper's
+// mileage history and copies the reported condition onto the camper.
+// Checking a camper in also bills the extra charges found by comparing it
+// with the check-out.
+func (i *inspectionRepository) Create(ctx context.Context, rentalID string, input model.RentalInspectionInput) (model.RentalInspection, error) {
+	logger := logrus.WithFields(logrus.Fields{
+		"rental_id": rentalID,
+		"input":     utils.Dump(input),
+	})
+
+	tx := i.db.WithContext(ctx).Begin()
+
+	var rental model.Rental
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", rentalID).First(&rental).Error
+	if err != nil {
+		tx.Rollback()
+		logger.Errorf("Error querying rental: %v", err)
+		return model.RentalInspection{}, err
+	}
+
+	report, err := i.load(tx, rentalID)
+	if err != nil {
+		tx.Rollback()
+		logger.Errorf("Error querying inspections: %v", err)
+		return model.RentalInspection{}, err
+	}
+
+	err = input.Validate(rental, report)
+	if err != nil {
+		tx.Rollback()
+		logger.Errorf("Error validating inspection: %v", err)
+		return model.RentalInspection{}, err
+	}
+
+	id, err := gonanoid.New()
+	if err != nil {
+		tx.Rollback()
+		logger.Errorf("Error generating ID: %v", err)
+		return model.RentalInspection{}, err
+	}
+
+	inspection := input.ToEntity(id, rental)
+
+	err = tx.Create(&inspection).Error
+	if err != nil {
+		tx.Rollback()
+		logger.Errorf("Error creating inspection: %v", err)
+		return model.RentalInspection{}, err
+	}
+
+	source := model.OdometerSourceCheckOut
+	if inspection.Type == model.InspectionTypeCheckIn {
+		source = model.OdometerSourceCheckIn
+	}
+
+	err = recordOdometer(tx, &model.OdometerReading{
+		CamperID:   rental.CamperID,
+		RentalID:   &rental.ID,
+		Source:     source,
+		Reading:    inspection.Odometer,
+		RecordedBy: input.ActorID,
+	})
+	if err != nil {
+		tx.Rollback()
+		logger.Errorf("Error recording odometer reading: %v", err)
+		return model.RentalInspection{}, err
+	}
+
+	if inspection.Condition != "" {
+		err = tx.Model(&model.Camper{}).Where("id = ?", rental.CamperID).Update("condition", inspection.Condition).Error
+		if err != nil {
+			tx.Rollback()
+			logger.Errorf("Error updating camper condition: %v", err)
+			return model.RentalInspection{}, err
+		}
+	}
+
+	if inspection.Type == model.InspectionTypeCheckIn {
+		comparison := model.CompareInspections(*report.CheckOut, inspection, i.rates)
+
+		err = i.charge(tx, rental, comparison)
+		if err != nil {
+			tx.Rollback()
+			logger.Errorf("Error charging inspection extras: %v", err)
+			return model.RentalInspection{}, err
+		}
+	}
+
+	tx.Commit()
+	return inspection, nil
+}
+
+// charge adds the comparison's extra charges to the rental's line items and
+// grand total.
+func (i *inspectionRepository) charge(tx *gorm.DB, rental model.Rental, comparison model.InspectionComparison) error {
+	if len(comparison.ExtraCharges) == 0 {
+		return nil
+	}
+
+	items := comparison.ExtraCharges
+	for n := range items {
+		id, err := gonanoid.New()
+		if err != nil {
+			return err
+		}
+
+		items[n].ID = id
+		items[n].RentalID = rental.ID
+	}
+
+	err := tx.Create(&items).Error
+	if err != nil {
+		return err
+	}
+
+	return tx.Model(&rental).Omit(clause.Associations).Update("grand_total", rental.GrandTotal.Add(comparison.ExtraTotal)).Error
+}
+
+func (i *inspectionRepository) load(db *gorm.DB, rentalID string) (model.InspectionReport, error) {
+	var inspections []model.RentalInspection
+	err := db.Where("rental_id = ?", rentalID).Order("created_at ASC").Find(&inspections).Error
+	if err != nil {
+		return model.InspectionReport{}, err
+	}
+
+	return model.NewInspectionReport(inspections, i.rates), nil
+}
@@ -0,0 +1,284 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	gonanoid "github.com/matoous/go-nanoid/v2"
+	"github.com/notblessy/rms/model"
+	"github.com/notblessy/rms/utils"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type invoiceRepository struct {
+	db      *gorm.DB
+	company model.CompanyDetails
+}
+
+// NewInvoiceRepository :nodoc:
+func NewInvoiceRepository(d *gorm.DB, company model.CompanyDetails) model.InvoiceRepository {
+	return &invoiceRepository{
+		db:      d,
+		company: company,
+	}
+}
+
+func (i *invoiceRepository) FindByRentalID(ctx context.Context, rentalID string) ([]model.Invoice, error) {
+	logger := logrus.WithField("rental_id", rentalID)
+
+	var invoices []model.Invoice
+	err := i.db.WithContext(ctx).Where("rental_id = ?", rentalID).Order("issued_at ASC").Find(&invoices).Error
+	if err != nil {
+		logger.Errorf("Error querying invoices: %v", err)
+		return nil, err
+	}
+
+	return invoices, nil
+}
+
+func (i *invoiceRepository) FindByID(ctx context.Context, id string) (model.Invoice, error) {
+	logger := logrus.WithField("id", id)
+
+	var invoice model.Invoice
+	err := i.db.WithContext(ctx).Where("id = ?", id).First(&invoice).Error
+	if err != nil {
+		logger.Errorf("Error querying invoice: %v", err)
+		return model.Invoice{}, err
+	}
+
+	return invoice, nil
+}
+
+// IssueInvoice returns the rental's invoice, issuing it with the next number
+// on first request.
+func (i *invoiceRepository) IssueInvoice(ctx context.Context, rentalID string) (model.Invoice, error) {
+	logger := logrus.WithField("rental_id", rentalID)
+
+	tx := i.db.WithContext(ctx).Begin()
+
+	invoice, err := i.issueInvoice(tx, rentalID)
+	if err != nil {
+		tx.Rollback()
+		logger.Errorf("Error issuing invoice: %v", err)
+		return model.Invoice{}, err
+	}
+
+	tx.Commit()
+	return invoice, nil
+}
+
+// IssueCreditNote credits a settled refund against the rental's invoice. A
+// refund is only ever credited once.
+func (i *invoiceRepository) IssueCreditNote(ctx context.Context, refund model.Payment) (model.Invoice, error) {
+	logger := logrus.WithField("refund", utils.Dump(refund))
+
+	if refund.Type != model.PaymentTypeRefund || refund.Status != model.PaymentStatusPaid {
+		logger.Errorf("Payment is not a settled refund")
+		return model.Invoice{}, model.ErrNotCreditable
+	}
+
+	tx := i.db.WithContext(ctx).Begin()
+
+	invoice, err := i.issueInvoice(tx, refund.RentalID)
+	if err != nil {
+		tx.Rollback()
+		logger.Errorf("Error issuing invoice: %v", err)
+		return model.Invoice{}, err
+	}
+
+	var creditNote model.Invoice
+	err = tx.Where("payment_id = ?", refund.ID).First(&creditNote).Error
+	if err == nil {
+		tx.Rollback()
+		return creditNote, nil
+	}
+
+	if err != gorm.ErrRecordNotFound {
+		tx.Rollback()
+		logger.Errorf("Error querying credit note: %v", err)
+		return model.Invoice{}, err
+	}
+
+	document := model.NewCreditNoteDocument(invoice, refund)
+
+	creditNote, err = i.create(tx, model.Invoice{
+		Type:            model.InvoiceTypeCreditNote,
+		RentalID:        refund.RentalID,
+		PaymentID:       &refund.ID,
+		ReferenceNumber: invoice.Number,
+		Total:           document.Total,
+		Document:        document,
+	})
+	if err != nil {
+		tx.Rollback()
+		logger.Errorf("Error creating credit note: %v", err)
+		return model.Invoice{}, err
+	}
+
+	tx.Commit()
+	return creditNote, nil
+}
+
+// IssueDebitNote bills the charges added to an invoiced rental since its
+// invoice and earlier debit notes were issued. A rental that has not been
+// invoiced yet has nothing to debit: its invoice will list every charge.
+func (i *invoiceRepository) IssueDebitNote(ctx context.Context, rentalID string) (model.Invoice, error) {
+	logger := logrus.WithField("rental_id", rentalID)
+
+	tx := i.db.WithContext(ctx).Begin()
+
+	var rental model.Rental
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Preload("LineItems").
+		Where("id = ?", rentalID).
+		First(&rental).Error
+	if err != nil {
+		tx.Rollback()
+		logger.Errorf("Error querying rental: %v", err)
+		return model.Invoice{}, err
+	}
+
+	var invoices []model.Invoice
+	err = tx.Where("rental_id = ? AND type IN ?", rentalID, []string{model.InvoiceTypeInvoice, model.InvoiceTypeDebitNote}).
+		Order("issued_at ASC").
+		Find(&invoices).Error
+	if err != nil {
+		tx.Rollback()
+		logger.Errorf("Error querying invoices: %v", err)
+		return model.Invoice{}, err
+	}
+
+	var invoice model.Invoice
+	for _, issued := range invoices {
+		if issued.Type == model.InvoiceTypeInvoice {
+			invoice = issued
+		}
+	}
+
+	if invoice.ID == "" {
+		tx.Rollback()
+		return model.Invoice{}, model.ErrNothingToDebit
+	}
+
+	charges := rental.UninvoicedCharges(invoices)
+	if len(charges) == 0 {
+		tx.Rollback()
+		return model.Invoice{}, model.ErrNothingToDebit
+	}
+
+	document := model.NewDebitNoteDocument(invoice, rental, charges)
+
+	debitNote, err := i.create(tx, model.Invoice{
+		Type:            model.InvoiceTypeDebitNote,
+		RentalID:        rentalID,
+		ReferenceNumber: invoice.Number,
+		Total:           document.Total,
+		Document:        document,
+	})
+	if err != nil {
+		tx.Rollback()
+		logger.Errorf("Error creating debit note: %v", err)
+		return model.Invoice{}, err
+	}
+
+	tx.Commit()
+	return debitNote, nil
+}
+
+func (i *invoiceRepository) issueInvoice(tx *gorm.DB, rentalID string) (model.Invoice, error) {
+	var rental model.Rental
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Preload("LineItems").
+		Where("id = ?", rentalID).
+		First(&rental).Error
+	if err != nil {
+		return model.Invoice{}, err
+	}
+
+	var invoice model.Invoice
+	err = tx.Where("rental_id = ? AND type = ?", rentalID, model.InvoiceTypeInvoice).First(&invoice).Error
+	if err == nil {
+		return invoice, nil
+	}
+
+	if err != gorm.ErrRecordNotFound {
+		return model.Invoice{}, err
+	}
+
+	if !rental.IsInvoiceable() {
+		return model.Invoice{}, model.ErrRentalNotInvoiceable
+	}
+
+	var customer model.User
+	err = tx.Where("id = ?", rental.CustomerID).First(&customer).Error
+	if err != nil {
+		return model.Invoice{}, err
+	}
+
+	var camper model.Camper
+	err = tx.Where("id = ?", rental.CamperID).First(&camper).Error
+	if err != nil {
+		return model.Invoice{}, err
+	}
+
+	var driver *model.Driver
+	if rental.DriverID != "" {
+		driver = &model.Driver{}
+		err = tx.Where("id = ?", rental.DriverID).First(driver).Error
+		if err != nil {
+			return model.Invoice{}, err
+		}
+	}
+
+	var payments []model.Payment
+	err = tx.Where("rental_id = ?", rentalID).Order("created_at ASC").Find(&payments).Error
+	if err != nil {
+		return model.Invoice{}, err
+	}
+
+	document := model.NewInvoiceDocument(i.company, customer, camper, driver, rental, payments)
+
+	return i.create(tx, model.Invoice{
+		Type:     model.InvoiceTypeInvoice,
+		RentalID: rentalID,
+		Total:    document.Total,
+		Document: document,
+	})
+}
+
+// create numbers and stores the invoice. Numbers come from a per-type,
+// per-year counter row that stays locked until tx ends, so they are
+// sequential without gaps.
+func (i *invoiceRepository) create(tx *gorm.DB, invoice model.Invoice) (model.Invoice, error) {
+	id, err := gonanoid.New()
+	if err != nil {
+		return model.Invoice{}, err
+	}
+
+	issuedAt := time.Now()
+	counter := invoice.Type + ":" + issuedAt.Format("2006")
+
+	var sequence int
+	err = tx.Raw(
+		`INSERT INTO invoice_counters (name, last_value) VALUES (?, 1)
+		ON CONFLICT (name) DO UPDATE SET last_value = invoice_counters.last_value + 1
+		RETURNING last_value`,
+		counter,
+	).Scan(&sequence).Error
+	if err != nil {
+		return model.Invoice{}, err
+	}
+
+	invoice.ID = id
+	invoice.Number = model.InvoiceNumber(invoice.Type, issuedAt.Year(), sequence)
+	invoice.IssuedAt = issuedAt
+
+	err = tx.Create(&invoice).Error
+	if err != nil {
+		return model.Invoice{}, err
+	}
+
+	return invoice, nil
+}
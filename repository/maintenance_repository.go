@@ -0,0 +1,247 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	gonanoid "github.com/matoous/go-nanoid/v2"
+	"github.com/notblessy/rms/model"
+	"github.com/notblessy/rms/utils"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type maintenanceRepository struct {
+	db *gorm.DB
+}
+
+// NewMaintenanceRepository :nodoc:
+func NewMaintenanceRepository(d *gorm.DB) model.MaintenanceRepository {
+	return &maintenanceRepository{
+		db: d,
+	}
+}
+
+func (m *maintenanceRepository) FindByID(ctx context.Context, id string) (model.ServiceRecord, error) {
+	logger := logrus.WithField("id", id)
+
+	var record model.ServiceRecord
+	err := m.db.WithContext(ctx).Preload("MaintenanceWindow").Where("id = ?", id).First(&record).Error
+	if err != nil {
+		logger.Errorf("Error querying service record: %v", err)
+		return model.ServiceRecord{}, err
+	}
+
+	return record, nil
+}
+
+func (m *maintenanceRepository) FindByCamperID(ctx context.Context, camperID string) ([]model.ServiceRecord, error) {
+	logger := logrus.WithField("camper_id", camperID)
+
+	var records []model.ServiceRecord
+	err := m.db.WithContext(ctx).
+		Preload("MaintenanceWindow").
+		Where("camper_id = ?", camperID).
+		Order("created_at DESC").
+		Find(&records).Error
+	if err != nil {
+		logger.Errorf("Error querying service records: %v", err)
+		return nil, err
+	}
+
+	return records, nil
+}
+
+// Schedule plans a service for the camper, booking it into the workshop with
+// a maintenance window when the input has dates.
+func (m *maintenanceRepository) Schedule(ctx context.Context, camperID string, input model.ServiceRecordInput) (model.ServiceRecord, error) {
+	logger := logrus.WithFields(logrus.Fields{
+		"camper_id": camperID,
+		"input":     utils.Dump(input),
+	})
+
+	err := input.Validate()
+	if err != nil {
+		logger.Errorf("Invalid service record: %v", err)
+		return model.ServiceRecord{}, err
+	}
+
+	err = m.db.WithContext(ctx).Select("id").Where("id = ?", camperID).First(&model.Camper{}).Error
+	if err != nil {
+		logger.Errorf("Error querying camper: %v", err)
+		return model.ServiceRecord{}, err
+	}
+
+	id, err := gonanoid.New()
+	if err != nil {
+		logger.Errorf("Error generating ID: %v", err)
+		return model.ServiceRecord{}, err
+	}
+
+	record := input.ToEntity(id, camperID)
+
+	tx := m.db.WithContext(ctx).Begin()
+
+	if window, ok := input.Window(camperID); ok {
+		window.ID, err = gonanoid.New()
+		if err != nil {
+			tx.Rollback()
+			logger.Errorf("Error generating ID: %v", err)
+			return model.ServiceRecord{}, err
+		}
+
+		err = tx.Create(&window).Error
+		if err != nil {
+			tx.Rollback()
+			logger.Errorf("Error creating maintenance window: %v", err)
+			return model.ServiceRecord{}, err
+		}
+
+		record.MaintenanceWindowID = &window.ID
+		record.MaintenanceWindow = &window
+	}
+
+	err = tx.Omit(clause.Associations).Create(&record).Error
+	if err != nil {
+		tx.Rollback()
+		logger.Errorf("Error creating service record: %v", err)
+		return model.ServiceRecord{}, err
+	}
+
+	tx.Commit()
+	return record, nil
+}
+
+// Complete logs a finished service, records it as the camper's last
+// maintenance with the condition it was left in and frees the rest of its
+// workshop booking.
+func (m *maintenanceRepository) Complete(ctx context.Context, id string, input model.ServiceCompleteInput) (model.ServiceRecord, error) {
+	logger := logrus.WithFields(logrus.Fields{
+		"id":    id,
+		"input": utils.Dump(input),
+	})
+
+	tx := m.db.WithContext(ctx).Begin()
+
+	var record model.ServiceRecord
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&record).Error
+	if err != nil {
+		tx.Rollback()
+		logger.Errorf("Error querying service record: %v", err)
+		return model.ServiceRecord{}, err
+	}
+
+	now := time.Now()
+
+	condition, err := record.Complete(input, now)
+	if err != nil {
+		tx.Rollback()
+		logger.Errorf("Error completing service record: %v", err)
+		return model.ServiceRecord{}, err
+	}
+
+	if input.Odometer != nil {
+		err = recordOdometer(tx, &model.OdometerReading{
+			CamperID:        record.CamperID,
+			ServiceRecordID: &record.ID,
+			Source:          model.OdometerSourceService,
+			Reading:         *input.Odometer,
+			RecordedBy:      input.ActorID,
+		})
+		if err != nil {
+			tx.Rollback()
+			logger.Errorf("Error recording odometer reading: %v", err)
+			return model.ServiceRecord{}, err
+		}
+	}
+
+	if record.MaintenanceWindowID != nil {
+		err = m.releaseWindow(tx, &record, now)
+		if err != nil {
+			tx.Rollback()
+			logger.Errorf("Error releasing maintenance window: %v", err)
+			return model.ServiceRecord{}, err
+		}
+	}
+
+	err = tx.Model(&record).Omit(clause.Associations).Select(
+		"status",
+		"cost",
+		"completed_at",
+		"completion_notes",
+		"maintenance_window_id",
+	).Updates(record).Error
+	if err != nil {
+		tx.Rollback()
+		logger.Errorf("Error updating service record: %v", err)
+		return model.ServiceRecord{}, err
+	}
+
+	err = tx.Model(&model.Camper{}).Where("id = ?", record.CamperID).Updates(map[string]interface{}{
+		"last_maintenance": now,
+		"condition":        condition,
+	}).Error
+	if err != nil {
+		tx.Rollback()
+		logger.Errorf("Error updating camper maintenance: %v", err)
+		return model.ServiceRecord{}, err
+	}
+
+	tx.Commit()
+	return record, nil
+}
+
+// releaseWindow ends the service's workshop booking at now so the camper can
+// be rented again, or drops it when the service finished before it began.
+func (m *maintenanceRepository) releaseWindow(tx *gorm.DB, record *model.ServiceRecord, now time.Time) error {
+	var window model.MaintenanceWindow
+	err := tx.Where("id = ?", *record.MaintenanceWindowID).First(&window).Error
+	if err != nil {
+		return err
+	}
+
+	if !window.EndDate.After(now) {
+		return nil
+	}
+
+	if window.StartDate.After(now) {
+		record.MaintenanceWindowID = nil
+		return tx.Delete(&window).Error
+	}
+
+	return tx.Model(&window).Update("end_date", now).Error
+}
+
+// Due lists the scheduled services due within the query's number of days or
+// kilometres of the camper's odometer, including those already overdue,
+// soonest first.
+func (m *maintenanceRepository) Due(ctx context.Context, query model.MaintenanceDueQueryInput) ([]model.ServiceRecord, error) {
+	logger := logrus.WithField("query", utils.Dump(query))
+
+	days := query.WithinDays
+	if days <= 0 {
+		days = model.DefaultMaintenanceDueDays
+	}
+
+	km := query.WithinKm
+	if km <= 0 {
+		km = model.DefaultMaintenanceDueKm
+	}
+
+	var records []model.ServiceRecord
+	err := m.db.WithContext(ctx).
+		Preload("MaintenanceWindow").
+		Joins("JOIN campers ON campers.id = service_records.camper_id").
+		Where("service_records.status = ?", model.ServiceStatusScheduled).
+		Where("service_records.due_date <= ? OR service_records.due_odometer <= campers.odometer + ?",
+			time.Now().AddDate(0, 0, days), km).
+		Order("service_records.due_date ASC NULLS LAST").
+		Find(&records).Error
+	if err != nil {
+		logger.Errorf("Error querying due services: %v", err)
+		return nil, err
+	}
+
+	return records, nil
+}
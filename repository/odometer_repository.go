@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+
+	gonanoid "github.com/matoous/go-nanoid/v2"
+	"github.com/notblessy/rms/model"
+	"github.com/notblessy/rms/utils"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type odometerRepository struct {
+	db *gorm.DB
+}
+
+// NewOdometerRepository :nodoc:
+func NewOdometerRepository(d *gorm.DB) model.OdometerRepository {
+	return &odometerRepository{
+		db: d,
+	}
+}
+
+func (o *odometerRepository) FindByCamperID(ctx context.Context, camperID string) ([]model.OdometerReading, error) {
+	logger := logrus.WithField("camper_id", camperID)
+
+	var readings []model.OdometerReading
+	err := o.db.WithContext(ctx).Where("camper_id = ?", camperID).Order("created_at DESC").Find(&readings).Error
+	if err != nil {
+		logger.Errorf("Error querying odometer readings: %v", err)
+		return nil, err
+	}
+
+	return readings, nil
+}
+
+func (o *odometerRepository) Record(ctx context.Context, camperID string, input model.OdometerReadingInput) (model.OdometerReading, error) {
+	logger := logrus.WithFields(logrus.Fields{
+		"camper_id": camperID,
+		"input":     utils.Dump(input),
+	})
+
+	reading := model.OdometerReading{
+		CamperID:   camperID,
+		Source:     model.OdometerSourceManual,
+		Reading:    input.Reading,
+		RecordedBy: input.ActorID,
+	}
+
+	tx := o.db.WithContext(ctx).Begin()
+
+	err := recordOdometer(tx, &reading)
+	if err != nil {
+		tx.Rollback()
+		logger.Errorf("Error recording odometer reading: %v", err)
+		return model.OdometerReading{}, err
+	}
+
+	tx.Commit()
+	return reading, nil
+}
+
+// recordOdometer adds a reading to the camper's mileage history and makes it
+// the camper's current odometer. The camper row stays locked for the rest of
+// tx so readings are appended in order.
+func recordOdometer(tx *gorm.DB, reading *model.OdometerReading) error {
+	var camper model.Camper
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Select("id", "odometer").
+		Where("id = ?", reading.CamperID).
+		First(&camper).Error
+	if err != nil {
+		return err
+	}
+
+	if reading.Reading < camper.Odometer {
+		return model.ErrInvalidOdometerReading
+	}
+
+	reading.ID, err = gonanoid.New()
+	if err != nil {
+		return err
+	}
+
+	err = tx.Create(reading).Error
+	if err != nil {
+		return err
+	}
+
+	return tx.Model(&camper).Update("odometer", reading.Reading).Error
+}
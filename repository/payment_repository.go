@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"context"
+
+	gonanoid "github.com/matoous/go-nanoid/v2"
+	"github.com/notblessy/rms/model"
+	"github.com/notblessy/rms/utils"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type paymentRepository struct {
+	db *gorm.DB
+}
+
+// NewPaymentRepository :nodoc:
+func NewPaymentRepository(d *gorm.DB) model.PaymentRepository {
+	return &paymentRepository{
+		db: d,
+	}
+}
+
+func (p *paymentRepository) FindByRentalID(ctx context.Context, rentalID string) ([]model.Payment, error) {
+	logger := logrus.WithField("rental_id", rentalID)
+
+	var payments []model.Payment
+	err := p.db.WithContext(ctx).Where("rental_id = ?", rentalID).Order("created_at ASC").Find(&payments).Error
+	if err != nil {
+		logger.Errorf("Error querying payments: %v", err)
+		return nil, err
+	}
+
+	return payments, nil
+}
+
+func (p *paymentRepository) Create(ctx context.Context, payment model.Payment) (model.Payment, error) {
+	logger := logrus.WithField("payment", utils.Dump(payment))
+
+	id, err := gonanoid.New()
+	if err != nil {
+		logger.Errorf("Error generating ID: %v", err)
+		return model.Payment{}, err
+	}
+
+	payment.ID = id
+	payment.Status = model.PaymentStatusPending
+
+	err = p.db.WithContext(ctx).Create(&payment).Error
+	if err != nil {
+		logger.Errorf("Error creating payment: %v", err)
+		return model.Payment{}, err
+	}
+
+	return payment, nil
+}
+
+func (p *paymentRepository) SetProviderRef(ctx context.Context, id, providerRef string) error {
+	logger := logrus.WithFields(logrus.Fields{
+		"id":           id,
+		"provider_ref": providerRef,
+	})
+
+	err := p.db.WithContext(ctx).Model(&model.Payment{}).Where("id = ?", id).Update("provider_ref", providerRef).Error
+	if err != nil {
+		logger.Errorf("Error updating payment provider ref: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// ApplyEvent records the outcome reported by the provider. Providers may
+// deliver the same event more than once, so events for a payment that is no
+// longer pending are ignored. An event can only settle a payment as paid or
+// failed.
+func (p *paymentRepository) ApplyEvent(ctx context.Context, event model.PaymentEvent) (model.Payment, error) {
+	logger := logrus.WithField("event", utils.Dump(event))
+
+	if event.Status != model.PaymentStatusPaid && event.Status != model.PaymentStatusFailed {
+		logger.Errorf("Invalid payment event status")
+		return model.Payment{}, model.ErrInvalidPaymentEvent
+	}
+
+	tx := p.db.WithContext(ctx).Begin()
+
+	var payment model.Payment
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", event.PaymentID).First(&payment).Error
+	if err != nil {
+		tx.Rollback()
+		logger.Errorf("Error querying payment: %v", err)
+		return model.Payment{}, err
+	}
+
+	if payment.Status != model.PaymentStatusPending {
+		tx.Rollback()
+		return payment, nil
+	}
+
+	payment.Status = event.Status
+	if event.ProviderRef != "" {
+		payment.ProviderRef = event.ProviderRef
+	}
+
+	if event.Status == model.PaymentStatusPaid {
+		payment.PaidAt = model.NewNullTime(event.OccurredAt)
+	}
+
+	err = tx.Model(&payment).Select("status", "provider_ref", "paid_at").Updates(payment).Error
+	if err != nil {
+		tx.Rollback()
+		logger.Errorf("Error updating payment: %v", err)
+		return model.Payment{}, err
+	}
+
+	tx.Commit()
+	return payment, nil
+}
@@ -2,22 +2,30 @@ package repository
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	gonanoid "github.com/matoous/go-nanoid/v2"
 	"github.com/notblessy/rms/model"
 	"github.com/notblessy/rms/utils"
+	"github.com/shopspring/decimal"
 	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type rentalRepository struct {
-	db *gorm.DB
+	db      *gorm.DB
+	holdTTL time.Duration
+	mileage model.MileagePolicy
 }
 
 // NewRentalRepository :nodoc:
-func NewRentalRepository(d *gorm.DB) model.RentalRepository {
+func NewRentalRepository(d *gorm.DB, holdTTL time.Duration, mileage model.MileagePolicy) model.RentalRepository {
 	return &rentalRepository{
-		db: d,
+		db:      d,
+		holdTTL: holdTTL,
+		mileage: mileage,
 	}
 }
 
@@ -33,7 +41,15 @@ func (r *rentalRepository) FindByID(ctx context.Context, id string) (model.Renta
 	logger := logrus.WithField("id", id)
 
 	var rental model.Rental
-	err := r.db.WithContext(ctx).Where("id = ?", id).First(&rental).Error
+	err := r.db.WithContext(ctx).
+		Preload("LineItems").
+		Preload("RentalEquipments").
+		Preload("RentalBundles").
+		Preload("DateChanges", func(db *gorm.DB) *gorm.DB {
+			return db.Order("created_at ASC")
+		}).
+		Where("id = ?", id).
+		First(&rental).Error
 	if err != nil {
 		logger.Errorf("Error querying rental: %v", err)
 		return model.Rental{}, err
@@ -58,6 +74,14 @@ func (r *rentalRepository) FindAll(ctx context.Context, query model.RentalQueryI
 		qb = qb.Where("name ILIKE ?", "%"+query.Keyword+"%")
 	}
 
+	if query.Overdue {
+		qb = overdueRentals(qb, time.Now())
+	}
+
+	if query.CustomerID != "" {
+		qb = qb.Where("customer_id = ?", query.CustomerID)
+	}
+
 	err := qb.Count(&total).Error
 	if err != nil {
 		logger.Errorf("Error counting rentals: %v", err)
@@ -76,11 +100,8 @@ func (r *rentalRepository) FindAll(ctx context.Context, query model.RentalQueryI
 func (r *rentalRepository) Create(ctx context.Context, rental model.RentalInput) error {
 	logger := logrus.WithField("rental", utils.Dump(rental))
 
-	var activeRentals []model.Rental
-
-	err := r.db.WithContext(ctx).Where("status <> ? AND camper_id = ?", model.RentalStatusCancelled, rental.CamperID).First(&activeRentals).Error
-	if err != nil && err != gorm.ErrRecordNotFound {
-		logger.Errorf("Error querying active rentals: %v", err)
+	if err := rental.ValidatePeriod(); err != nil {
+		logger.Errorf("Invalid rental period: %v", err)
 		return err
 	}
 
@@ -90,10 +111,24 @@ func (r *rentalRepository) Create(ctx context.Context, rental model.RentalInput)
 		return err
 	}
 
+	rental.ID = id
 	rentalPayload := rental.ToEntity(id)
+	rentalPayload.Status = model.RentalStatusPending
+	rentalPayload.SecurityDepositStatus = model.SecurityDepositNone
+	if rental.Hold {
+		rentalPayload.Status = model.RentalStatusHeld
+		rentalPayload.HoldExpiresAt = model.NewNullTime(time.Now().Add(r.holdTTL))
+	}
 
 	tx := r.db.WithContext(ctx).Begin()
 
+	err = r.ensureAvailable(tx, rental, "")
+	if err != nil {
+		tx.Rollback()
+		logger.Errorf("Error checking rental availability: %v", err)
+		return err
+	}
+
 	err = tx.Create(&rentalPayload).Error
 	if err != nil {
 		tx.Rollback()
@@ -101,7 +136,7 @@ func (r *rentalRepository) Create(ctx context.Context, rental model.RentalInput)
 		return err
 	}
 
-	if len(rental.EquipmentIDs) > 0 {
+	if rental.HasEquipments() {
 		equipments := rental.Equipments()
 		err := tx.Create(&equipments).Error
 		if err != nil {
@@ -111,6 +146,23 @@ func (r *rentalRepository) Create(ctx context.Context, rental model.RentalInput)
 		}
 	}
 
+	if rental.HasBundles() {
+		bundles := rental.Bundles()
+		err := tx.Create(&bundles).Error
+		if err != nil {
+			tx.Rollback()
+			logger.Errorf("Error creating rental bundles: %v", err)
+			return err
+		}
+	}
+
+	err = r.replaceLineItems(tx, id, rental.PricedLineItems())
+	if err != nil {
+		tx.Rollback()
+		logger.Errorf("Error creating rental line items: %v", err)
+		return err
+	}
+
 	tx.Commit()
 	return nil
 }
@@ -122,29 +174,58 @@ func (r *rentalRepository) Update(ctx context.Context, id string, rental model.R
 	})
 
 	var existingRental model.Rental
-	err := r.db.WithContext(ctx).Where("id = ?", id).First(&existingRental).Error
+	err := r.db.WithContext(ctx).
+		Preload("RentalEquipments").
+		Preload("RentalBundles").
+		Where("id = ?", id).
+		First(&existingRental).Error
 	if err != nil {
 		logger.Errorf("Error querying rental: %v", err)
 		return err
 	}
 
 	if existingRental.Status != model.RentalStatusPending {
-		logger.Errorf("Cannot update cancelled rental: %v", err)
-		return model.ErrRentalCancelled
+		logger.Errorf("Cannot update %s rental", existingRental.Status)
+		return model.ErrRentalNotEditable
 	}
 
+	rental.ID = id
 	rentalPayload := rental.ToEntity(id)
 
+	booking := rental.WithDefaults(existingRental)
+
+	rebooked := booking.CamperID != existingRental.CamperID ||
+		!booking.StartDate.Equal(existingRental.StartDate) ||
+		!booking.EndDate.Equal(existingRental.EndDate) ||
+		rental.HasEquipments() ||
+		rental.HasBundles()
+
+	if rebooked {
+		if err := booking.ValidatePeriod(); err != nil {
+			logger.Errorf("Invalid rental period: %v", err)
+			return err
+		}
+	}
+
 	tx := r.db.WithContext(ctx).Begin()
 
-	err = tx.Model(&existingRental).Updates(rentalPayload).Error
+	if rebooked {
+		err = r.ensureAvailable(tx, booking, id)
+		if err != nil {
+			tx.Rollback()
+			logger.Errorf("Error checking rental availability: %v", err)
+			return err
+		}
+	}
+
+	err = tx.Model(&existingRental).Omit(clause.Associations).Updates(rentalPayload).Error
 	if err != nil {
 		tx.Rollback()
 		logger.Errorf("Error updating rental: %v", err)
 		return err
 	}
 
-	if len(rental.EquipmentIDs) > 0 {
+	if rental.HasEquipments() {
 		err := tx.Where("rental_id = ?", id).Delete(&model.RentalEquipment{}).Error
 		if err != nil {
 			tx.Rollback()
@@ -162,6 +243,860 @@ func (r *rentalRepository) Update(ctx context.Context, id string, rental model.R
 		}
 	}
 
+	if rental.HasBundles() {
+		err := tx.Where("rental_id = ?", id).Delete(&model.RentalBundle{}).Error
+		if err != nil {
+			tx.Rollback()
+			logger.Errorf("Error deleting rental bundles: %v", err)
+			return err
+		}
+
+		bundles := rental.Bundles()
+
+		err = tx.Create(&bundles).Error
+		if err != nil {
+			tx.Rollback()
+			logger.Errorf("Error creating rental bundles: %v", err)
+			return err
+		}
+	}
+
+	if len(rental.LineItems) > 0 {
+		err = r.replaceLineItems(tx, id, rental.PricedLineItems())
+		if err != nil {
+			tx.Rollback()
+			logger.Errorf("Error replacing rental line items: %v", err)
+			return err
+		}
+	}
+
+	tx.Commit()
+	return nil
+}
+
+func (r *rentalRepository) Transition(ctx context.Context, id string, input model.RentalTransitionInput) (model.Rental, error) {
+	logger := logrus.WithFields(logrus.Fields{
+		"id":    id,
+		"input": utils.Dump(input),
+	})
+
+	tx := r.db.WithContext(ctx).Begin()
+
+	var rental model.Rental
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&rental).Error
+	if err != nil {
+		tx.Rollback()
+		logger.Errorf("Error querying rental: %v", err)
+		return model.Rental{}, err
+	}
+
+	if input.Action == model.RentalActionConfirm {
+		err = tx.Model(&model.Payment{}).
+			Select("COALESCE(SUM(amount), 0)").
+			Where("rental_id = ? AND type = ? AND status = ?", id, model.PaymentTypeDeposit, model.PaymentStatusPaid).
+			Scan(&input.DepositPaid).Error
+		if err != nil {
+			tx.Rollback()
+			logger.Errorf("Error summing deposit payments: %v", err)
+			return model.Rental{}, err
+		}
+	}
+
+	now := time.Now()
+
+	if input.Action == model.RentalActionStart {
+		var overdue int64
+		err = overdueRentals(tx, now).Where("camper_id = ? AND id <> ?", rental.CamperID, id).Count(&overdue).Error
+		if err != nil {
+			tx.Rollback()
+			logger.Errorf("Error checking overdue rentals: %v", err)
+			return model.Rental{}, err
+		}
+
+		if overdue > 0 {
+			tx.Rollback()
+			logger.Errorf("Camper has not been returned from an overdue rental")
+			return model.Rental{}, model.ErrCamperUnavailable
+		}
+	}
+
+	transitioned, err := rental.Transition(input, now)
+	if err != nil {
+		tx.Rollback()
+		logger.Errorf("Error transitioning rental: %v", err)
+		return model.Rental{}, err
+	}
+
+	if input.Action == model.RentalActionComplete {
+		err = r.chargeLateFee(tx, &transitioned, now)
+		if err != nil {
+			tx.Rollback()
+			logger.Errorf("Error charging late fee: %v", err)
+			return model.Rental{}, err
+		}
+
+		err = r.chargeMileage(tx, &transitioned)
+		if err != nil {
+			tx.Rollback()
+			logger.Errorf("Error charging excess mileage: %v", err)
+			return model.Rental{}, err
+		}
+	}
+
+	if transitioned.Status == model.RentalStatusCompleted || transitioned.Status == model.RentalStatusCancelled {
+		err = releaseEquipmentUnits(tx, id, now)
+		if err != nil {
+			tx.Rollback()
+			logger.Errorf("Error releasing equipment units: %v", err)
+			return model.Rental{}, err
+		}
+	}
+
+	err = tx.Model(&rental).Omit(clause.Associations).Select(
+		"status",
+		"confirmed_at",
+		"started_at",
+		"completed_at",
+		"cancelled_at",
+		"late_fee",
+		"distance",
+		"mileage_fee",
+		"grand_total",
+	).Updates(transitioned).Error
+	if err != nil {
+		tx.Rollback()
+		logger.Errorf("Error updating rental status: %v", err)
+		return model.Rental{}, err
+	}
+
+	tx.Commit()
+	return transitioned, nil
+}
+
+// Cancel cancels the rental under its cancellation policy and creates a
+// pending refund of what the customer gets back, for the caller to submit to
+// the payment gateway.
+func (r *rentalRepository) Cancel(ctx context.Context, id string, input model.RentalCancelInput) (model.RentalCancelResult, error) {
+	logger := logrus.WithFields(logrus.Fields{
+		"id":    id,
+		"input": utils.Dump(input),
+	})
+
+	tx := r.db.WithContext(ctx).Begin()
+
+	var rental model.Rental
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&rental).Error
+	if err != nil {
+		tx.Rollback()
+		logger.Errorf("Error querying rental: %v", err)
+		return model.RentalCancelResult{}, err
+	}
+
+	policy, err := r.cancellationPolicy(tx, rental.CamperID)
+	if err != nil {
+		tx.Rollback()
+		logger.Errorf("Error querying cancellation policy: %v", err)
+		return model.RentalCancelResult{}, err
+	}
+
+	var payments []model.Payment
+	err = tx.Where("rental_id = ?", id).Find(&payments).Error
+	if err != nil {
+		tx.Rollback()
+		logger.Errorf("Error querying payments: %v", err)
+		return model.RentalCancelResult{}, err
+	}
+
+	now := time.Now()
+
+	cancelled, err := rental.Cancel(input, policy, model.NewPaymentLedger(payments), now)
+	if err != nil {
+		tx.Rollback()
+		logger.Errorf("Error cancelling rental: %v", err)
+		return model.RentalCancelResult{}, err
+	}
+
+	err = releaseEquipmentUnits(tx, id, now)
+	if err != nil {
+		tx.Rollback()
+		logger.Errorf("Error releasing equipment units: %v", err)
+		return model.RentalCancelResult{}, err
+	}
+
+	err = tx.Model(&rental).Omit(clause.Associations).Select(
+		"status",
+		"cancelled_at",
+		"cancelled_by",
+		"cancellation_reason",
+		"cancellation_policy_id",
+		"refund_amount",
+		"cancellation_fee",
+		"refund_override_reason",
+	).Updates(cancelled).Error
+	if err != nil {
+		tx.Rollback()
+		logger.Errorf("Error updating cancelled rental: %v", err)
+		return model.RentalCancelResult{}, err
+	}
+
+	result := model.RentalCancelResult{Rental: cancelled}
+
+	if cancelled.RefundAmount.IsPositive() {
+		paymentID, err := gonanoid.New()
+		if err != nil {
+			tx.Rollback()
+			logger.Errorf("Error generating ID: %v", err)
+			return model.RentalCancelResult{}, err
+		}
+
+		payment := model.Payment{
+			ID:        paymentID,
+			RentalID:  id,
+			Type:      model.PaymentTypeRefund,
+			Status:    model.PaymentStatusPending,
+			Amount:    cancelled.RefundAmount,
+			Provider:  input.Provider,
+			Note:      "Refund on cancellation",
+			CreatedBy: input.ActorID,
+		}
+
+		err = tx.Create(&payment).Error
+		if err != nil {
+			tx.Rollback()
+			logger.Errorf("Error creating payment: %v", err)
+			return model.RentalCancelResult{}, err
+		}
+
+		result.Payment = &payment
+	}
+
+	tx.Commit()
+	return result, nil
+}
+
+// cancellationPolicy returns the camper's own policy, falling back to the
+// global policy and then to model.DefaultCancellationPolicy.
+func (r *rentalRepository) cancellationPolicy(tx *gorm.DB, camperID string) (model.CancellationPolicy, error) {
+	var policy model.CancellationPolicy
+
+	err := tx.Preload("Tiers").Where("camper_id = ?", camperID).First(&policy).Error
+	if err == nil {
+		return policy, nil
+	}
+
+	if err != gorm.ErrRecordNotFound {
+		return model.CancellationPolicy{}, err
+	}
+
+	err = tx.Preload("Tiers").Where("camper_id IS NULL").First(&policy).Error
+	if err == gorm.ErrRecordNotFound {
+		return model.DefaultCancellationPolicy, nil
+	}
+
+	if err != nil {
+		return model.CancellationPolicy{}, err
+	}
+
+	return policy, nil
+}
+
+// ChangeEndDate extends a rental or brings its return forward. An extension
+// must find the camper, driver and equipment free for the added days. The
+// rental is repriced at its original rates, the replaced end date is kept in
+// its history, and a pending charge or refund is created for the difference
+// for the caller to submit to the payment gateway.
+func (r *rentalRepository) ChangeEndDate(ctx context.Context, id string, input model.RentalDateChangeInput, reprice model.RentalRepricer) (model.RentalDateChangeResult, error) {
+	logger := logrus.WithFields(logrus.Fields{
+		"id":    id,
+		"input": utils.Dump(input),
+	})
+
+	tx := r.db.WithContext(ctx).Begin()
+
+	var rental model.Rental
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Preload("LineItems").
+		Preload("RentalEquipments").
+		Preload("RentalBundles").
+		Where("id = ?", id).
+		First(&rental).Error
+	if err != nil {
+		tx.Rollback()
+		logger.Errorf("Error querying rental: %v", err)
+		return model.RentalDateChangeResult{}, err
+	}
+
+	now := time.Now()
+
+	err = rental.ValidateEndDateChange(input, now)
+	if err != nil {
+		tx.Rollback()
+		logger.Errorf("Invalid end date change: %v", err)
+		return model.RentalDateChangeResult{}, err
+	}
+
+	if input.Type == model.RentalDateChangeExtend {
+		extension := model.RentalInput{
+			Rental: model.Rental{
+				CamperID:  rental.CamperID,
+				DriverID:  rental.DriverID,
+				StartDate: rental.EndDate,
+				EndDate:   input.EndDate,
+
+				RentalEquipments: rental.RentalEquipments,
+				RentalBundles:    rental.RentalBundles,
+			},
+		}
+
+		err = r.ensureAvailable(tx, extension, id)
+		if err != nil {
+			tx.Rollback()
+			logger.Errorf("Error checking extension availability: %v", err)
+			return model.RentalDateChangeResult{}, err
+		}
+	}
+
+	price, err := reprice(rental, input.EndDate)
+	if err != nil {
+		tx.Rollback()
+		logger.Errorf("Error repricing rental: %v", err)
+		return model.RentalDateChangeResult{}, err
+	}
+
+	delta := price.GrandTotal.Sub(rental.PricedTotal())
+
+	updated := rental
+	updated.EndDate = input.EndDate
+	updated.RentalType = price.RentalType
+	updated.Discount = price.Discount
+	updated.GrandTotal = rental.GrandTotal.Add(delta)
+	if !updated.IsOverdue(now) {
+		updated.OverdueSince = model.NullTime{}
+		updated.LateFee = decimal.Zero
+	}
+
+	err = tx.Where("rental_id = ? AND kind IN ?", id, model.PricedLineItemKinds).Delete(&model.RentalLineItem{}).Error
+	if err != nil {
+		tx.Rollback()
+		logger.Errorf("Error deleting rental line items: %v", err)
+		return model.RentalDateChangeResult{}, err
+	}
+
+	err = createLineItems(tx, id, price.LineItems)
+	if err != nil {
+		tx.Rollback()
+		logger.Errorf("Error creating rental line items: %v", err)
+		return model.RentalDateChangeResult{}, err
+	}
+
+	err = tx.Model(&rental).Omit(clause.Associations).Select(
+		"end_date",
+		"rental_type",
+		"discount",
+		"grand_total",
+		"overdue_since",
+		"late_fee",
+	).Updates(updated).Error
+	if err != nil {
+		tx.Rollback()
+		logger.Errorf("Error updating rental: %v", err)
+		return model.RentalDateChangeResult{}, err
+	}
+
+	var payments []model.Payment
+	err = tx.Where("rental_id = ?", id).Find(&payments).Error
+	if err != nil {
+		tx.Rollback()
+		logger.Errorf("Error querying payments: %v", err)
+		return model.RentalDateChangeResult{}, err
+	}
+
+	result := model.RentalDateChangeResult{}
+
+	paymentType, amount := model.DateChangePayment(delta, updated.GrandTotal, model.NewPaymentLedger(payments))
+	if paymentType != "" {
+		paymentID, err := gonanoid.New()
+		if err != nil {
+			tx.Rollback()
+			logger.Errorf("Error generating ID: %v", err)
+			return model.RentalDateChangeResult{}, err
+		}
+
+		payment := model.Payment{
+			ID:        paymentID,
+			RentalID:  id,
+			Type:      paymentType,
+			Status:    model.PaymentStatusPending,
+			Amount:    amount,
+			Provider:  input.Provider,
+			Note:      fmt.Sprintf("End date moved from %s to %s", rental.EndDate.Format(model.DateLayout), input.EndDate.Format(model.DateLayout)),
+			CreatedBy: input.ActorID,
+		}
+
+		err = tx.Create(&payment).Error
+		if err != nil {
+			tx.Rollback()
+			logger.Errorf("Error creating payment: %v", err)
+			return model.RentalDateChangeResult{}, err
+		}
+
+		result.Payment = &payment
+	}
+
+	changeID, err := gonanoid.New()
+	if err != nil {
+		tx.Rollback()
+		logger.Errorf("Error generating ID: %v", err)
+		return model.RentalDateChangeResult{}, err
+	}
+
+	result.Change = model.RentalDateChange{
+		ID:              changeID,
+		RentalID:        id,
+		Type:            input.Type,
+		PreviousEndDate: rental.EndDate,
+		NewEndDate:      input.EndDate,
+		PriceDelta:      delta,
+		CreatedBy:       input.ActorID,
+	}
+	if result.Payment != nil {
+		result.Change.PaymentID = &result.Payment.ID
+	}
+
+	err = tx.Create(&result.Change).Error
+	if err != nil {
+		tx.Rollback()
+		logger.Errorf("Error creating rental date change: %v", err)
+		return model.RentalDateChangeResult{}, err
+	}
+
 	tx.Commit()
+
+	updated.LineItems = nil
+	updated.RentalEquipments = nil
+	updated.RentalBundles = nil
+	result.Rental = updated
+
+	return result, nil
+}
+
+// chargeLateFee bills a rental completed past its return time for the late
+// fee owed at now.
+func (r *rentalRepository) chargeLateFee(tx *gorm.DB, rental *model.Rental, now time.Time) error {
+	var camper model.Camper
+	err := tx.Where("id = ?", rental.CamperID).First(&camper).Error
+	if err != nil {
+		return err
+	}
+
+	rental.LateFee = camper.LateFee(*rental, now)
+	if !rental.LateFee.IsPositive() {
+		return nil
+	}
+
+	id, err := gonanoid.New()
+	if err != nil {
+		return err
+	}
+
+	item := model.RentalLineItem{
+		ID:          id,
+		RentalID:    rental.ID,
+		Kind:        model.LineItemLateFee,
+		Description: fmt.Sprintf("Late return, due %s", rental.ReturnDue().Format("2 Jan 2006 15:04")),
+		Quantity:    1,
+		UnitPrice:   rental.LateFee,
+		Amount:      rental.LateFee,
+	}
+
+	err = tx.Create(&item).Error
+	if err != nil {
+		return err
+	}
+
+	rental.GrandTotal = rental.GrandTotal.Add(rental.LateFee)
+	return nil
+}
+
+// chargeMileage records how far a completed rental was driven, from its
+// check-out and check-in inspections, and bills the kilometres beyond the
+// allowance of its rental type.
+func (r *rentalRepository) chargeMileage(tx *gorm.DB, rental *model.Rental) error {
+	var inspections []model.RentalInspection
+	err := tx.Where("rental_id = ?", rental.ID).Find(&inspections).Error
+	if err != nil {
+		return err
+	}
+
+	report := model.NewInspectionReport(inspections, model.InspectionRates{})
+	if report.Comparison == nil {
+		return nil
+	}
+
+	rental.Distance = report.Comparison.DistanceDriven
+
+	item := r.mileage.Overage(*rental)
+	if !item.Amount.IsPositive() {
+		return nil
+	}
+
+	item.ID, err = gonanoid.New()
+	if err != nil {
+		return err
+	}
+
+	err = tx.Create(&item).Error
+	if err != nil {
+		return err
+	}
+
+	rental.MileageFee = item.Amount
+	rental.GrandTotal = rental.GrandTotal.Add(item.Amount)
 	return nil
 }
+
+// FlagOverdue marks active rentals past their return time as overdue and
+// refreshes the late fee they have run up so far. Only rentals flagged for
+// the first time are returned, with the bookings they impact, so staff are
+// notified once per rental.
+func (r *rentalRepository) FlagOverdue(ctx context.Context, now time.Time) ([]model.OverdueRental, error) {
+	logger := logrus.WithField("now", now)
+
+	tx := r.db.WithContext(ctx).Begin()
+
+	var rentals []model.Rental
+	err := overdueRentals(tx, now).Clauses(clause.Locking{Strength: "UPDATE"}).Find(&rentals).Error
+	if err != nil {
+		tx.Rollback()
+		logger.Errorf("Error querying overdue rentals: %v", err)
+		return nil, err
+	}
+
+	var flagged []model.OverdueRental
+	for _, rental := range rentals {
+		var camper model.Camper
+		err = tx.Where("id = ?", rental.CamperID).First(&camper).Error
+		if err != nil {
+			tx.Rollback()
+			logger.Errorf("Error querying camper: %v", err)
+			return nil, err
+		}
+
+		updated := rental
+		updated.LateFee = camper.LateFee(rental, now)
+		if !updated.OverdueSince.Valid {
+			updated.OverdueSince = model.NewNullTime(now)
+		}
+
+		err = tx.Model(&rental).Omit(clause.Associations).Select("overdue_since", "late_fee").Updates(updated).Error
+		if err != nil {
+			tx.Rollback()
+			logger.Errorf("Error flagging overdue rental: %v", err)
+			return nil, err
+		}
+
+		if rental.OverdueSince.Valid {
+			continue
+		}
+
+		var impacted []model.Rental
+		err = tx.Where("camper_id = ? AND id <> ? AND deleted_at IS NULL", rental.CamperID, rental.ID).
+			Where("status IN ?", []string{model.RentalStatusPending, model.RentalStatusConfirmed}).
+			Where("start_date < ?", now.Add(model.LateReturnImpactWindow)).
+			Order("start_date ASC").
+			Find(&impacted).Error
+		if err != nil {
+			tx.Rollback()
+			logger.Errorf("Error querying impacted rentals: %v", err)
+			return nil, err
+		}
+
+		flagged = append(flagged, model.OverdueRental{
+			Rental:   updated,
+			Impacted: impacted,
+		})
+	}
+
+	tx.Commit()
+	return flagged, nil
+}
+
+// ReleaseExpiredHolds cancels held rentals whose hold has run out, so they no
+// longer show up as cancellable or confirmable holds. Availability checks
+// already ignore them once they expire.
+func (r *rentalRepository) ReleaseExpiredHolds(ctx context.Context, now time.Time) (int64, error) {
+	logger := logrus.WithField("now", now)
+
+	result := r.db.WithContext(ctx).Model(&model.Rental{}).
+		Where("status = ? AND hold_expires_at <= ?", model.RentalStatusHeld, now).
+		Updates(map[string]any{
+			"status":              model.RentalStatusCancelled,
+			"cancelled_at":        now,
+			"cancellation_reason": "hold expired",
+		})
+	if result.Error != nil {
+		logger.Errorf("Error releasing expired holds: %v", result.Error)
+		return 0, result.Error
+	}
+
+	return result.RowsAffected, nil
+}
+
+// replaceLineItems swaps the stored price breakdown of a rental for items.
+func (r *rentalRepository) replaceLineItems(tx *gorm.DB, rentalID string, items []model.RentalLineItem) error {
+	err := tx.Where("rental_id = ?", rentalID).Delete(&model.RentalLineItem{}).Error
+	if err != nil {
+		return err
+	}
+
+	return createLineItems(tx, rentalID, items)
+}
+
+// createLineItems adds items to the rental's price breakdown.
+func createLineItems(tx *gorm.DB, rentalID string, items []model.RentalLineItem) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	for i := range items {
+		id, err := gonanoid.New()
+		if err != nil {
+			return err
+		}
+
+		items[i].ID = id
+		items[i].RentalID = rentalID
+	}
+
+	return tx.Create(&items).Error
+}
+
+func (r *rentalRepository) CheckAvailability(ctx context.Context, rental model.RentalInput, excludeID string) ([]model.BlockingReason, error) {
+	logger := logrus.WithFields(logrus.Fields{
+		"rental":     utils.Dump(rental),
+		"exclude_id": excludeID,
+	})
+
+	reasons, err := r.blockingReasons(r.db.WithContext(ctx), rental, excludeID)
+	if err != nil {
+		logger.Errorf("Error checking rental availability: %v", err)
+		return nil, err
+	}
+
+	return reasons, nil
+}
+
+// ensureAvailable locks the camper and equipment rows for the rest of tx and
+// fails with the first blocking reason. Holding the locks serialises
+// concurrent bookings of the same camper or equipment, so two transactions
+// cannot both pass the check.
+func (r *rentalRepository) ensureAvailable(tx *gorm.DB, rental model.RentalInput, excludeID string) error {
+	var camper model.Camper
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", rental.CamperID).First(&camper).Error
+	if err != nil {
+		return err
+	}
+
+	if rental.DriverID != "" {
+		var driver model.Driver
+		err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", rental.DriverID).First(&driver).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	requested, err := requestedStock(tx, rental)
+	if err != nil {
+		return err
+	}
+
+	if len(requested) > 0 {
+		var equipments []model.Equipment
+		err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id IN ?", stockIDs(requested)).
+			Order("id").
+			Find(&equipments).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	reasons, err := r.blockingReasons(tx, rental, excludeID)
+	if err != nil {
+		return err
+	}
+
+	if len(reasons) > 0 {
+		return reasons[0].Err()
+	}
+
+	return nil
+}
+
+// requestedStock is how many of each equipment the rental takes from stock,
+// with its bundles exploded into their contents.
+func requestedStock(db *gorm.DB, rental model.RentalInput) (map[string]int, error) {
+	var items []model.EquipmentBundleItem
+	if rental.HasBundles() {
+		ids := rental.RequestedBundleIDs()
+
+		var found int64
+		err := db.Model(&model.EquipmentBundle{}).Where("id IN ?", ids).Count(&found).Error
+		if err != nil {
+			return nil, err
+		}
+
+		if int(found) != len(ids) {
+			return nil, model.ErrBundleNotFound
+		}
+
+		err = db.Where("bundle_id IN ?", ids).Find(&items).Error
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return rental.RequestedStock(items), nil
+}
+
+func stockIDs(requested map[string]int) []string {
+	ids := make([]string, 0, len(requested))
+	for id := range requested {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// blockingReasons lists everything preventing the rental from being booked:
+// overlapping rentals, maintenance, damage or an overdue return on the
+// camper, a driver already assigned elsewhere, and equipment whose stock is
+// used up by overlapping rentals. excludeID skips the rental being
+// updated.
+func (r *rentalRepository) blockingReasons(db *gorm.DB, rental model.RentalInput, excludeID string) ([]model.BlockingReason, error) {
+	var reasons []model.BlockingReason
+
+	qb := bookedCamperIDs(db, rental.StartDate, rental.EndDate).Where("camper_id = ?", rental.CamperID)
+	if excludeID != "" {
+		qb = qb.Where("id <> ?", excludeID)
+	}
+
+	var booked int64
+	if err := qb.Count(&booked).Error; err != nil {
+		return nil, err
+	}
+
+	if booked > 0 {
+		reasons = append(reasons, model.BlockingReason{
+			Code:        model.BlockingCamperUnavailable,
+			ReferenceID: rental.CamperID,
+			Message:     "camper is already booked for the requested dates",
+		})
+	}
+
+	var maintained int64
+	err := maintainedCamperIDs(db, rental.StartDate, rental.EndDate).
+		Where("camper_id = ?", rental.CamperID).
+		Count(&maintained).Error
+	if err != nil {
+		return nil, err
+	}
+
+	if maintained > 0 {
+		reasons = append(reasons, model.BlockingReason{
+			Code:        model.BlockingCamperUnavailable,
+			ReferenceID: rental.CamperID,
+			Message:     "camper is in maintenance during the requested dates",
+		})
+	}
+
+	var damaged int64
+	err = damagedCamperIDs(db).Where("camper_id = ?", rental.CamperID).Count(&damaged).Error
+	if err != nil {
+		return nil, err
+	}
+
+	if damaged > 0 {
+		reasons = append(reasons, model.BlockingReason{
+			Code:        model.BlockingCamperUnavailable,
+			ReferenceID: rental.CamperID,
+			Message:     "camper is damaged and awaiting repair",
+		})
+	}
+
+	if rental.DriverID != "" {
+		qb := bookedDriverIDs(db, rental.StartDate, rental.EndDate).Where("driver_id = ?", rental.DriverID)
+		if excludeID != "" {
+			qb = qb.Where("id <> ?", excludeID)
+		}
+
+		var driving int64
+		if err := qb.Count(&driving).Error; err != nil {
+			return nil, err
+		}
+
+		if driving > 0 {
+			reasons = append(reasons, model.BlockingReason{
+				Code:        model.BlockingDriverUnavailable,
+				ReferenceID: rental.DriverID,
+				Message:     "driver is already assigned to another rental during the requested dates",
+			})
+		}
+	}
+
+	now := time.Now()
+	if rental.StartDate.Before(now.Add(model.LateReturnImpactWindow)) {
+		qb := overdueRentals(db, now).Where("camper_id = ?", rental.CamperID)
+		if excludeID != "" {
+			qb = qb.Where("id <> ?", excludeID)
+		}
+
+		var overdue int64
+		if err := qb.Count(&overdue).Error; err != nil {
+			return nil, err
+		}
+
+		if overdue > 0 {
+			reasons = append(reasons, model.BlockingReason{
+				Code:        model.BlockingCamperUnavailable,
+				ReferenceID: rental.CamperID,
+				Message:     "camper has not been returned from an overdue rental",
+			})
+		}
+	}
+
+	requested, err := requestedStock(db, rental)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(requested) == 0 {
+		return reasons, nil
+	}
+
+	ids := stockIDs(requested)
+
+	var equipments []model.Equipment
+	err = db.Model(&model.Equipment{}).Where("id IN ?", ids).Find(&equipments).Error
+	if err != nil {
+		return nil, err
+	}
+
+	reserved, err := reservedEquipment(db, ids, rental.StartDate, rental.EndDate, excludeID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, equipment := range equipments {
+		stock := model.NewEquipmentStock(equipment, reserved[equipment.ID])
+		if requested[equipment.ID] > stock.Available {
+			reasons = append(reasons, model.BlockingReason{
+				Code:        model.BlockingEquipmentOutOfStock,
+				ReferenceID: equipment.ID,
+				Message: fmt.Sprintf("%s: %d requested but only %d available for the requested dates",
+					equipment.Name, requested[equipment.ID], stock.Available),
+			})
+		}
+	}
+
+	return reasons, nil
+}
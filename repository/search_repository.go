@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"context"
+	"strings"
+	"unicode"
+
+	"github.com/notblessy/rms/model"
+	"github.com/notblessy/rms/utils"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// searchSources select the hits of each searchable table, ranked against
+// the @query tsquery. Their columns line up so they can be combined with
+// UNION ALL.
+var searchSources = map[string]string{
+	model.SearchTypeCamper: `SELECT 'camper' AS type, id, name AS title, license_plate AS subtitle,
+		COALESCE(image_url, '') AS image_url, ts_rank(search_vector, to_tsquery('simple', @query)) AS rank
+		FROM campers WHERE search_vector @@ to_tsquery('simple', @query)`,
+	model.SearchTypeEquipment: `SELECT 'equipment' AS type, id, name AS title, category AS subtitle,
+		COALESCE(image_url, '') AS image_url, ts_rank(search_vector, to_tsquery('simple', @query)) AS rank
+		FROM equipments WHERE search_vector @@ to_tsquery('simple', @query)`,
+	model.SearchTypeDriver: `SELECT 'driver' AS type, id, name AS title, license_number AS subtitle,
+		COALESCE(photo, '') AS image_url, ts_rank(search_vector, to_tsquery('simple', @query)) AS rank
+		FROM drivers WHERE search_vector @@ to_tsquery('simple', @query)`,
+}
+
+type searchRepository struct {
+	db *gorm.DB
+}
+
+// NewSearchRepository :nodoc:
+func NewSearchRepository(d *gorm.DB) model.SearchRepository {
+	return &searchRepository{
+		db: d,
+	}
+}
+
+// Search finds campers, equipment and drivers matching every word of the
+// query, best matches first.
+func (s *searchRepository) Search(ctx context.Context, query model.SearchQueryInput) ([]model.SearchHit, error) {
+	logger := logrus.WithField("query", utils.Dump(query))
+
+	err := query.Validate()
+	if err != nil {
+		logger.Errorf("Error validating search query: %v", err)
+		return nil, err
+	}
+
+	tsquery := searchQuery(query.Q)
+	if tsquery == "" {
+		logger.Errorf("Search query has no words to search for")
+		return nil, model.ErrInvalidSearchQuery
+	}
+
+	var sources []string
+	for _, searchType := range query.SearchTypes() {
+		sources = append(sources, "("+searchSources[searchType]+")")
+	}
+
+	sql := strings.Join(sources, " UNION ALL ") + " ORDER BY rank DESC, title ASC LIMIT @limit"
+
+	hits := []model.SearchHit{}
+	err = s.db.WithContext(ctx).Raw(sql, map[string]any{
+		"query": tsquery,
+		"limit": query.LimitOrDefault(),
+	}).Scan(&hits).Error
+	if err != nil {
+		logger.Errorf("Error searching: %v", err)
+		return nil, err
+	}
+
+	return hits, nil
+}
+
+// searchQuery turns free text into a tsquery in which every word must
+// prefix-match a word of the document, so results narrow as the user types.
+// Punctuation is dropped, which also keeps tsquery operators out of it. It
+// returns "" when there is no word to search for.
+func searchQuery(text string) string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+
+	for i, word := range words {
+		words[i] = word + ":*"
+	}
+
+	return strings.Join(words, " & ")
+}
+
+// matchKeyword narrows a listing of table to the rows whose search vector
+// matches the keyword.
+func matchKeyword(table, keyword string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		tsquery := searchQuery(keyword)
+		if tsquery == "" {
+			return db
+		}
+
+		return db.Where(table+".search_vector @@ to_tsquery('simple', ?)", tsquery)
+	}
+}
+
+// keywordOrder sorts a listing of table by the page's sort or, when a
+// keyword search is not sorted explicitly, by how well rows match it.
+func keywordOrder(table, keyword string, page model.PaginatedRequest) any {
+	tsquery := searchQuery(keyword)
+	if tsquery == "" || page.Sort != "" {
+		return page.Sorted()
+	}
+
+	return clause.OrderBy{
+		Expression: clause.Expr{
+			SQL:  "ts_rank(" + table + ".search_vector, to_tsquery('simple', ?)) DESC, " + table + ".created_at DESC",
+			Vars: []any{tsquery},
+		},
+	}
+}
@@ -0,0 +1,150 @@
+package repository
+
+import (
+	"context"
+
+	gonanoid "github.com/matoous/go-nanoid/v2"
+	"github.com/notblessy/rms/model"
+	"github.com/notblessy/rms/utils"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type securityDepositRepository struct {
+	db *gorm.DB
+}
+
+// NewSecurityDepositRepository :nodoc:
+func NewSecurityDepositRepository(d *gorm.DB) model.SecurityDepositRepository {
+	return &securityDepositRepository{
+		db: d,
+	}
+}
+
+func (s *securityDepositRepository) FindByRentalID(ctx context.Context, rentalID string) (model.SecurityDeposit, error) {
+	logger := logrus.WithField("rental_id", rentalID)
+
+	var rental model.Rental
+	err := s.db.WithContext(ctx).Select("id").Where("id = ?", rentalID).First(&rental).Error
+	if err != nil {
+		logger.Errorf("Error querying rental: %v", err)
+		return model.SecurityDeposit{}, err
+	}
+
+	deposit, err := s.load(s.db.WithContext(ctx), rentalID)
+	if err != nil {
+		logger.Errorf("Error querying security deposit events: %v", err)
+		return model.SecurityDeposit{}, err
+	}
+
+	return deposit, nil
+}
+
+func (s *securityDepositRepository) Hold(ctx context.Context, rentalID string, input model.SecurityDepositInput) (model.SecurityDeposit, error) {
+	return s.apply(ctx, rentalID, input, func(tx *gorm.DB, rental model.Rental, deposit model.SecurityDeposit) (model.SecurityDepositEvent, error) {
+		var camper model.Camper
+		err := tx.Where("id = ?", rental.CamperID).First(&camper).Error
+		if err != nil {
+			return model.SecurityDepositEvent{}, err
+		}
+
+		return deposit.Hold(rental, camper.SecurityDeposit, input)
+	})
+}
+
+func (s *securityDepositRepository) Capture(ctx context.Context, rentalID string, input model.SecurityDepositInput) (model.SecurityDeposit, error) {
+	return s.apply(ctx, rentalID, input, func(tx *gorm.DB, rental model.Rental, deposit model.SecurityDeposit) (model.SecurityDepositEvent, error) {
+		return deposit.Capture(rental, input)
+	})
+}
+
+func (s *securityDepositRepository) Release(ctx context.Context, rentalID string, input model.SecurityDepositInput) (model.SecurityDeposit, error) {
+	return s.apply(ctx, rentalID, input, func(tx *gorm.DB, rental model.Rental, deposit model.SecurityDeposit) (model.SecurityDepositEvent, error) {
+		return deposit.Release(rental, input)
+	})
+}
+
+// apply locks the rental, appends the event decided by action to the deposit
+// history and mirrors the resulting state on the rental.
+func (s *securityDepositRepository) apply(
+	ctx context.Context,
+	rentalID string,
+	input model.SecurityDepositInput,
+	action func(tx *gorm.DB, rental model.Rental, deposit model.SecurityDeposit) (model.SecurityDepositEvent, error),
+) (model.SecurityDeposit, error) {
+	logger := logrus.WithFields(logrus.Fields{
+		"rental_id": rentalID,
+		"input":     utils.Dump(input),
+	})
+
+	tx := s.db.WithContext(ctx).Begin()
+
+	var rental model.Rental
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", rentalID).First(&rental).Error
+	if err != nil {
+		tx.Rollback()
+		logger.Errorf("Error querying rental: %v", err)
+		return model.SecurityDeposit{}, err
+	}
+
+	deposit, err := s.load(tx, rentalID)
+	if err != nil {
+		tx.Rollback()
+		logger.Errorf("Error querying security deposit events: %v", err)
+		return model.SecurityDeposit{}, err
+	}
+
+	event, err := action(tx, rental, deposit)
+	if err != nil {
+		tx.Rollback()
+		logger.Errorf("Error applying security deposit action: %v", err)
+		return model.SecurityDeposit{}, err
+	}
+
+	id, err := gonanoid.New()
+	if err != nil {
+		tx.Rollback()
+		logger.Errorf("Error generating ID: %v", err)
+		return model.SecurityDeposit{}, err
+	}
+
+	event.ID = id
+
+	err = tx.Create(&event).Error
+	if err != nil {
+		tx.Rollback()
+		logger.Errorf("Error creating security deposit event: %v", err)
+		return model.SecurityDeposit{}, err
+	}
+
+	deposit = model.NewSecurityDeposit(rentalID, append(deposit.Events, event))
+
+	err = tx.Model(&rental).Omit(clause.Associations).Select(
+		"security_deposit_status",
+		"security_deposit_held",
+		"security_deposit_captured",
+	).Updates(model.Rental{
+		SecurityDepositStatus:   deposit.Status,
+		SecurityDepositHeld:     deposit.Held,
+		SecurityDepositCaptured: deposit.Captured,
+	}).Error
+	if err != nil {
+		tx.Rollback()
+		logger.Errorf("Error updating rental security deposit: %v", err)
+		return model.SecurityDeposit{}, err
+	}
+
+	tx.Commit()
+	return deposit, nil
+}
+
+func (s *securityDepositRepository) load(db *gorm.DB, rentalID string) (model.SecurityDeposit, error) {
+	var events []model.SecurityDepositEvent
+	err := db.Where("rental_id = ?", rentalID).Order("created_at ASC").Find(&events).Error
+	if err != nil {
+		return model.SecurityDeposit{}, err
+	}
+
+	return model.NewSecurityDeposit(rentalID, events), nil
+}
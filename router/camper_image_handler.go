@@ -0,0 +1,192 @@
+package router
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/notblessy/rms/model"
+	"github.com/notblessy/rms/utils"
+	"github.com/sirupsen/logrus"
+)
+
+func (h *httpService) findCamperImagesHandler(c echo.Context) error {
+	logger := logrus.WithField("context", utils.Dump(c))
+
+	images, err := h.camperImageRepo.FindByCamperID(c.Request().Context(), c.Param("id"))
+	if err != nil {
+		logger.Errorf("Error querying camper images: %v", err)
+		return c.JSON(http.StatusInternalServerError, response{
+			Success: false,
+			Message: "internal server error",
+		})
+	}
+
+	return c.JSON(http.StatusOK, response{
+		Success: true,
+		Data:    images,
+	})
+}
+
+// uploadCamperImagesHandler adds the photos sent as the multipart "images"
+// field to the camper's gallery, in the order they were sent.
+func (h *httpService) uploadCamperImagesHandler(c echo.Context) error {
+	logger := logrus.WithField("context", utils.Dump(c))
+
+	session, err := authSession(c)
+	if err != nil {
+		logger.Errorf("Error getting session: %v", err)
+		return c.JSON(http.StatusUnauthorized, response{
+			Success: false,
+			Message: "unauthorized",
+		})
+	}
+
+	if session.IsCustomer() {
+		logger.Errorf("User is not authorized to access this resource")
+		return c.JSON(http.StatusForbidden, response{
+			Success: false,
+			Message: "forbidden",
+		})
+	}
+
+	form, err := c.MultipartForm()
+	if err != nil {
+		logger.Errorf("Error parsing request: %v", err)
+		return c.JSON(http.StatusBadRequest, response{
+			Success: false,
+			Message: err.Error(),
+		})
+	}
+
+	files := form.File["images"]
+	if len(files) == 0 {
+		logger.Errorf("No images uploaded")
+		return imageErrorResponse(c, model.ErrInvalidImage)
+	}
+
+	var images []model.CamperImage
+	for _, file := range files {
+		upload, err := readImageUpload(file, session.ID)
+		if err != nil {
+			logger.Errorf("Error reading uploaded image: %v", err)
+			return imageErrorResponse(c, err)
+		}
+
+		image, err := h.camperImageRepo.Upload(c.Request().Context(), c.Param("id"), upload)
+		if err != nil {
+			logger.Errorf("Error uploading camper image: %v", err)
+			return imageErrorResponse(c, err)
+		}
+
+		images = append(images, image)
+	}
+
+	return c.JSON(http.StatusCreated, response{
+		Success: true,
+		Data:    images,
+	})
+}
+
+func (h *httpService) reorderCamperImagesHandler(c echo.Context) error {
+	logger := logrus.WithField("context", utils.Dump(c))
+
+	var input model.CamperImageOrderInput
+	if err := c.Bind(&input); err != nil {
+		logger.Errorf("Error parsing request: %v", err)
+		return c.JSON(http.StatusBadRequest, response{
+			Success: false,
+			Message: err.Error(),
+		})
+	}
+
+	session, err := authSession(c)
+	if err != nil {
+		logger.Errorf("Error getting session: %v", err)
+		return c.JSON(http.StatusUnauthorized, response{
+			Success: false,
+			Message: "unauthorized",
+		})
+	}
+
+	if session.IsCustomer() {
+		logger.Errorf("User is not authorized to access this resource")
+		return c.JSON(http.StatusForbidden, response{
+			Success: false,
+			Message: "forbidden",
+		})
+	}
+
+	images, err := h.camperImageRepo.Reorder(c.Request().Context(), c.Param("id"), input)
+	if err != nil {
+		logger.Errorf("Error reordering camper images: %v", err)
+		return imageErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, response{
+		Success: true,
+		Data:    images,
+	})
+}
+
+func (h *httpService) setPrimaryCamperImageHandler(c echo.Context) error {
+	logger := logrus.WithField("context", utils.Dump(c))
+
+	session, err := authSession(c)
+	if err != nil {
+		logger.Errorf("Error getting session: %v", err)
+		return c.JSON(http.StatusUnauthorized, response{
+			Success: false,
+			Message: "unauthorized",
+		})
+	}
+
+	if session.IsCustomer() {
+		logger.Errorf("User is not authorized to access this resource")
+		return c.JSON(http.StatusForbidden, response{
+			Success: false,
+			Message: "forbidden",
+		})
+	}
+
+	image, err := h.camperImageRepo.SetPrimary(c.Request().Context(), c.Param("id"), c.Param("imageID"))
+	if err != nil {
+		logger.Errorf("Error setting primary camper image: %v", err)
+		return imageErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, response{
+		Success: true,
+		Data:    image,
+	})
+}
+
+func (h *httpService) deleteCamperImageHandler(c echo.Context) error {
+	logger := logrus.WithField("context", utils.Dump(c))
+
+	session, err := authSession(c)
+	if err != nil {
+		logger.Errorf("Error getting session: %v", err)
+		return c.JSON(http.StatusUnauthorized, response{
+			Success: false,
+			Message: "unauthorized",
+		})
+	}
+
+	if session.IsCustomer() {
+		logger.Errorf("User is not authorized to access this resource")
+		return c.JSON(http.StatusForbidden, response{
+			Success: false,
+			Message: "forbidden",
+		})
+	}
+
+	err = h.camperImageRepo.Delete(c.Request().Context(), c.Param("id"), c.Param("imageID"))
+	if err != nil {
+		logger.Errorf("Error deleting camper image: %v", err)
+		return imageErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusNoContent, response{
+		Success: true,
+	})
+}
@@ -1,12 +1,15 @@
 package router
 
 import (
+	"errors"
 	"net/http"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/notblessy/rms/model"
 	"github.com/notblessy/rms/utils"
 	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
 )
 
 func (h *httpService) findCamperByIDHandler(c echo.Context) error {
@@ -45,13 +48,32 @@ func (h *httpService) findAllCampersHandler(c echo.Context) error {
 	campers, total, err := h.camperRepo.FindAll(c.Request().Context(), query)
 	if err != nil {
 		logger.Errorf("Error getting campers: %v", err)
+		if errors.Is(err, model.ErrInvalidAvailabilityRange) || errors.Is(err, model.ErrInvalidCamperFilter) {
+			return c.JSON(http.StatusBadRequest, response{
+				Success: false,
+				Message: err.Error(),
+			})
+		}
+
 		return c.JSON(http.StatusInternalServerError, response{
 			Success: false,
 			Message: err.Error(),
 		})
 	}
 
-	return c.JSON(http.StatusOK, withPaging(campers, total, query.PageOrDefault(), query.SizeOrDefault()))
+	facets, err := h.camperRepo.Facets(c.Request().Context(), query)
+	if err != nil {
+		logger.Errorf("Error getting camper facets: %v", err)
+		return c.JSON(http.StatusInternalServerError, response{
+			Success: false,
+			Message: "internal server error",
+		})
+	}
+
+	return c.JSON(http.StatusOK, responseWithFacets{
+		responseWithPaging: withPaging(campers, total, query.PageOrDefault(), query.SizeOrDefault()),
+		Facets:             facets,
+	})
 }
 
 func (h *httpService) updateCamperHandler(c echo.Context) error {
@@ -168,6 +190,150 @@ func (h *httpService) deleteCamperHandler(c echo.Context) error {
 
 	if err := h.camperRepo.Delete(c.Request().Context(), id); err != nil {
 		logger.Errorf("Error deleting camper: %v", err)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.JSON(http.StatusNotFound, response{
+				Success: false,
+				Message: "camper not found",
+			})
+		}
+
+		return c.JSON(http.StatusInternalServerError, response{
+			Success: false,
+			Message: "internal server error",
+		})
+	}
+
+	return c.JSON(http.StatusNoContent, response{
+		Success: true,
+	})
+}
+
+func (h *httpService) findCamperAvailabilityHandler(c echo.Context) error {
+	logger := logrus.WithField("context", utils.Dump(c))
+
+	id := c.Param("id")
+
+	var query model.AvailabilityQueryInput
+
+	if err := c.Bind(&query); err != nil {
+		logger.Errorf("Error parsing request: %v", err)
+		return c.JSON(http.StatusBadRequest, response{
+			Success: false,
+			Message: err.Error(),
+		})
+	}
+
+	from, to, err := query.Period(time.Now())
+	if err != nil {
+		logger.Errorf("Error parsing availability range: %v", err)
+		return c.JSON(http.StatusBadRequest, response{
+			Success: false,
+			Message: err.Error(),
+		})
+	}
+
+	availability, err := h.camperRepo.Availability(c.Request().Context(), id, from, to)
+	if err != nil {
+		logger.Errorf("Error getting camper availability: %v", err)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.JSON(http.StatusNotFound, response{
+				Success: false,
+				Message: "camper not found",
+			})
+		}
+
+		return c.JSON(http.StatusInternalServerError, response{
+			Success: false,
+			Message: "internal server error",
+		})
+	}
+
+	return c.JSON(http.StatusOK, response{
+		Success: true,
+		Data:    availability,
+	})
+}
+
+func (h *httpService) createMaintenanceWindowHandler(c echo.Context) error {
+	logger := logrus.WithField("context", utils.Dump(c))
+
+	id := c.Param("id")
+
+	var window model.MaintenanceWindow
+
+	if err := c.Bind(&window); err != nil {
+		logger.Errorf("Error parsing request: %v", err)
+		return c.JSON(http.StatusBadRequest, response{
+			Success: false,
+			Message: err.Error(),
+		})
+	}
+
+	session, err := authSession(c)
+	if err != nil {
+		logger.Errorf("Error getting session: %v", err)
+		return c.JSON(http.StatusUnauthorized, response{
+			Success: false,
+			Message: "unauthorized",
+		})
+	}
+
+	if session.IsCustomer() {
+		logger.Errorf("User is not authorized to access this resource")
+		return c.JSON(http.StatusForbidden, response{
+			Success: false,
+			Message: "forbidden",
+		})
+	}
+
+	window.CamperID = id
+
+	if err := h.camperRepo.CreateMaintenanceWindow(c.Request().Context(), window); err != nil {
+		logger.Errorf("Error creating maintenance window: %v", err)
+		if errors.Is(err, model.ErrInvalidMaintenanceWindow) {
+			return c.JSON(http.StatusBadRequest, response{
+				Success: false,
+				Message: err.Error(),
+			})
+		}
+
+		return c.JSON(http.StatusInternalServerError, response{
+			Success: false,
+			Message: "internal server error",
+		})
+	}
+
+	return c.JSON(http.StatusCreated, response{
+		Success: true,
+		Data:    window,
+	})
+}
+
+func (h *httpService) deleteMaintenanceWindowHandler(c echo.Context) error {
+	logger := logrus.WithField("context", utils.Dump(c))
+
+	id := c.Param("id")
+	windowID := c.Param("windowID")
+
+	session, err := authSession(c)
+	if err != nil {
+		logger.Errorf("Error getting session: %v", err)
+		return c.JSON(http.StatusUnauthorized, response{
+			Success: false,
+			Message: "unauthorized",
+		})
+	}
+
+	if session.IsCustomer() {
+		logger.Errorf("User is not authorized to access this resource")
+		return c.JSON(http.StatusForbidden, response{
+			Success: false,
+			Message: "forbidden",
+		})
+	}
+
+	if err := h.camperRepo.DeleteMaintenanceWindow(c.Request().Context(), id, windowID); err != nil {
+		logger.Errorf("Error deleting maintenance window: %v", err)
 		return c.JSON(http.StatusInternalServerError, response{
 			Success: false,
 			Message: "internal server error",
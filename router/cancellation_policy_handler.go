@@ -0,0 +1,229 @@
+package router
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/notblessy/rms/model"
+	"github.com/notblessy/rms/utils"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+func (h *httpService) findAllCancellationPoliciesHandler(c echo.Context) error {
+	logger := logrus.WithField("context", utils.Dump(c))
+
+	session, err := authSession(c)
+	if err != nil {
+		logger.Errorf("Error getting session: %v", err)
+		return c.JSON(http.StatusUnauthorized, response{
+			Success: false,
+			Message: "unauthorized",
+		})
+	}
+
+	if session.IsCustomer() {
+		logger.Errorf("User is not authorized to access this resource")
+		return c.JSON(http.StatusForbidden, response{
+			Success: false,
+			Message: "forbidden",
+		})
+	}
+
+	policies, err := h.cancellationPolicyRepo.FindAll(c.Request().Context())
+	if err != nil {
+		logger.Errorf("Error querying cancellation policies: %v", err)
+		return c.JSON(http.StatusInternalServerError, response{
+			Success: false,
+			Message: "internal server error",
+		})
+	}
+
+	return c.JSON(http.StatusOK, response{
+		Success: true,
+		Data:    policies,
+	})
+}
+
+func (h *httpService) findCancellationPolicyByIDHandler(c echo.Context) error {
+	logger := logrus.WithField("context", utils.Dump(c))
+
+	id := c.Param("id")
+
+	session, err := authSession(c)
+	if err != nil {
+		logger.Errorf("Error getting session: %v", err)
+		return c.JSON(http.StatusUnauthorized, response{
+			Success: false,
+			Message: "unauthorized",
+		})
+	}
+
+	if session.IsCustomer() {
+		logger.Errorf("User is not authorized to access this resource")
+		return c.JSON(http.StatusForbidden, response{
+			Success: false,
+			Message: "forbidden",
+		})
+	}
+
+	policy, err := h.cancellationPolicyRepo.FindByID(c.Request().Context(), id)
+	if err != nil {
+		logger.Errorf("Error querying cancellation policy: %v", err)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.JSON(http.StatusNotFound, response{
+				Success: false,
+				Message: "cancellation policy not found",
+			})
+		}
+
+		return c.JSON(http.StatusInternalServerError, response{
+			Success: false,
+			Message: "internal server error",
+		})
+	}
+
+	return c.JSON(http.StatusOK, response{
+		Success: true,
+		Data:    policy,
+	})
+}
+
+func (h *httpService) createCancellationPolicyHandler(c echo.Context) error {
+	logger := logrus.WithField("context", utils.Dump(c))
+
+	session, err := authSession(c)
+	if err != nil {
+		logger.Errorf("Error getting session: %v", err)
+		return c.JSON(http.StatusUnauthorized, response{
+			Success: false,
+			Message: "unauthorized",
+		})
+	}
+
+	if session.IsCustomer() {
+		logger.Errorf("User is not authorized to access this resource")
+		return c.JSON(http.StatusForbidden, response{
+			Success: false,
+			Message: "forbidden",
+		})
+	}
+
+	var policy model.CancellationPolicy
+	if err := c.Bind(&policy); err != nil {
+		logger.Errorf("Error parsing request: %v", err)
+		return c.JSON(http.StatusBadRequest, response{
+			Success: false,
+			Message: err.Error(),
+		})
+	}
+
+	policy, err = h.cancellationPolicyRepo.Create(c.Request().Context(), policy)
+	if err != nil {
+		logger.Errorf("Error creating cancellation policy: %v", err)
+		if errors.Is(err, model.ErrInvalidCancellationPolicy) {
+			return c.JSON(http.StatusBadRequest, response{
+				Success: false,
+				Message: err.Error(),
+			})
+		}
+
+		return c.JSON(http.StatusInternalServerError, response{
+			Success: false,
+			Message: "internal server error",
+		})
+	}
+
+	return c.JSON(http.StatusCreated, response{
+		Success: true,
+		Data:    policy,
+	})
+}
+
+func (h *httpService) updateCancellationPolicyHandler(c echo.Context) error {
+	logger := logrus.WithField("context", utils.Dump(c))
+
+	id := c.Param("id")
+
+	session, err := authSession(c)
+	if err != nil {
+		logger.Errorf("Error getting session: %v", err)
+		return c.JSON(http.StatusUnauthorized, response{
+			Success: false,
+			Message: "unauthorized",
+		})
+	}
+
+	if session.IsCustomer() {
+		logger.Errorf("User is not authorized to access this resource")
+		return c.JSON(http.StatusForbidden, response{
+			Success: false,
+			Message: "forbidden",
+		})
+	}
+
+	var policy model.CancellationPolicy
+	if err := c.Bind(&policy); err != nil {
+		logger.Errorf("Error parsing request: %v", err)
+		return c.JSON(http.StatusBadRequest, response{
+			Success: false,
+			Message: err.Error(),
+		})
+	}
+
+	if err := h.cancellationPolicyRepo.Update(c.Request().Context(), id, policy); err != nil {
+		logger.Errorf("Error updating cancellation policy: %v", err)
+		if errors.Is(err, model.ErrInvalidCancellationPolicy) {
+			return c.JSON(http.StatusBadRequest, response{
+				Success: false,
+				Message: err.Error(),
+			})
+		}
+
+		return c.JSON(http.StatusInternalServerError, response{
+			Success: false,
+			Message: "internal server error",
+		})
+	}
+
+	return c.JSON(http.StatusOK, response{
+		Success: true,
+		Data:    policy,
+	})
+}
+
+func (h *httpService) deleteCancellationPolicyHandler(c echo.Context) error {
+	logger := logrus.WithField("context", utils.Dump(c))
+
+	id := c.Param("id")
+
+	session, err := authSession(c)
+	if err != nil {
+		logger.Errorf("Error getting session: %v", err)
+		return c.JSON(http.StatusUnauthorized, response{
+			Success: false,
+			Message: "unauthorized",
+		})
+	}
+
+	if session.IsCustomer() {
+		logger.Errorf("User is not authorized to access this resource")
+		return c.JSON(http.StatusForbidden, response{
+			Success: false,
+			Message: "forbidden",
+		})
+	}
+
+	if err := h.cancellationPolicyRepo.Delete(c.Request().Context(), id); err != nil {
+		logger.Errorf("Error deleting cancellation policy: %v", err)
+		return c.JSON(http.StatusInternalServerError, response{
+			Success: false,
+			Message: "internal server error",
+		})
+	}
+
+	return c.JSON(http.StatusNoContent, response{
+		Success: true,
+	})
+}
@@ -9,6 +9,7 @@ import (
 
 	"github.com/golang-jwt/jwt/v4"
 	"github.com/labstack/echo/v4"
+	"github.com/notblessy/rms/model"
 	"github.com/sirupsen/logrus"
 )
 
@@ -23,6 +24,13 @@ type responseWithPaging struct {
 	PageSummary map[string]any `json:"page_summary"`
 }
 
+// responseWithFacets is a page of results with the facet counts of the
+// filters that produced it.
+type responseWithFacets struct {
+	responseWithPaging
+	Facets any `json:"facets"`
+}
+
 func withPaging(result any, total int64, page, size int) responseWithPaging {
 	offset := (page - 1) * size
 
@@ -57,6 +65,16 @@ func (j *jwtClaims) IsCustomer() bool {
 	return j.Role == "customer"
 }
 
+// Actor tells the rental state machine whether the session acts as a
+// customer or as staff.
+func (j *jwtClaims) Actor() string {
+	if j.IsCustomer() {
+		return model.ActorCustomer
+	}
+
+	return model.ActorStaff
+}
+
 type JWTMiddleware struct{}
 
 func NewJWTMiddleware() *JWTMiddleware {
@@ -0,0 +1,292 @@
+package router
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/notblessy/rms/model"
+	"github.com/notblessy/rms/utils"
+	"github.com/sirupsen/logrus"
+)
+
+type damageChargeResponse struct {
+	Report  model.DamageReport `json:"report"`
+	Payment model.Payment      `json:"payment"`
+}
+
+func (h *httpService) findRentalDamageReportsHandler(c echo.Context) error {
+	logger := logrus.WithField("context", utils.Dump(c))
+
+	id := c.Param("id")
+
+	session, err := authSession(c)
+	if err != nil {
+		logger.Errorf("Error getting session: %v", err)
+		return c.JSON(http.StatusUnauthorized, response{
+			Success: false,
+			Message: "unauthorized",
+		})
+	}
+
+	rental, err := h.rentalRepo.FindByID(c.Request().Context(), id)
+	if err != nil {
+		logger.Errorf("Error querying rental: %v", err)
+		return rentalErrorResponse(c, err)
+	}
+
+	if session.IsCustomer() && rental.CustomerID != session.ID {
+		logger.Errorf("User is not authorized to access this resource")
+		return c.JSON(http.StatusForbidden, response{
+			Success: false,
+			Message: "forbidden",
+		})
+	}
+
+	reports, err := h.damageReportRepo.FindByRentalID(c.Request().Context(), id)
+	if err != nil {
+		logger.Errorf("Error querying damage reports: %v", err)
+		return c.JSON(http.StatusInternalServerError, response{
+			Success: false,
+			Message: "internal server error",
+		})
+	}
+
+	return c.JSON(http.StatusOK, response{
+		Success: true,
+		Data:    reports,
+	})
+}
+
+func (h *httpService) createDamageReportHandler(c echo.Context) error {
+	logger := logrus.WithField("context", utils.Dump(c))
+
+	id := c.Param("id")
+
+	var input model.DamageReportInput
+	if err := c.Bind(&input); err != nil {
+		logger.Errorf("Error parsing request: %v", err)
+		return c.JSON(http.StatusBadRequest, response{
+			Success: false,
+			Message: err.Error(),
+		})
+	}
+
+	session, err := authSession(c)
+	if err != nil {
+		logger.Errorf("Error getting session: %v", err)
+		return c.JSON(http.StatusUnauthorized, response{
+			Success: false,
+			Message: "unauthorized",
+		})
+	}
+
+	if session.IsCustomer() {
+		logger.Errorf("User is not authorized to access this resource")
+		return c.JSON(http.StatusForbidden, response{
+			Success: false,
+			Message: "forbidden",
+		})
+	}
+
+	input.ActorID = session.ID
+
+	report, err := h.damageReportRepo.Create(c.Request().Context(), id, input)
+	if err != nil {
+		logger.Errorf("Error creating damage report: %v", err)
+		return damageReportErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, response{
+		Success: true,
+		Data:    report,
+	})
+}
+
+func (h *httpService) findDamageReportByIDHandler(c echo.Context) error {
+	logger := logrus.WithField("context", utils.Dump(c))
+
+	session, err := authSession(c)
+	if err != nil {
+		logger.Errorf("Error getting session: %v", err)
+		return c.JSON(http.StatusUnauthorized, response{
+			Success: false,
+			Message: "unauthorized",
+		})
+	}
+
+	if session.IsCustomer() {
+		logger.Errorf("User is not authorized to access this resource")
+		return c.JSON(http.StatusForbidden, response{
+			Success: false,
+			Message: "forbidden",
+		})
+	}
+
+	report, err := h.damageReportRepo.FindByID(c.Request().Context(), c.Param("id"))
+	if err != nil {
+		logger.Errorf("Error querying damage report: %v", err)
+		return damageReportErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, response{
+		Success: true,
+		Data:    report,
+	})
+}
+
+func (h *httpService) assessDamageReportHandler(c echo.Context) error {
+	logger := logrus.WithField("context", utils.Dump(c))
+
+	var input model.DamageAssessInput
+	if err := c.Bind(&input); err != nil {
+		logger.Errorf("Error parsing request: %v", err)
+		return c.JSON(http.StatusBadRequest, response{
+			Success: false,
+			Message: err.Error(),
+		})
+	}
+
+	session, err := authSession(c)
+	if err != nil {
+		logger.Errorf("Error getting session: %v", err)
+		return c.JSON(http.StatusUnauthorized, response{
+			Success: false,
+			Message: "unauthorized",
+		})
+	}
+
+	if session.IsCustomer() {
+		logger.Errorf("User is not authorized to access this resource")
+		return c.JSON(http.StatusForbidden, response{
+			Success: false,
+			Message: "forbidden",
+		})
+	}
+
+	report, err := h.damageReportRepo.Assess(c.Request().Context(), c.Param("id"), input)
+	if err != nil {
+		logger.Errorf("Error assessing damage report: %v", err)
+		return damageReportErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, response{
+		Success: true,
+		Data:    report,
+	})
+}
+
+// chargeDamageReportHandler bills an assessed damage to the rental and
+// submits the resulting charge to the payment gateway.
+func (h *httpService) chargeDamageReportHandler(c echo.Context) error {
+	logger := logrus.WithField("context", utils.Dump(c))
+
+	var input model.DamageChargeInput
+	if err := c.Bind(&input); err != nil {
+		logger.Errorf("Error parsing request: %v", err)
+		return c.JSON(http.StatusBadRequest, response{
+			Success: false,
+			Message: err.Error(),
+		})
+	}
+
+	session, err := authSession(c)
+	if err != nil {
+		logger.Errorf("Error getting session: %v", err)
+		return c.JSON(http.StatusUnauthorized, response{
+			Success: false,
+			Message: "unauthorized",
+		})
+	}
+
+	if session.IsCustomer() {
+		logger.Errorf("User is not authorized to access this resource")
+		return c.JSON(http.StatusForbidden, response{
+			Success: false,
+			Message: "forbidden",
+		})
+	}
+
+	input.ActorID = session.ID
+	input.Provider = h.paymentGateway.Name()
+
+	report, payment, err := h.damageReportRepo.Charge(c.Request().Context(), c.Param("id"), input)
+	if err != nil {
+		logger.Errorf("Error charging damage report: %v", err)
+		return damageReportErrorResponse(c, err)
+	}
+
+	h.issueDebitNote(c.Request().Context(), report.RentalID)
+
+	payment, err = h.submitPayment(c.Request().Context(), payment)
+	if err != nil {
+		logger.Errorf("Error submitting damage charge: %v", err)
+		return paymentErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, response{
+		Success: true,
+		Data: damageChargeResponse{
+			Report:  report,
+			Payment: payment,
+		},
+	})
+}
+
+func (h *httpService) repairDamageReportHandler(c echo.Context) error {
+	logger := logrus.WithField("context", utils.Dump(c))
+
+	var input model.DamageRepairInput
+	if err := c.Bind(&input); err != nil {
+		logger.Errorf("Error parsing request: %v", err)
+		return c.JSON(http.StatusBadRequest, response{
+			Success: false,
+			Message: err.Error(),
+		})
+	}
+
+	session, err := authSession(c)
+	if err != nil {
+		logger.Errorf("Error getting session: %v", err)
+		return c.JSON(http.StatusUnauthorized, response{
+			Success: false,
+			Message: "unauthorized",
+		})
+	}
+
+	if session.IsCustomer() {
+		logger.Errorf("User is not authorized to access this resource")
+		return c.JSON(http.StatusForbidden, response{
+			Success: false,
+			Message: "forbidden",
+		})
+	}
+
+	report, err := h.damageReportRepo.Repair(c.Request().Context(), c.Param("id"), input)
+	if err != nil {
+		logger.Errorf("Error repairing damage report: %v", err)
+		return damageReportErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, response{
+		Success: true,
+		Data:    report,
+	})
+}
+
+func damageReportErrorResponse(e echo.Context, err error) error {
+	switch {
+	case errors.Is(err, model.ErrInvalidDamageReport):
+		return e.JSON(http.StatusBadRequest, response{
+			Success: false,
+			Message: err.Error(),
+		})
+	case errors.Is(err, model.ErrInvalidDamageTransition):
+		return e.JSON(http.StatusConflict, response{
+			Success: false,
+			Message: err.Error(),
+		})
+	}
+
+	return rentalErrorResponse(e, err)
+}
@@ -0,0 +1,213 @@
+package router
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/notblessy/rms/model"
+	"github.com/notblessy/rms/utils"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+func (h *httpService) findAllEquipmentBundlesHandler(c echo.Context) error {
+	logger := logrus.WithField("context", utils.Dump(c))
+
+	_, err := authSession(c)
+	if err != nil {
+		logger.Errorf("Error getting session: %v", err)
+		return c.JSON(http.StatusUnauthorized, response{
+			Success: false,
+			Message: "unauthorized",
+		})
+	}
+
+	bundles, err := h.equipmentBundleRepo.FindAll(c.Request().Context())
+	if err != nil {
+		logger.Errorf("Error querying equipment bundles: %v", err)
+		return c.JSON(http.StatusInternalServerError, response{
+			Success: false,
+			Message: "internal server error",
+		})
+	}
+
+	return c.JSON(http.StatusOK, response{
+		Success: true,
+		Data:    bundles,
+	})
+}
+
+func (h *httpService) findEquipmentBundleByIDHandler(c echo.Context) error {
+	logger := logrus.WithField("context", utils.Dump(c))
+
+	id := c.Param("id")
+
+	_, err := authSession(c)
+	if err != nil {
+		logger.Errorf("Error getting session: %v", err)
+		return c.JSON(http.StatusUnauthorized, response{
+			Success: false,
+			Message: "unauthorized",
+		})
+	}
+
+	bundle, err := h.equipmentBundleRepo.FindByID(c.Request().Context(), id)
+	if err != nil {
+		logger.Errorf("Error querying equipment bundle: %v", err)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.JSON(http.StatusNotFound, response{
+				Success: false,
+				Message: "equipment bundle not found",
+			})
+		}
+
+		return c.JSON(http.StatusInternalServerError, response{
+			Success: false,
+			Message: "internal server error",
+		})
+	}
+
+	return c.JSON(http.StatusOK, response{
+		Success: true,
+		Data:    bundle,
+	})
+}
+
+func (h *httpService) createEquipmentBundleHandler(c echo.Context) error {
+	logger := logrus.WithField("context", utils.Dump(c))
+
+	session, err := authSession(c)
+	if err != nil {
+		logger.Errorf("Error getting session: %v", err)
+		return c.JSON(http.StatusUnauthorized, response{
+			Success: false,
+			Message: "unauthorized",
+		})
+	}
+
+	if session.IsCustomer() {
+		logger.Errorf("User is not authorized to access this resource")
+		return c.JSON(http.StatusForbidden, response{
+			Success: false,
+			Message: "forbidden",
+		})
+	}
+
+	var bundle model.EquipmentBundle
+	if err := c.Bind(&bundle); err != nil {
+		logger.Errorf("Error parsing request: %v", err)
+		return c.JSON(http.StatusBadRequest, response{
+			Success: false,
+			Message: err.Error(),
+		})
+	}
+
+	bundle, err = h.equipmentBundleRepo.Create(c.Request().Context(), bundle)
+	if err != nil {
+		logger.Errorf("Error creating equipment bundle: %v", err)
+		if errors.Is(err, model.ErrInvalidEquipmentBundle) || errors.Is(err, model.ErrEquipmentNotFound) {
+			return c.JSON(http.StatusBadRequest, response{
+				Success: false,
+				Message: err.Error(),
+			})
+		}
+
+		return c.JSON(http.StatusInternalServerError, response{
+			Success: false,
+			Message: "internal server error",
+		})
+	}
+
+	return c.JSON(http.StatusCreated, response{
+		Success: true,
+		Data:    bundle,
+	})
+}
+
+func (h *httpService) updateEquipmentBundleHandler(c echo.Context) error {
+	logger := logrus.WithField("context", utils.Dump(c))
+
+	id := c.Param("id")
+
+	session, err := authSession(c)
+	if err != nil {
+		logger.Errorf("Error getting session: %v", err)
+		return c.JSON(http.StatusUnauthorized, response{
+			Success: false,
+			Message: "unauthorized",
+		})
+	}
+
+	if session.IsCustomer() {
+		logger.Errorf("User is not authorized to access this resource")
+		return c.JSON(http.StatusForbidden, response{
+			Success: false,
+			Message: "forbidden",
+		})
+	}
+
+	var bundle model.EquipmentBundle
+	if err := c.Bind(&bundle); err != nil {
+		logger.Errorf("Error parsing request: %v", err)
+		return c.JSON(http.StatusBadRequest, response{
+			Success: false,
+			Message: err.Error(),
+		})
+	}
+
+	if err := h.equipmentBundleRepo.Update(c.Request().Context(), id, bundle); err != nil {
+		logger.Errorf("Error updating equipment bundle: %v", err)
+		if errors.Is(err, model.ErrInvalidEquipmentBundle) || errors.Is(err, model.ErrEquipmentNotFound) {
+			return c.JSON(http.StatusBadRequest, response{
+				Success: false,
+				Message: err.Error(),
+			})
+		}
+
+		return c.JSON(http.StatusInternalServerError, response{
+			Success: false,
+			Message: "internal server error",
+		})
+	}
+
+	return c.JSON(http.StatusOK, response{
+		Success: true,
+		Data:    bundle,
+	})
+}
+
+func (h *httpService) deleteEquipmentBundleHandler(c echo.Context) error {
+	logger := logrus.WithField("context", utils.Dump(c))
+
+	id := c.Param("id")
+
+	session, err := authSession(c)
+	if err != nil {
+		logger.Errorf("Error getting session: %v", err)
+		return c.JSON(http.StatusUnauthorized, response{
+			Success: false,
+			Message: "unauthorized",
+		})
+	}
+
+	if session.IsCustomer() {
+		logger.Errorf("User is not authorized to access this resource")
+		return c.JSON(http.StatusForbidden, response{
+			Success: false,
+			Message: "forbidden",
+		})
+	}
+
+	if err := h.equipmentBundleRepo.Delete(c.Request().Context(), id); err != nil {
+		logger.Errorf("Error deleting equipment bundle: %v", err)
+		return c.JSON(http.StatusInternalServerError, response{
+			Success: false,
+			Message: "internal server error",
+		})
+	}
+
+	return c.JSON(http.StatusNoContent, response{
+		Success: true,
+	})
+}
@@ -1,12 +1,15 @@
 package router
 
 import (
+	"errors"
 	"net/http"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/notblessy/rms/model"
 	"github.com/notblessy/rms/utils"
 	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
 )
 
 func (h *httpService) findEquipmentByIDHandler(c echo.Context) error {
@@ -119,12 +122,10 @@ func (h *httpService) createEquipmentHandler(c echo.Context) error {
 		})
 	}
 
-	if err := h.equipmentRepo.Create(c.Request().Context(), equipment); err != nil {
+	equipment, err = h.equipmentRepo.Create(c.Request().Context(), equipment, session.ID)
+	if err != nil {
 		logger.Errorf("Error creating equipment: %v", err)
-		return c.JSON(http.StatusInternalServerError, response{
-			Success: false,
-			Message: "internal server error",
-		})
+		return stockMovementErrorResponse(c, err)
 	}
 
 	return c.JSON(http.StatusCreated, response{
@@ -212,3 +213,174 @@ func (h *httpService) deleteEquipmentHandler(c echo.Context) error {
 		Success: true,
 	})
 }
+
+func (h *httpService) findEquipmentStockHandler(c echo.Context) error {
+	logger := logrus.WithField("context", utils.Dump(c))
+
+	id := c.Param("id")
+
+	session, err := authSession(c)
+	if err != nil {
+		logger.Errorf("Error getting session: %v", err)
+		return c.JSON(http.StatusUnauthorized, response{
+			Success: false,
+			Message: "unauthorized",
+		})
+	}
+
+	if session.IsCustomer() {
+		logger.Errorf("User is not authorized to access this resource")
+		return c.JSON(http.StatusForbidden, response{
+			Success: false,
+			Message: "forbidden",
+		})
+	}
+
+	var query model.AvailabilityQueryInput
+	if err := c.Bind(&query); err != nil {
+		logger.Errorf("Error parsing request: %v", err)
+		return c.JSON(http.StatusBadRequest, response{
+			Success: false,
+			Message: err.Error(),
+		})
+	}
+
+	from, to, err := query.Period(time.Now())
+	if err != nil {
+		logger.Errorf("Error parsing availability range: %v", err)
+		return c.JSON(http.StatusBadRequest, response{
+			Success: false,
+			Message: err.Error(),
+		})
+	}
+
+	stock, err := h.equipmentRepo.Stock(c.Request().Context(), []string{id}, from, to)
+	if err != nil {
+		logger.Errorf("Error getting equipment stock: %v", err)
+		return c.JSON(http.StatusInternalServerError, response{
+			Success: false,
+			Message: "internal server error",
+		})
+	}
+
+	if len(stock) == 0 {
+		return c.JSON(http.StatusNotFound, response{
+			Success: false,
+			Message: "equipment not found",
+		})
+	}
+
+	return c.JSON(http.StatusOK, response{
+		Success: true,
+		Data:    stock[0],
+	})
+}
+
+func (h *httpService) findEquipmentMovementsHandler(c echo.Context) error {
+	logger := logrus.WithField("context", utils.Dump(c))
+
+	id := c.Param("id")
+
+	session, err := authSession(c)
+	if err != nil {
+		logger.Errorf("Error getting session: %v", err)
+		return c.JSON(http.StatusUnauthorized, response{
+			Success: false,
+			Message: "unauthorized",
+		})
+	}
+
+	if session.IsCustomer() {
+		logger.Errorf("User is not authorized to access this resource")
+		return c.JSON(http.StatusForbidden, response{
+			Success: false,
+			Message: "forbidden",
+		})
+	}
+
+	movements, err := h.equipmentRepo.FindMovements(c.Request().Context(), id)
+	if err != nil {
+		logger.Errorf("Error querying stock movements: %v", err)
+		return c.JSON(http.StatusInternalServerError, response{
+			Success: false,
+			Message: "internal server error",
+		})
+	}
+
+	return c.JSON(http.StatusOK, response{
+		Success: true,
+		Data:    movements,
+	})
+}
+
+func (h *httpService) createEquipmentMovementHandler(c echo.Context) error {
+	logger := logrus.WithField("context", utils.Dump(c))
+
+	id := c.Param("id")
+
+	var input model.StockMovementInput
+	if err := c.Bind(&input); err != nil {
+		logger.Errorf("Error parsing request: %v", err)
+		return c.JSON(http.StatusBadRequest, response{
+			Success: false,
+			Message: err.Error(),
+		})
+	}
+
+	session, err := authSession(c)
+	if err != nil {
+		logger.Errorf("Error getting session: %v", err)
+		return c.JSON(http.StatusUnauthorized, response{
+			Success: false,
+			Message: "unauthorized",
+		})
+	}
+
+	if session.IsCustomer() {
+		logger.Errorf("User is not authorized to access this resource")
+		return c.JSON(http.StatusForbidden, response{
+			Success: false,
+			Message: "forbidden",
+		})
+	}
+
+	input.ActorID = session.ID
+
+	movement, err := h.equipmentRepo.RecordMovement(c.Request().Context(), id, input)
+	if err != nil {
+		logger.Errorf("Error recording stock movement: %v", err)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.JSON(http.StatusNotFound, response{
+				Success: false,
+				Message: "equipment not found",
+			})
+		}
+
+		return stockMovementErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, response{
+		Success: true,
+		Data:    movement,
+	})
+}
+
+func stockMovementErrorResponse(e echo.Context, err error) error {
+	switch {
+	case errors.Is(err, model.ErrInvalidStockMovement):
+		return e.JSON(http.StatusBadRequest, response{
+			Success: false,
+			Message: err.Error(),
+		})
+	case errors.Is(err, model.ErrInsufficientStock):
+		return e.JSON(http.StatusConflict, response{
+			Success: false,
+			Message: err.Error(),
+		})
+	}
+
+	return e.JSON(http.StatusInternalServerError, response{
+		Success: false,
+		Message: "internal server error",
+	})
+}
@@ -0,0 +1,361 @@
+package router
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/notblessy/rms/label"
+	"github.com/notblessy/rms/model"
+	"github.com/notblessy/rms/utils"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+func (h *httpService) findEquipmentUnitsHandler(c echo.Context) error {
+	logger := logrus.WithField("context", utils.Dump(c))
+
+	session, err := authSession(c)
+	if err != nil {
+		logger.Errorf("Error getting session: %v", err)
+		return c.JSON(http.StatusUnauthorized, response{
+			Success: false,
+			Message: "unauthorized",
+		})
+	}
+
+	if session.IsCustomer() {
+		logger.Errorf("User is not authorized to access this resource")
+		return c.JSON(http.StatusForbidden, response{
+			Success: false,
+			Message: "forbidden",
+		})
+	}
+
+	units, err := h.equipmentUnitRepo.FindByEquipmentID(c.Request().Context(), c.Param("id"))
+	if err != nil {
+		logger.Errorf("Error querying equipment units: %v", err)
+		return c.JSON(http.StatusInternalServerError, response{
+			Success: false,
+			Message: "internal server error",
+		})
+	}
+
+	return c.JSON(http.StatusOK, response{
+		Success: true,
+		Data:    units,
+	})
+}
+
+func (h *httpService) findEquipmentUnitByIDHandler(c echo.Context) error {
+	logger := logrus.WithField("context", utils.Dump(c))
+
+	session, err := authSession(c)
+	if err != nil {
+		logger.Errorf("Error getting session: %v", err)
+		return c.JSON(http.StatusUnauthorized, response{
+			Success: false,
+			Message: "unauthorized",
+		})
+	}
+
+	if session.IsCustomer() {
+		logger.Errorf("User is not authorized to access this resource")
+		return c.JSON(http.StatusForbidden, response{
+			Success: false,
+			Message: "forbidden",
+		})
+	}
+
+	unit, err := h.equipmentUnitRepo.FindByID(c.Request().Context(), c.Param("id"), c.Param("unit"))
+	if err != nil {
+		logger.Errorf("Error querying equipment unit: %v", err)
+		return equipmentUnitErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, response{
+		Success: true,
+		Data:    unit,
+	})
+}
+
+func (h *httpService) createEquipmentUnitHandler(c echo.Context) error {
+	logger := logrus.WithField("context", utils.Dump(c))
+
+	var input model.EquipmentUnitInput
+	if err := c.Bind(&input); err != nil {
+		logger.Errorf("Error parsing request: %v", err)
+		return c.JSON(http.StatusBadRequest, response{
+			Success: false,
+			Message: err.Error(),
+		})
+	}
+
+	session, err := authSession(c)
+	if err != nil {
+		logger.Errorf("Error getting session: %v", err)
+		return c.JSON(http.StatusUnauthorized, response{
+			Success: false,
+			Message: "unauthorized",
+		})
+	}
+
+	if session.IsCustomer() {
+		logger.Errorf("User is not authorized to access this resource")
+		return c.JSON(http.StatusForbidden, response{
+			Success: false,
+			Message: "forbidden",
+		})
+	}
+
+	unit, err := h.equipmentUnitRepo.Create(c.Request().Context(), c.Param("id"), input)
+	if err != nil {
+		logger.Errorf("Error creating equipment unit: %v", err)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.JSON(http.StatusNotFound, response{
+				Success: false,
+				Message: "equipment not found",
+			})
+		}
+
+		return equipmentUnitErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, response{
+		Success: true,
+		Data:    unit,
+	})
+}
+
+func (h *httpService) updateEquipmentUnitHandler(c echo.Context) error {
+	logger := logrus.WithField("context", utils.Dump(c))
+
+	var input model.EquipmentUnitInput
+	if err := c.Bind(&input); err != nil {
+		logger.Errorf("Error parsing request: %v", err)
+		return c.JSON(http.StatusBadRequest, response{
+			Success: false,
+			Message: err.Error(),
+		})
+	}
+
+	session, err := authSession(c)
+	if err != nil {
+		logger.Errorf("Error getting session: %v", err)
+		return c.JSON(http.StatusUnauthorized, response{
+			Success: false,
+			Message: "unauthorized",
+		})
+	}
+
+	if session.IsCustomer() {
+		logger.Errorf("User is not authorized to access this resource")
+		return c.JSON(http.StatusForbidden, response{
+			Success: false,
+			Message: "forbidden",
+		})
+	}
+
+	unit, err := h.equipmentUnitRepo.Update(c.Request().Context(), c.Param("id"), c.Param("unit"), input)
+	if err != nil {
+		logger.Errorf("Error updating equipment unit: %v", err)
+		return equipmentUnitErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, response{
+		Success: true,
+		Data:    unit,
+	})
+}
+
+func (h *httpService) deleteEquipmentUnitHandler(c echo.Context) error {
+	logger := logrus.WithField("context", utils.Dump(c))
+
+	session, err := authSession(c)
+	if err != nil {
+		logger.Errorf("Error getting session: %v", err)
+		return c.JSON(http.StatusUnauthorized, response{
+			Success: false,
+			Message: "unauthorized",
+		})
+	}
+
+	if session.IsCustomer() {
+		logger.Errorf("User is not authorized to access this resource")
+		return c.JSON(http.StatusForbidden, response{
+			Success: false,
+			Message: "forbidden",
+		})
+	}
+
+	err = h.equipmentUnitRepo.Delete(c.Request().Context(), c.Param("id"), c.Param("unit"))
+	if err != nil {
+		logger.Errorf("Error deleting equipment unit: %v", err)
+		return equipmentUnitErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusNoContent, response{
+		Success: true,
+	})
+}
+
+// equipmentUnitLabelHandler renders the QR label stuck on a unit so the
+// depot can scan it at check-out and check-in. The optional size query
+// parameter sets the width in pixels.
+func (h *httpService) equipmentUnitLabelHandler(c echo.Context) error {
+	logger := logrus.WithField("context", utils.Dump(c))
+
+	session, err := authSession(c)
+	if err != nil {
+		logger.Errorf("Error getting session: %v", err)
+		return c.JSON(http.StatusUnauthorized, response{
+			Success: false,
+			Message: "unauthorized",
+		})
+	}
+
+	if session.IsCustomer() {
+		logger.Errorf("User is not authorized to access this resource")
+		return c.JSON(http.StatusForbidden, response{
+			Success: false,
+			Message: "forbidden",
+		})
+	}
+
+	size := label.DefaultSize
+	if raw := c.QueryParam("size"); raw != "" {
+		size, err = strconv.Atoi(raw)
+		if err != nil || size < 64 || size > 2048 {
+			logger.Errorf("Invalid label size: %s", raw)
+			return c.JSON(http.StatusBadRequest, response{
+				Success: false,
+				Message: "size must be between 64 and 2048",
+			})
+		}
+	}
+
+	unit, err := h.equipmentUnitRepo.FindByID(c.Request().Context(), c.Param("id"), c.Param("unit"))
+	if err != nil {
+		logger.Errorf("Error querying equipment unit: %v", err)
+		return equipmentUnitErrorResponse(c, err)
+	}
+
+	var buf bytes.Buffer
+	if err := label.RenderQR(&buf, unit, size); err != nil {
+		logger.Errorf("Error rendering equipment unit label: %v", err)
+		return c.JSON(http.StatusInternalServerError, response{
+			Success: false,
+			Message: "internal server error",
+		})
+	}
+
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf(`inline; filename="%s.png"`, unit.AssetTag))
+	return c.Blob(http.StatusOK, "image/png", buf.Bytes())
+}
+
+func (h *httpService) findRentalEquipmentUnitsHandler(c echo.Context) error {
+	logger := logrus.WithField("context", utils.Dump(c))
+
+	session, err := authSession(c)
+	if err != nil {
+		logger.Errorf("Error getting session: %v", err)
+		return c.JSON(http.StatusUnauthorized, response{
+			Success: false,
+			Message: "unauthorized",
+		})
+	}
+
+	if session.IsCustomer() {
+		logger.Errorf("User is not authorized to access this resource")
+		return c.JSON(http.StatusForbidden, response{
+			Success: false,
+			Message: "forbidden",
+		})
+	}
+
+	assignments, err := h.equipmentUnitRepo.FindByRentalID(c.Request().Context(), c.Param("id"))
+	if err != nil {
+		logger.Errorf("Error querying rental equipment units: %v", err)
+		return c.JSON(http.StatusInternalServerError, response{
+			Success: false,
+			Message: "internal server error",
+		})
+	}
+
+	return c.JSON(http.StatusOK, response{
+		Success: true,
+		Data:    assignments,
+	})
+}
+
+// assignRentalEquipmentUnitsHandler records which physical units go out on
+// a rental's equipment, bundled or not, at check-out.
+func (h *httpService) assignRentalEquipmentUnitsHandler(c echo.Context) error {
+	logger := logrus.WithField("context", utils.Dump(c))
+
+	var input model.EquipmentUnitAssignInput
+	if err := c.Bind(&input); err != nil {
+		logger.Errorf("Error parsing request: %v", err)
+		return c.JSON(http.StatusBadRequest, response{
+			Success: false,
+			Message: err.Error(),
+		})
+	}
+
+	session, err := authSession(c)
+	if err != nil {
+		logger.Errorf("Error getting session: %v", err)
+		return c.JSON(http.StatusUnauthorized, response{
+			Success: false,
+			Message: "unauthorized",
+		})
+	}
+
+	if session.IsCustomer() {
+		logger.Errorf("User is not authorized to access this resource")
+		return c.JSON(http.StatusForbidden, response{
+			Success: false,
+			Message: "forbidden",
+		})
+	}
+
+	input.ActorID = session.ID
+
+	assignments, err := h.equipmentUnitRepo.Assign(c.Request().Context(), c.Param("id"), input)
+	if err != nil {
+		logger.Errorf("Error assigning equipment units: %v", err)
+		return equipmentUnitErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, response{
+		Success: true,
+		Data:    assignments,
+	})
+}
+
+func equipmentUnitErrorResponse(e echo.Context, err error) error {
+	switch {
+	case errors.Is(err, model.ErrInvalidEquipmentUnit),
+		errors.Is(err, model.ErrInvalidUnitAssignment):
+		return e.JSON(http.StatusBadRequest, response{
+			Success: false,
+			Message: err.Error(),
+		})
+	case errors.Is(err, model.ErrEquipmentUnitNotFound):
+		return e.JSON(http.StatusNotFound, response{
+			Success: false,
+			Message: err.Error(),
+		})
+	case errors.Is(err, model.ErrEquipmentUnitUnavailable),
+		errors.Is(err, model.ErrUnitAssignmentNotAllowed):
+		return e.JSON(http.StatusConflict, response{
+			Success: false,
+			Message: err.Error(),
+		})
+	}
+
+	return rentalErrorResponse(e, err)
+}
@@ -0,0 +1,159 @@
+package router
+
+import (
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/notblessy/rms/model"
+	"github.com/notblessy/rms/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// uploadEquipmentImageHandler replaces the equipment's image with the file
+// sent as the multipart "image" field.
+func (h *httpService) uploadEquipmentImageHandler(c echo.Context) error {
+	logger := logrus.WithField("context", utils.Dump(c))
+
+	session, err := authSession(c)
+	if err != nil {
+		logger.Errorf("Error getting session: %v", err)
+		return c.JSON(http.StatusUnauthorized, response{
+			Success: false,
+			Message: "unauthorized",
+		})
+	}
+
+	if session.IsCustomer() {
+		logger.Errorf("User is not authorized to access this resource")
+		return c.JSON(http.StatusForbidden, response{
+			Success: false,
+			Message: "forbidden",
+		})
+	}
+
+	file, err := c.FormFile("image")
+	if err != nil {
+		logger.Errorf("Error parsing request: %v", err)
+		return c.JSON(http.StatusBadRequest, response{
+			Success: false,
+			Message: err.Error(),
+		})
+	}
+
+	upload, err := readImageUpload(file, session.ID)
+	if err != nil {
+		logger.Errorf("Error reading uploaded image: %v", err)
+		return imageErrorResponse(c, err)
+	}
+
+	equipment, err := h.equipmentRepo.UploadImage(c.Request().Context(), c.Param("id"), upload)
+	if err != nil {
+		logger.Errorf("Error uploading equipment image: %v", err)
+		return imageErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, response{
+		Success: true,
+		Data:    equipment,
+	})
+}
+
+// uploadDriverPhotoHandler replaces the driver's photo with the file sent as
+// the multipart "photo" field.
+func (h *httpService) uploadDriverPhotoHandler(c echo.Context) error {
+	logger := logrus.WithField("context", utils.Dump(c))
+
+	session, err := authSession(c)
+	if err != nil {
+		logger.Errorf("Error getting session: %v", err)
+		return c.JSON(http.StatusUnauthorized, response{
+			Success: false,
+			Message: "unauthorized",
+		})
+	}
+
+	if session.IsCustomer() {
+		logger.Errorf("User is not authorized to access this resource")
+		return c.JSON(http.StatusForbidden, response{
+			Success: false,
+			Message: "forbidden",
+		})
+	}
+
+	file, err := c.FormFile("photo")
+	if err != nil {
+		logger.Errorf("Error parsing request: %v", err)
+		return c.JSON(http.StatusBadRequest, response{
+			Success: false,
+			Message: err.Error(),
+		})
+	}
+
+	upload, err := readImageUpload(file, session.ID)
+	if err != nil {
+		logger.Errorf("Error reading uploaded photo: %v", err)
+		return imageErrorResponse(c, err)
+	}
+
+	driver, err := h.driverRepo.UploadPhoto(c.Request().Context(), c.Param("id"), upload)
+	if err != nil {
+		logger.Errorf("Error uploading driver photo: %v", err)
+		return imageErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, response{
+		Success: true,
+		Data:    driver,
+	})
+}
+
+// readImageUpload reads an uploaded file into memory, refusing files over
+// model.MaxImageSize before reading them.
+func readImageUpload(file *multipart.FileHeader, actorID string) (model.ImageUpload, error) {
+	if file.Size > model.MaxImageSize {
+		return model.ImageUpload{}, model.ErrImageTooLarge
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return model.ImageUpload{}, err
+	}
+	defer src.Close()
+
+	content, err := io.ReadAll(io.LimitReader(src, model.MaxImageSize+1))
+	if err != nil {
+		return model.ImageUpload{}, err
+	}
+
+	return model.ImageUpload{
+		Filename: file.Filename,
+		Content:  content,
+		ActorID:  actorID,
+	}, nil
+}
+
+func imageErrorResponse(e echo.Context, err error) error {
+	switch {
+	case errors.Is(err, model.ErrInvalidImage),
+		errors.Is(err, model.ErrInvalidCamperImageOrder):
+		return e.JSON(http.StatusBadRequest, response{
+			Success: false,
+			Message: err.Error(),
+		})
+	case errors.Is(err, model.ErrImageTooLarge):
+		return e.JSON(http.StatusRequestEntityTooLarge, response{
+			Success: false,
+			Message: err.Error(),
+		})
+	case errors.Is(err, model.ErrCamperImageNotFound):
+		return e.JSON(http.StatusNotFound, response{
+			Success: false,
+			Message: err.Error(),
+		})
+	}
+
+	return rentalErrorResponse(e, err)
+}
@@ -0,0 +1,97 @@
+package router
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/notblessy/rms/model"
+	"github.com/notblessy/rms/utils"
+	"github.com/sirupsen/logrus"
+)
+
+func (h *httpService) findRentalInspectionsHandler(c echo.Context) error {
+	logger := logrus.WithField("context", utils.Dump(c))
+
+	id := c.Param("id")
+
+	session, err := authSession(c)
+	if err != nil {
+		logger.Errorf("Error getting session: %v", err)
+		return c.JSON(http.StatusUnauthorized, response{
+			Success: false,
+			Message: "unauthorized",
+		})
+	}
+
+	rental, err := h.rentalRepo.FindByID(c.Request().Context(), id)
+	if err != nil {
+		logger.Errorf("Error querying rental: %v", err)
+		return rentalErrorResponse(c, err)
+	}
+
+	if session.IsCustomer() && rental.CustomerID != session.ID {
+		logger.Errorf("User is not authorized to access this resource")
+		return c.JSON(http.StatusForbidden, response{
+			Success: false,
+			Message: "forbidden",
+		})
+	}
+
+	report, err := h.inspectionRepo.FindByRentalID(c.Request().Context(), id)
+	if err != nil {
+		logger.Errorf("Error querying inspections: %v", err)
+		return rentalErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, response{
+		Success: true,
+		Data:    report,
+	})
+}
+
+func (h *httpService) createRentalInspectionHandler(c echo.Context) error {
+	logger := logrus.WithField("context", utils.Dump(c))
+
+	id := c.Param("id")
+
+	var input model.RentalInspectionInput
+	if err := c.Bind(&input); err != nil {
+		logger.Errorf("Error parsing request: %v", err)
+		return c.JSON(http.StatusBadRequest, response{
+			Success: false,
+			Message: err.Error(),
+		})
+	}
+
+	session, err := authSession(c)
+	if err != nil {
+		logger.Errorf("Error getting session: %v", err)
+		return c.JSON(http.StatusUnauthorized, response{
+			Success: false,
+			Message: "unauthorized",
+		})
+	}
+
+	if session.IsCustomer() {
+		logger.Errorf("User is not authorized to access this resource")
+		return c.JSON(http.StatusForbidden, response{
+			Success: false,
+			Message: "forbidden",
+		})
+	}
+
+	input.ActorID = session.ID
+
+	inspection, err := h.inspectionRepo.Create(c.Request().Context(), id, input)
+	if err != nil {
+		logger.Errorf("Error creating inspection: %v", err)
+		return rentalErrorResponse(c, err)
+	}
+
+	h.issueDebitNote(c.Request().Context(), id)
+
+	return c.JSON(http.StatusCreated, response{
+		Success: true,
+		Data:    inspection,
+	})
+}
@@ -0,0 +1,154 @@
+package router
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/notblessy/rms/invoice"
+	"github.com/notblessy/rms/model"
+	"github.com/notblessy/rms/utils"
+	"github.com/sirupsen/logrus"
+)
+
+func (h *httpService) rentalInvoicePDFHandler(c echo.Context) error {
+	logger := logrus.WithField("context", utils.Dump(c))
+
+	id := c.Param("id")
+
+	session, err := authSession(c)
+	if err != nil {
+		logger.Errorf("Error getting session: %v", err)
+		return c.JSON(http.StatusUnauthorized, response{
+			Success: false,
+			Message: "unauthorized",
+		})
+	}
+
+	rental, err := h.rentalRepo.FindByID(c.Request().Context(), id)
+	if err != nil {
+		logger.Errorf("Error querying rental: %v", err)
+		return rentalErrorResponse(c, err)
+	}
+
+	if session.IsCustomer() && rental.CustomerID != session.ID {
+		logger.Errorf("User is not authorized to access this resource")
+		return c.JSON(http.StatusForbidden, response{
+			Success: false,
+			Message: "forbidden",
+		})
+	}
+
+	issued, err := h.invoiceRepo.IssueInvoice(c.Request().Context(), id)
+	if err != nil {
+		logger.Errorf("Error issuing invoice: %v", err)
+		return rentalErrorResponse(c, err)
+	}
+
+	return renderInvoicePDF(c, issued)
+}
+
+func (h *httpService) findRentalInvoicesHandler(c echo.Context) error {
+	logger := logrus.WithField("context", utils.Dump(c))
+
+	id := c.Param("id")
+
+	session, err := authSession(c)
+	if err != nil {
+		logger.Errorf("Error getting session: %v", err)
+		return c.JSON(http.StatusUnauthorized, response{
+			Success: false,
+			Message: "unauthorized",
+		})
+	}
+
+	rental, err := h.rentalRepo.FindByID(c.Request().Context(), id)
+	if err != nil {
+		logger.Errorf("Error querying rental: %v", err)
+		return rentalErrorResponse(c, err)
+	}
+
+	if session.IsCustomer() && rental.CustomerID != session.ID {
+		logger.Errorf("User is not authorized to access this resource")
+		return c.JSON(http.StatusForbidden, response{
+			Success: false,
+			Message: "forbidden",
+		})
+	}
+
+	invoices, err := h.invoiceRepo.FindByRentalID(c.Request().Context(), id)
+	if err != nil {
+		logger.Errorf("Error querying invoices: %v", err)
+		return c.JSON(http.StatusInternalServerError, response{
+			Success: false,
+			Message: "internal server error",
+		})
+	}
+
+	return c.JSON(http.StatusOK, response{
+		Success: true,
+		Data:    invoices,
+	})
+}
+
+func (h *httpService) invoicePDFHandler(c echo.Context) error {
+	logger := logrus.WithField("context", utils.Dump(c))
+
+	id := c.Param("id")
+	invoiceID := c.Param("invoiceID")
+
+	session, err := authSession(c)
+	if err != nil {
+		logger.Errorf("Error getting session: %v", err)
+		return c.JSON(http.StatusUnauthorized, response{
+			Success: false,
+			Message: "unauthorized",
+		})
+	}
+
+	issued, err := h.invoiceRepo.FindByID(c.Request().Context(), invoiceID)
+	if err != nil || issued.RentalID != id {
+		logger.Errorf("Error querying invoice: %v", err)
+		return c.JSON(http.StatusNotFound, response{
+			Success: false,
+			Message: "invoice not found",
+		})
+	}
+
+	if session.IsCustomer() && issued.Document.Customer.ID != session.ID {
+		logger.Errorf("User is not authorized to access this resource")
+		return c.JSON(http.StatusForbidden, response{
+			Success: false,
+			Message: "forbidden",
+		})
+	}
+
+	return renderInvoicePDF(c, issued)
+}
+
+func renderInvoicePDF(c echo.Context, issued model.Invoice) error {
+	var buf bytes.Buffer
+	if err := invoice.RenderPDF(&buf, issued); err != nil {
+		logrus.WithField("invoice", issued.ID).Errorf("Error rendering invoice: %v", err)
+		return c.JSON(http.StatusInternalServerError, response{
+			Success: false,
+			Message: "internal server error",
+		})
+	}
+
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf(`inline; filename="%s.pdf"`, issued.Number))
+	return c.Blob(http.StatusOK, "application/pdf", buf.Bytes())
+}
+
+// issueDebitNote bills the charges just added to an invoiced rental. The
+// change that added them is already saved, so a failure is only logged; the
+// charges are picked up by the next debit note.
+func (h *httpService) issueDebitNote(ctx context.Context, rentalID string) {
+	_, err := h.invoiceRepo.IssueDebitNote(ctx, rentalID)
+	if err != nil && !errors.Is(err, model.ErrNothingToDebit) {
+		logrus.WithField("rental_id", rentalID).Errorf("Error issuing debit note: %v", err)
+	}
+}
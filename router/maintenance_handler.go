@@ -0,0 +1,217 @@
+package router
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/notblessy/rms/model"
+	"github.com/notblessy/rms/utils"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+func (h *httpService) findCamperServicesHandler(c echo.Context) error {
+	logger := logrus.WithField("context", utils.Dump(c))
+
+	session, err := authSession(c)
+	if err != nil {
+		logger.Errorf("Error getting session: %v", err)
+		return c.JSON(http.StatusUnauthorized, response{
+			Success: false,
+			Message: "unauthorized",
+		})
+	}
+
+	if session.IsCustomer() {
+		logger.Errorf("User is not authorized to access this resource")
+		return c.JSON(http.StatusForbidden, response{
+			Success: false,
+			Message: "forbidden",
+		})
+	}
+
+	records, err := h.maintenanceRepo.FindByCamperID(c.Request().Context(), c.Param("id"))
+	if err != nil {
+		logger.Errorf("Error querying service records: %v", err)
+		return c.JSON(http.StatusInternalServerError, response{
+			Success: false,
+			Message: "internal server error",
+		})
+	}
+
+	return c.JSON(http.StatusOK, response{
+		Success: true,
+		Data:    records,
+	})
+}
+
+func (h *httpService) scheduleCamperServiceHandler(c echo.Context) error {
+	logger := logrus.WithField("context", utils.Dump(c))
+
+	var input model.ServiceRecordInput
+	if err := c.Bind(&input); err != nil {
+		logger.Errorf("Error parsing request: %v", err)
+		return c.JSON(http.StatusBadRequest, response{
+			Success: false,
+			Message: err.Error(),
+		})
+	}
+
+	session, err := authSession(c)
+	if err != nil {
+		logger.Errorf("Error getting session: %v", err)
+		return c.JSON(http.StatusUnauthorized, response{
+			Success: false,
+			Message: "unauthorized",
+		})
+	}
+
+	if session.IsCustomer() {
+		logger.Errorf("User is not authorized to access this resource")
+		return c.JSON(http.StatusForbidden, response{
+			Success: false,
+			Message: "forbidden",
+		})
+	}
+
+	input.ActorID = session.ID
+
+	record, err := h.maintenanceRepo.Schedule(c.Request().Context(), c.Param("id"), input)
+	if err != nil {
+		logger.Errorf("Error scheduling service: %v", err)
+		return maintenanceErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, response{
+		Success: true,
+		Data:    record,
+	})
+}
+
+// completeCamperServiceHandler logs a finished service. The camper's last
+// maintenance and condition are updated from it.
+func (h *httpService) completeCamperServiceHandler(c echo.Context) error {
+	logger := logrus.WithField("context", utils.Dump(c))
+
+	var input model.ServiceCompleteInput
+	if err := c.Bind(&input); err != nil {
+		logger.Errorf("Error parsing request: %v", err)
+		return c.JSON(http.StatusBadRequest, response{
+			Success: false,
+			Message: err.Error(),
+		})
+	}
+
+	session, err := authSession(c)
+	if err != nil {
+		logger.Errorf("Error getting session: %v", err)
+		return c.JSON(http.StatusUnauthorized, response{
+			Success: false,
+			Message: "unauthorized",
+		})
+	}
+
+	if session.IsCustomer() {
+		logger.Errorf("User is not authorized to access this resource")
+		return c.JSON(http.StatusForbidden, response{
+			Success: false,
+			Message: "forbidden",
+		})
+	}
+
+	record, err := h.maintenanceRepo.FindByID(c.Request().Context(), c.Param("serviceID"))
+	if err != nil || record.CamperID != c.Param("id") {
+		logger.Errorf("Error querying service record: %v", err)
+		return c.JSON(http.StatusNotFound, response{
+			Success: false,
+			Message: "service record not found",
+		})
+	}
+
+	input.ActorID = session.ID
+
+	record, err = h.maintenanceRepo.Complete(c.Request().Context(), record.ID, input)
+	if err != nil {
+		logger.Errorf("Error completing service: %v", err)
+		return maintenanceErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, response{
+		Success: true,
+		Data:    record,
+	})
+}
+
+// findDueMaintenanceHandler reports the services coming up across the fleet
+// so the workshop can be booked ahead.
+func (h *httpService) findDueMaintenanceHandler(c echo.Context) error {
+	logger := logrus.WithField("context", utils.Dump(c))
+
+	var query model.MaintenanceDueQueryInput
+	if err := c.Bind(&query); err != nil {
+		logger.Errorf("Error parsing request: %v", err)
+		return c.JSON(http.StatusBadRequest, response{
+			Success: false,
+			Message: err.Error(),
+		})
+	}
+
+	session, err := authSession(c)
+	if err != nil {
+		logger.Errorf("Error getting session: %v", err)
+		return c.JSON(http.StatusUnauthorized, response{
+			Success: false,
+			Message: "unauthorized",
+		})
+	}
+
+	if session.IsCustomer() {
+		logger.Errorf("User is not authorized to access this resource")
+		return c.JSON(http.StatusForbidden, response{
+			Success: false,
+			Message: "forbidden",
+		})
+	}
+
+	records, err := h.maintenanceRepo.Due(c.Request().Context(), query)
+	if err != nil {
+		logger.Errorf("Error querying due services: %v", err)
+		return c.JSON(http.StatusInternalServerError, response{
+			Success: false,
+			Message: "internal server error",
+		})
+	}
+
+	return c.JSON(http.StatusOK, response{
+		Success: true,
+		Data:    records,
+	})
+}
+
+func maintenanceErrorResponse(e echo.Context, err error) error {
+	switch {
+	case errors.Is(err, model.ErrInvalidServiceRecord),
+		errors.Is(err, model.ErrInvalidMaintenanceWindow),
+		errors.Is(err, model.ErrInvalidOdometerReading):
+		return e.JSON(http.StatusBadRequest, response{
+			Success: false,
+			Message: err.Error(),
+		})
+	case errors.Is(err, model.ErrServiceAlreadyCompleted):
+		return e.JSON(http.StatusConflict, response{
+			Success: false,
+			Message: err.Error(),
+		})
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return e.JSON(http.StatusNotFound, response{
+			Success: false,
+			Message: "camper not found",
+		})
+	}
+
+	return e.JSON(http.StatusInternalServerError, response{
+		Success: false,
+		Message: "internal server error",
+	})
+}
@@ -0,0 +1,108 @@
+package router
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/notblessy/rms/model"
+	"github.com/notblessy/rms/utils"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// findCamperOdometerHandler lists the camper's mileage history, latest
+// reading first.
+func (h *httpService) findCamperOdometerHandler(c echo.Context) error {
+	logger := logrus.WithField("context", utils.Dump(c))
+
+	session, err := authSession(c)
+	if err != nil {
+		logger.Errorf("Error getting session: %v", err)
+		return c.JSON(http.StatusUnauthorized, response{
+			Success: false,
+			Message: "unauthorized",
+		})
+	}
+
+	if session.IsCustomer() {
+		logger.Errorf("User is not authorized to access this resource")
+		return c.JSON(http.StatusForbidden, response{
+			Success: false,
+			Message: "forbidden",
+		})
+	}
+
+	readings, err := h.odometerRepo.FindByCamperID(c.Request().Context(), c.Param("id"))
+	if err != nil {
+		logger.Errorf("Error querying odometer readings: %v", err)
+		return c.JSON(http.StatusInternalServerError, response{
+			Success: false,
+			Message: "internal server error",
+		})
+	}
+
+	return c.JSON(http.StatusOK, response{
+		Success: true,
+		Data:    readings,
+	})
+}
+
+func (h *httpService) recordCamperOdometerHandler(c echo.Context) error {
+	logger := logrus.WithField("context", utils.Dump(c))
+
+	var input model.OdometerReadingInput
+	if err := c.Bind(&input); err != nil {
+		logger.Errorf("Error parsing request: %v", err)
+		return c.JSON(http.StatusBadRequest, response{
+			Success: false,
+			Message: err.Error(),
+		})
+	}
+
+	session, err := authSession(c)
+	if err != nil {
+		logger.Errorf("Error getting session: %v", err)
+		return c.JSON(http.StatusUnauthorized, response{
+			Success: false,
+			Message: "unauthorized",
+		})
+	}
+
+	if session.IsCustomer() {
+		logger.Errorf("User is not authorized to access this resource")
+		return c.JSON(http.StatusForbidden, response{
+			Success: false,
+			Message: "forbidden",
+		})
+	}
+
+	input.ActorID = session.ID
+
+	reading, err := h.odometerRepo.Record(c.Request().Context(), c.Param("id"), input)
+	if err != nil {
+		logger.Errorf("Error recording odometer reading: %v", err)
+		switch {
+		case errors.Is(err, model.ErrInvalidOdometerReading):
+			return c.JSON(http.StatusBadRequest, response{
+				Success: false,
+				Message: err.Error(),
+			})
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			return c.JSON(http.StatusNotFound, response{
+				Success: false,
+				Message: "camper not found",
+			})
+		}
+
+		return c.JSON(http.StatusInternalServerError, response{
+			Success: false,
+			Message: "internal server error",
+		})
+	}
+
+	return c.JSON(http.StatusCreated, response{
+		Success: true,
+		Data:    reading,
+	})
+}
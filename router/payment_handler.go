@@ -0,0 +1,284 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/notblessy/rms/model"
+	"github.com/notblessy/rms/utils"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+var errPaymentProvider = errors.New("payment provider error")
+
+type rentalPaymentsResponse struct {
+	Payments []model.Payment     `json:"payments"`
+	Ledger   model.PaymentLedger `json:"ledger"`
+}
+
+func (h *httpService) findRentalPaymentsHandler(c echo.Context) error {
+	logger := logrus.WithField("context", utils.Dump(c))
+
+	id := c.Param("id")
+
+	session, err := authSession(c)
+	if err != nil {
+		logger.Errorf("Error getting session: %v", err)
+		return c.JSON(http.StatusUnauthorized, response{
+			Success: false,
+			Message: "unauthorized",
+		})
+	}
+
+	rental, err := h.rentalRepo.FindByID(c.Request().Context(), id)
+	if err != nil {
+		logger.Errorf("Error querying rental: %v", err)
+		return rentalErrorResponse(c, err)
+	}
+
+	if session.IsCustomer() && rental.CustomerID != session.ID {
+		logger.Errorf("User is not authorized to access this resource")
+		return c.JSON(http.StatusForbidden, response{
+			Success: false,
+			Message: "forbidden",
+		})
+	}
+
+	payments, err := h.paymentRepo.FindByRentalID(c.Request().Context(), id)
+	if err != nil {
+		logger.Errorf("Error querying payments: %v", err)
+		return c.JSON(http.StatusInternalServerError, response{
+			Success: false,
+			Message: "internal server error",
+		})
+	}
+
+	return c.JSON(http.StatusOK, response{
+		Success: true,
+		Data: rentalPaymentsResponse{
+			Payments: payments,
+			Ledger:   model.NewPaymentLedger(payments),
+		},
+	})
+}
+
+func (h *httpService) createRentalPaymentHandler(c echo.Context) error {
+	logger := logrus.WithField("context", utils.Dump(c))
+
+	id := c.Param("id")
+
+	var input model.PaymentInput
+	if err := c.Bind(&input); err != nil {
+		logger.Errorf("Error parsing request: %v", err)
+		return c.JSON(http.StatusBadRequest, response{
+			Success: false,
+			Message: err.Error(),
+		})
+	}
+
+	session, err := authSession(c)
+	if err != nil {
+		logger.Errorf("Error getting session: %v", err)
+		return c.JSON(http.StatusUnauthorized, response{
+			Success: false,
+			Message: "unauthorized",
+		})
+	}
+
+	if err := input.Validate(session.Actor()); err != nil {
+		logger.Errorf("Invalid payment: %v", err)
+		return rentalErrorResponse(c, err)
+	}
+
+	rental, err := h.rentalRepo.FindByID(c.Request().Context(), id)
+	if err != nil {
+		logger.Errorf("Error querying rental: %v", err)
+		return rentalErrorResponse(c, err)
+	}
+
+	if session.IsCustomer() && rental.CustomerID != session.ID {
+		logger.Errorf("User is not authorized to access this resource")
+		return c.JSON(http.StatusForbidden, response{
+			Success: false,
+			Message: "forbidden",
+		})
+	}
+
+	payments, err := h.paymentRepo.FindByRentalID(c.Request().Context(), id)
+	if err != nil {
+		logger.Errorf("Error querying payments: %v", err)
+		return c.JSON(http.StatusInternalServerError, response{
+			Success: false,
+			Message: "internal server error",
+		})
+	}
+
+	amount := model.NewPaymentLedger(payments).AmountFor(input.Type, rental)
+	if input.Amount != nil {
+		amount = *input.Amount
+	}
+
+	if !amount.IsPositive() {
+		logger.Errorf("Nothing left to pay for %s", input.Type)
+		return rentalErrorResponse(c, model.ErrInvalidPaymentAmount)
+	}
+
+	payment, err := h.paymentRepo.Create(c.Request().Context(), model.Payment{
+		RentalID:  id,
+		Type:      input.Type,
+		Amount:    amount,
+		Provider:  h.paymentGateway.Name(),
+		Note:      input.Note,
+		CreatedBy: session.ID,
+	})
+	if err != nil {
+		logger.Errorf("Error creating payment: %v", err)
+		return c.JSON(http.StatusInternalServerError, response{
+			Success: false,
+			Message: "internal server error",
+		})
+	}
+
+	payment, err = h.submitPayment(c.Request().Context(), payment)
+	if err != nil {
+		logger.Errorf("Error submitting payment: %v", err)
+		return paymentErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, response{
+		Success: true,
+		Data:    payment,
+	})
+}
+
+// submitPayment sends a pending payment to the gateway and stores the
+// provider's reference. A payment the gateway rejects is marked as failed.
+func (h *httpService) submitPayment(ctx context.Context, payment model.Payment) (model.Payment, error) {
+	logger := logrus.WithField("payment", utils.Dump(payment))
+
+	var providerRef string
+	var err error
+	if payment.Type == model.PaymentTypeRefund {
+		providerRef, err = h.paymentGateway.Refund(ctx, payment)
+	} else {
+		providerRef, err = h.paymentGateway.Charge(ctx, payment)
+	}
+
+	if err != nil {
+		logger.Errorf("Error submitting payment to %s: %v", payment.Provider, err)
+
+		_, applyErr := h.paymentRepo.ApplyEvent(ctx, model.PaymentEvent{
+			PaymentID: payment.ID,
+			Status:    model.PaymentStatusFailed,
+		})
+		if applyErr != nil {
+			logger.Errorf("Error marking payment as failed: %v", applyErr)
+		}
+
+		return model.Payment{}, errPaymentProvider
+	}
+
+	if err := h.paymentRepo.SetProviderRef(ctx, payment.ID, providerRef); err != nil {
+		logger.Errorf("Error saving provider ref: %v", err)
+		return model.Payment{}, err
+	}
+
+	payment.ProviderRef = providerRef
+	return payment, nil
+}
+
+func paymentErrorResponse(e echo.Context, err error) error {
+	if errors.Is(err, errPaymentProvider) {
+		return e.JSON(http.StatusBadGateway, response{
+			Success: false,
+			Message: err.Error(),
+		})
+	}
+
+	return e.JSON(http.StatusInternalServerError, response{
+		Success: false,
+		Message: "internal server error",
+	})
+}
+
+// paymentWebhookHandler receives payment outcomes from the provider. It is
+// public, so the payload is only trusted once its signature checks out.
+func (h *httpService) paymentWebhookHandler(c echo.Context) error {
+	logger := logrus.WithField("context", utils.Dump(c))
+
+	payload, err := io.ReadAll(c.Request().Body)
+	if err != nil {
+		logger.Errorf("Error reading webhook: %v", err)
+		return c.JSON(http.StatusBadRequest, response{
+			Success: false,
+			Message: err.Error(),
+		})
+	}
+
+	event, err := h.paymentGateway.ParseWebhook(c.Request().Header.Get("X-Signature"), payload)
+	if err != nil {
+		logger.Errorf("Error parsing webhook: %v", err)
+		return c.JSON(http.StatusBadRequest, response{
+			Success: false,
+			Message: err.Error(),
+		})
+	}
+
+	payment, err := h.applyPaymentEvent(c.Request().Context(), event)
+	if err != nil {
+		logger.Errorf("Error applying payment event: %v", err)
+		if errors.Is(err, model.ErrInvalidPaymentEvent) {
+			return c.JSON(http.StatusBadRequest, response{
+				Success: false,
+				Message: err.Error(),
+			})
+		}
+
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.JSON(http.StatusNotFound, response{
+				Success: false,
+				Message: "payment not found",
+			})
+		}
+
+		return c.JSON(http.StatusInternalServerError, response{
+			Success: false,
+			Message: "internal server error",
+		})
+	}
+
+	return c.JSON(http.StatusOK, response{
+		Success: true,
+		Data:    payment,
+	})
+}
+
+// HandlePaymentEvent applies an event reported by the payment gateway. It is
+// registered with gateways that report outcomes in-process rather than
+// through the webhook.
+func (h *httpService) HandlePaymentEvent(ctx context.Context, event model.PaymentEvent) error {
+	_, err := h.applyPaymentEvent(ctx, event)
+	return err
+}
+
+// applyPaymentEvent records the payment outcome and issues a credit note
+// once a refund has been settled.
+func (h *httpService) applyPaymentEvent(ctx context.Context, event model.PaymentEvent) (model.Payment, error) {
+	payment, err := h.paymentRepo.ApplyEvent(ctx, event)
+	if err != nil {
+		return model.Payment{}, err
+	}
+
+	if payment.Type == model.PaymentTypeRefund && payment.Status == model.PaymentStatusPaid {
+		_, err = h.invoiceRepo.IssueCreditNote(ctx, payment)
+		if err != nil && !errors.Is(err, model.ErrRentalNotInvoiceable) {
+			return model.Payment{}, err
+		}
+	}
+
+	return payment, nil
+}
@@ -1,12 +1,17 @@
 package router
 
 import (
+	"context"
+	"errors"
 	"net/http"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/notblessy/rms/model"
+	"github.com/notblessy/rms/pricing"
 	"github.com/notblessy/rms/utils"
 	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
 )
 
 func (h *httpService) findRentalByIDHandler(e echo.Context) error {
@@ -14,7 +19,7 @@ func (h *httpService) findRentalByIDHandler(e echo.Context) error {
 
 	id := e.Param("id")
 
-	_, err := authSession(e)
+	session, err := authSession(e)
 	if err != nil {
 		logger.Errorf("Error getting session: %v", err)
 		return e.JSON(http.StatusUnauthorized, response{
@@ -32,6 +37,14 @@ func (h *httpService) findRentalByIDHandler(e echo.Context) error {
 		})
 	}
 
+	if session.IsCustomer() && rental.CustomerID != session.ID {
+		logger.Errorf("User is not authorized to access this resource")
+		return e.JSON(http.StatusForbidden, response{
+			Success: false,
+			Message: "forbidden",
+		})
+	}
+
 	return e.JSON(http.StatusOK, response{
 		Success: true,
 		Data:    rental,
@@ -80,6 +93,74 @@ func (h *httpService) findAllRentalHandler(e echo.Context) error {
 	return e.JSON(http.StatusOK, withPaging(rentals, total, rentalQuery.PageOrDefault(), rentalQuery.SizeOrDefault()))
 }
 
+// findMyRentalsHandler lists the rentals booked by the logged-in user.
+func (h *httpService) findMyRentalsHandler(e echo.Context) error {
+	logger := logrus.WithField("context", utils.Dump(e))
+
+	session, err := authSession(e)
+	if err != nil {
+		logger.Errorf("Error getting session: %v", err)
+		return e.JSON(http.StatusUnauthorized, response{
+			Success: false,
+			Message: "unauthorized",
+		})
+	}
+
+	var rentalQuery model.RentalQueryInput
+
+	if err := e.Bind(&rentalQuery); err != nil {
+		logger.Errorf("Error parsing request: %v", err)
+		return e.JSON(http.StatusBadRequest, response{
+			Success: false,
+			Message: err.Error(),
+		})
+	}
+
+	rentalQuery.CustomerID = session.ID
+
+	rentals, total, err := h.rentalRepo.FindAll(e.Request().Context(), rentalQuery)
+	if err != nil {
+		logger.Errorf("Error getting rentals: %v", err)
+		return e.JSON(http.StatusInternalServerError, response{
+			Success: false,
+			Message: err.Error(),
+		})
+	}
+
+	return e.JSON(http.StatusOK, withPaging(rentals, total, rentalQuery.PageOrDefault(), rentalQuery.SizeOrDefault()))
+}
+
+// findMyRentalByIDHandler returns one of the logged-in user's rentals. Other
+// users' rentals are reported as not found rather than forbidden.
+func (h *httpService) findMyRentalByIDHandler(e echo.Context) error {
+	logger := logrus.WithField("context", utils.Dump(e))
+
+	id := e.Param("id")
+
+	session, err := authSession(e)
+	if err != nil {
+		logger.Errorf("Error getting session: %v", err)
+		return e.JSON(http.StatusUnauthorized, response{
+			Success: false,
+			Message: "unauthorized",
+		})
+	}
+
+	rental, err := h.rentalRepo.FindByID(e.Request().Context(), id)
+	if err != nil || rental.CustomerID != session.ID {
+		logger.Errorf("Error querying rental: %v", err)
+		return e.JSON(http.StatusNotFound, response{
+			Success: false,
+			Message: "rental not found",
+		})
+	}
+
+	return e.JSON(http.StatusOK, response{
+		Success: true,
+		Data:    rental,
+	})
+}
+
 func (h *httpService) createRentalHandler(e echo.Context) error {
 	logger := logrus.WithField("context", utils.Dump(e))
 
@@ -111,13 +192,18 @@ func (h *httpService) createRentalHandler(e echo.Context) error {
 
 	rental.CustomerID = session.ID
 
+	quote, err := h.quoteRental(e.Request().Context(), rental)
+	if err != nil {
+		logger.Errorf("Error pricing rental: %v", err)
+		return rentalErrorResponse(e, err)
+	}
+
+	rental.ApplyQuote(quote.GrandTotal, quote.Discount, quote.DepositDue, quote.LineItems)
+
 	err = h.rentalRepo.Create(e.Request().Context(), rental)
 	if err != nil {
 		logger.Errorf("Error creating rental: %v", err)
-		return e.JSON(http.StatusInternalServerError, response{
-			Success: false,
-			Message: err.Error(),
-		})
+		return rentalErrorResponse(e, err)
 	}
 
 	return e.JSON(http.StatusCreated, response{
@@ -140,7 +226,7 @@ func (h *httpService) updateRentalHandler(e echo.Context) error {
 		})
 	}
 
-	_, err := authSession(e)
+	session, err := authSession(e)
 	if err != nil {
 		logger.Errorf("Error getting session: %v", err)
 		return e.JSON(http.StatusUnauthorized, response{
@@ -149,17 +235,427 @@ func (h *httpService) updateRentalHandler(e echo.Context) error {
 		})
 	}
 
+	existingRental, err := h.rentalRepo.FindByID(e.Request().Context(), id)
+	if err != nil {
+		logger.Errorf("Error querying rental: %v", err)
+		return e.JSON(http.StatusNotFound, response{
+			Success: false,
+			Message: "rental not found",
+		})
+	}
+
+	if session.IsCustomer() && existingRental.CustomerID != session.ID {
+		logger.Errorf("User is not authorized to access this resource")
+		return e.JSON(http.StatusForbidden, response{
+			Success: false,
+			Message: "forbidden",
+		})
+	}
+
+	// A rental always stays with the customer who booked it.
+	rental.CustomerID = existingRental.CustomerID
+
+	quote, err := h.quoteRental(e.Request().Context(), rental.WithDefaults(existingRental))
+	if err != nil {
+		logger.Errorf("Error pricing rental: %v", err)
+		return rentalErrorResponse(e, err)
+	}
+
+	rental.ApplyQuote(quote.GrandTotal, quote.Discount, quote.DepositDue, quote.LineItems)
+
 	err = h.rentalRepo.Update(e.Request().Context(), id, rental)
 	if err != nil {
 		logger.Errorf("Error updating rental: %v", err)
-		return e.JSON(http.StatusInternalServerError, response{
+		return rentalErrorResponse(e, err)
+	}
+
+	return e.JSON(http.StatusOK, response{
+		Success: true,
+		Data:    rental,
+	})
+}
+
+// transitionRentalHandler serves the endpoint that moves a rental through
+// action, e.g. POST /v1/rentals/:id/confirm.
+func (h *httpService) transitionRentalHandler(action string) echo.HandlerFunc {
+	return func(e echo.Context) error {
+		logger := logrus.WithField("context", utils.Dump(e))
+
+		id := e.Param("id")
+
+		session, err := authSession(e)
+		if err != nil {
+			logger.Errorf("Error getting session: %v", err)
+			return e.JSON(http.StatusUnauthorized, response{
+				Success: false,
+				Message: "unauthorized",
+			})
+		}
+
+		rental, err := h.rentalRepo.Transition(e.Request().Context(), id, model.RentalTransitionInput{
+			Action:  action,
+			Actor:   session.Actor(),
+			ActorID: session.ID,
+		})
+		if err != nil {
+			logger.Errorf("Error transitioning rental: %v", err)
+			return rentalErrorResponse(e, err)
+		}
+
+		if rental.Status == model.RentalStatusCompleted {
+			h.issueDebitNote(e.Request().Context(), id)
+		}
+
+		return e.JSON(http.StatusOK, response{
+			Success: true,
+			Data:    rental,
+		})
+	}
+}
+
+func (h *httpService) cancelRentalHandler(e echo.Context) error {
+	logger := logrus.WithField("context", utils.Dump(e))
+
+	id := e.Param("id")
+
+	var input model.RentalCancelInput
+	if err := e.Bind(&input); err != nil {
+		logger.Errorf("Error parsing request: %v", err)
+		return e.JSON(http.StatusBadRequest, response{
+			Success: false,
+			Message: err.Error(),
+		})
+	}
+
+	session, err := authSession(e)
+	if err != nil {
+		logger.Errorf("Error getting session: %v", err)
+		return e.JSON(http.StatusUnauthorized, response{
+			Success: false,
+			Message: "unauthorized",
+		})
+	}
+
+	input.Actor = session.Actor()
+	input.ActorID = session.ID
+
+	input.Provider = h.paymentGateway.Name()
+
+	result, err := h.rentalRepo.Cancel(e.Request().Context(), id, input)
+	if err != nil {
+		logger.Errorf("Error cancelling rental: %v", err)
+		return rentalErrorResponse(e, err)
+	}
+
+	if result.Payment != nil {
+		payment, err := h.submitPayment(e.Request().Context(), *result.Payment)
+		if err != nil {
+			logger.Errorf("Error submitting cancellation refund: %v", err)
+			return paymentErrorResponse(e, err)
+		}
+
+		result.Payment = &payment
+	}
+
+	return e.JSON(http.StatusOK, response{
+		Success: true,
+		Data:    result,
+	})
+}
+
+// cancelMyRentalHandler lets the logged-in user cancel one of their own
+// rentals. The refund always follows the cancellation policy, even for staff
+// cancelling a trip they booked themselves.
+func (h *httpService) cancelMyRentalHandler(e echo.Context) error {
+	logger := logrus.WithField("context", utils.Dump(e))
+
+	id := e.Param("id")
+
+	var input model.RentalCancelInput
+	if err := e.Bind(&input); err != nil {
+		logger.Errorf("Error parsing request: %v", err)
+		return e.JSON(http.StatusBadRequest, response{
 			Success: false,
 			Message: err.Error(),
 		})
 	}
 
+	session, err := authSession(e)
+	if err != nil {
+		logger.Errorf("Error getting session: %v", err)
+		return e.JSON(http.StatusUnauthorized, response{
+			Success: false,
+			Message: "unauthorized",
+		})
+	}
+
+	input.Actor = model.ActorCustomer
+	input.ActorID = session.ID
+	input.RefundAmount = nil
+	input.OverrideReason = ""
+
+	input.Provider = h.paymentGateway.Name()
+
+	result, err := h.rentalRepo.Cancel(e.Request().Context(), id, input)
+	if err != nil {
+		logger.Errorf("Error cancelling rental: %v", err)
+		return rentalErrorResponse(e, err)
+	}
+
+	if result.Payment != nil {
+		payment, err := h.submitPayment(e.Request().Context(), *result.Payment)
+		if err != nil {
+			logger.Errorf("Error submitting cancellation refund: %v", err)
+			return paymentErrorResponse(e, err)
+		}
+
+		result.Payment = &payment
+	}
+
 	return e.JSON(http.StatusOK, response{
 		Success: true,
-		Data:    rental,
+		Data:    result,
+	})
+}
+
+type rentalQuoteResponse struct {
+	pricing.Quote
+	Bookable        bool                   `json:"bookable"`
+	BlockingReasons []model.BlockingReason `json:"blocking_reasons"`
+}
+
+func (h *httpService) quoteRentalHandler(e echo.Context) error {
+	logger := logrus.WithField("context", utils.Dump(e))
+
+	var rental model.RentalInput
+	if err := e.Bind(&rental); err != nil {
+		logger.Errorf("Error parsing request: %v", err)
+		return e.JSON(http.StatusBadRequest, response{
+			Success: false,
+			Message: err.Error(),
+		})
+	}
+
+	_, err := authSession(e)
+	if err != nil {
+		logger.Errorf("Error getting session: %v", err)
+		return e.JSON(http.StatusUnauthorized, response{
+			Success: false,
+			Message: "unauthorized",
+		})
+	}
+
+	quote, err := h.quoteRental(e.Request().Context(), rental)
+	if err != nil {
+		logger.Errorf("Error pricing rental: %v", err)
+		return rentalErrorResponse(e, err)
+	}
+
+	reasons, err := h.rentalRepo.CheckAvailability(e.Request().Context(), rental, "")
+	if err != nil {
+		logger.Errorf("Error checking rental availability: %v", err)
+		return rentalErrorResponse(e, err)
+	}
+
+	return e.JSON(http.StatusOK, response{
+		Success: true,
+		Data: rentalQuoteResponse{
+			Quote:           quote,
+			Bookable:        len(reasons) == 0,
+			BlockingReasons: reasons,
+		},
+	})
+}
+
+// quoteRental loads the camper, equipment, bundles and driver of a rental
+// and prices it with the server-side pricing engine.
+func (h *httpService) quoteRental(ctx context.Context, rental model.RentalInput) (pricing.Quote, error) {
+	camper, err := h.camperRepo.FindByID(ctx, rental.CamperID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return pricing.Quote{}, model.ErrCamperNotFound
+		}
+
+		return pricing.Quote{}, err
+	}
+
+	var equipments []pricing.EquipmentLine
+	if rental.HasEquipments() {
+		ids := rental.RequestedEquipmentIDs()
+
+		found, err := h.equipmentRepo.FindByIDs(ctx, ids)
+		if err != nil {
+			return pricing.Quote{}, err
+		}
+
+		if len(found) != len(ids) {
+			return pricing.Quote{}, model.ErrEquipmentNotFound
+		}
+
+		quantities := rental.EquipmentQuantities()
+		for _, equipment := range found {
+			equipments = append(equipments, pricing.EquipmentLine{
+				Equipment: equipment,
+				Quantity:  quantities[equipment.ID],
+			})
+		}
+	}
+
+	var bundles []pricing.BundleLine
+	if rental.HasBundles() {
+		ids := rental.RequestedBundleIDs()
+
+		found, err := h.equipmentBundleRepo.FindByIDs(ctx, ids)
+		if err != nil {
+			return pricing.Quote{}, err
+		}
+
+		if len(found) != len(ids) {
+			return pricing.Quote{}, model.ErrBundleNotFound
+		}
+
+		quantities := rental.BundleQuantities()
+		for _, bundle := range found {
+			bundles = append(bundles, pricing.BundleLine{
+				Bundle:   bundle,
+				Quantity: quantities[bundle.ID],
+			})
+		}
+	}
+
+	var driver *model.Driver
+	if rental.DriverID != "" {
+		found, err := h.driverRepo.FindByID(ctx, rental.DriverID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return pricing.Quote{}, model.ErrDriverNotFound
+			}
+
+			return pricing.Quote{}, err
+		}
+
+		driver = &found
+	}
+
+	return h.pricingEngine.Calculate(pricing.Input{
+		StartDate:  rental.StartDate,
+		EndDate:    rental.EndDate,
+		RentalType: rental.RentalType,
+		Camper:     camper,
+		Equipments: equipments,
+		Bundles:    bundles,
+		Driver:     driver,
+	})
+}
+
+// changeRentalEndDateHandler serves the extend and early-return endpoints.
+// The rental is repriced at its original rates and the difference is
+// submitted to the payment gateway as a charge or refund.
+func (h *httpService) changeRentalEndDateHandler(changeType string) echo.HandlerFunc {
+	return func(e echo.Context) error {
+		logger := logrus.WithField("context", utils.Dump(e))
+
+		id := e.Param("id")
+
+		var input model.RentalDateChangeInput
+		if err := e.Bind(&input); err != nil {
+			logger.Errorf("Error parsing request: %v", err)
+			return e.JSON(http.StatusBadRequest, response{
+				Success: false,
+				Message: err.Error(),
+			})
+		}
+
+		session, err := authSession(e)
+		if err != nil {
+			logger.Errorf("Error getting session: %v", err)
+			return e.JSON(http.StatusUnauthorized, response{
+				Success: false,
+				Message: "unauthorized",
+			})
+		}
+
+		input.Type = changeType
+		input.Actor = session.Actor()
+		input.ActorID = session.ID
+		input.Provider = h.paymentGateway.Name()
+
+		result, err := h.rentalRepo.ChangeEndDate(e.Request().Context(), id, input, h.repriceRental)
+		if err != nil {
+			logger.Errorf("Error changing rental end date: %v", err)
+			return rentalErrorResponse(e, err)
+		}
+
+		if result.Payment != nil {
+			payment, err := h.submitPayment(e.Request().Context(), *result.Payment)
+			if err != nil {
+				logger.Errorf("Error submitting end date change payment: %v", err)
+				return paymentErrorResponse(e, err)
+			}
+
+			result.Payment = &payment
+		}
+
+		h.issueDebitNote(e.Request().Context(), id)
+
+		return e.JSON(http.StatusOK, response{
+			Success: true,
+			Data:    result,
+		})
+	}
+}
+
+func (h *httpService) repriceRental(rental model.Rental, endDate time.Time) (model.RentalPrice, error) {
+	quote, err := h.pricingEngine.Reprice(rental, endDate)
+	if err != nil {
+		return model.RentalPrice{}, err
+	}
+
+	return model.RentalPrice{
+		RentalType: quote.RentalType,
+		LineItems:  quote.LineItems,
+		Discount:   quote.Discount,
+		GrandTotal: quote.GrandTotal,
+	}, nil
+}
+
+// rentalErrorResponse maps rental domain errors to their HTTP status.
+func rentalErrorResponse(e echo.Context, err error) error {
+	status := http.StatusInternalServerError
+
+	switch {
+	case errors.Is(err, model.ErrInvalidRentalPeriod),
+		errors.Is(err, model.ErrInvalidRentalType),
+		errors.Is(err, model.ErrCamperNotFound),
+		errors.Is(err, model.ErrEquipmentNotFound),
+		errors.Is(err, model.ErrBundleNotFound),
+		errors.Is(err, model.ErrDriverNotFound),
+		errors.Is(err, model.ErrOverrideReasonRequired),
+		errors.Is(err, model.ErrInvalidRefundAmount),
+		errors.Is(err, model.ErrInvalidPaymentType),
+		errors.Is(err, model.ErrInvalidPaymentAmount),
+		errors.Is(err, model.ErrInvalidInspection),
+		errors.Is(err, model.ErrInvalidOdometerReading),
+		errors.Is(err, model.ErrInvalidEndDateChange):
+		status = http.StatusBadRequest
+	case errors.Is(err, model.ErrCamperUnavailable),
+		errors.Is(err, model.ErrDriverUnavailable),
+		errors.Is(err, model.ErrEquipmentOutOfStock),
+		errors.Is(err, model.ErrRentalNotEditable),
+		errors.Is(err, model.ErrInvalidRentalTransition),
+		errors.Is(err, model.ErrHoldExpired),
+		errors.Is(err, model.ErrDepositNotPaid),
+		errors.Is(err, model.ErrRentalNotInvoiceable),
+		errors.Is(err, model.ErrInspectionNotAllowed):
+		status = http.StatusConflict
+	case errors.Is(err, model.ErrForbidden):
+		status = http.StatusForbidden
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		status = http.StatusNotFound
+	}
+
+	return e.JSON(status, response{
+		Success: false,
+		Message: err.Error(),
 	})
 }
@@ -3,6 +3,7 @@ package router
 import (
 	"github.com/labstack/echo/v4"
 	"github.com/notblessy/rms/model"
+	"github.com/notblessy/rms/pricing"
 	"gorm.io/gorm"
 )
 
@@ -13,6 +14,21 @@ type httpService struct {
 	equipmentRepo model.EquipmentRepository
 	driverRepo    model.DriverRepository
 	rentalRepo    model.RentalRepository
+	pricingEngine *pricing.Engine
+
+	cancellationPolicyRepo model.CancellationPolicyRepository
+	paymentRepo            model.PaymentRepository
+	paymentGateway         model.PaymentGateway
+	securityDepositRepo    model.SecurityDepositRepository
+	invoiceRepo            model.InvoiceRepository
+	inspectionRepo         model.InspectionRepository
+	damageReportRepo       model.DamageReportRepository
+	equipmentUnitRepo      model.EquipmentUnitRepository
+	equipmentBundleRepo    model.EquipmentBundleRepository
+	maintenanceRepo        model.MaintenanceRepository
+	odometerRepo           model.OdometerRepository
+	camperImageRepo        model.CamperImageRepository
+	searchRepo             model.SearchRepository
 }
 
 func NewHTTPService() *httpService {
@@ -43,6 +59,62 @@ func (h *httpService) RegisterRentalRepository(r model.RentalRepository) {
 	h.rentalRepo = r
 }
 
+func (h *httpService) RegisterCancellationPolicyRepository(c model.CancellationPolicyRepository) {
+	h.cancellationPolicyRepo = c
+}
+
+func (h *httpService) RegisterPaymentRepository(p model.PaymentRepository) {
+	h.paymentRepo = p
+}
+
+func (h *httpService) RegisterPaymentGateway(g model.PaymentGateway) {
+	h.paymentGateway = g
+}
+
+func (h *httpService) RegisterSecurityDepositRepository(s model.SecurityDepositRepository) {
+	h.securityDepositRepo = s
+}
+
+func (h *httpService) RegisterInvoiceRepository(i model.InvoiceRepository) {
+	h.invoiceRepo = i
+}
+
+func (h *httpService) RegisterInspectionRepository(i model.InspectionRepository) {
+	h.inspectionRepo = i
+}
+
+func (h *httpService) RegisterDamageReportRepository(d model.DamageReportRepository) {
+	h.damageReportRepo = d
+}
+
+func (h *httpService) RegisterEquipmentUnitRepository(e model.EquipmentUnitRepository) {
+	h.equipmentUnitRepo = e
+}
+
+func (h *httpService) RegisterEquipmentBundleRepository(e model.EquipmentBundleRepository) {
+	h.equipmentBundleRepo = e
+}
+
+func (h *httpService) RegisterMaintenanceRepository(m model.MaintenanceRepository) {
+	h.maintenanceRepo = m
+}
+
+func (h *httpService) RegisterOdometerRepository(o model.OdometerRepository) {
+	h.odometerRepo = o
+}
+
+func (h *httpService) RegisterCamperImageRepository(c model.CamperImageRepository) {
+	h.camperImageRepo = c
+}
+
+func (h *httpService) RegisterSearchRepository(s model.SearchRepository) {
+	h.searchRepo = s
+}
+
+func (h *httpService) RegisterPricingEngine(p *pricing.Engine) {
+	h.pricingEngine = p
+}
+
 func (h *httpService) Routes(e *echo.Echo) {
 	e.GET("/ping", h.ping)
 	e.GET("/health", h.health)
@@ -53,6 +125,10 @@ func (h *httpService) Routes(e *echo.Echo) {
 	publicCampers := v1.Group("/campers")
 	publicCampers.GET("", h.findAllCampersHandler)
 	publicCampers.GET("/:id", h.findCamperByIDHandler)
+	publicCampers.GET("/:id/availability", h.findCamperAvailabilityHandler)
+	publicCampers.GET("/:id/images", h.findCamperImagesHandler)
+
+	v1.POST("/payments/webhook", h.paymentWebhookHandler)
 
 	v1.Use(NewJWTMiddleware().ValidateJWT)
 
@@ -61,30 +137,102 @@ func (h *httpService) Routes(e *echo.Echo) {
 	users.GET("/me", h.profileHandler)
 	users.PATCH("", h.patchUserHandler)
 
+	v1.GET("/search", h.searchHandler)
+
+	me := v1.Group("/me")
+	me.GET("/rentals", h.findMyRentalsHandler)
+	me.GET("/rentals/:id", h.findMyRentalByIDHandler)
+	me.POST("/rentals/:id/cancel", h.cancelMyRentalHandler)
+
 	campers := v1.Group("/campers")
 	campers.POST("", h.createCamperHandler)
 	campers.PUT("/:id", h.updateCamperHandler)
 	campers.DELETE("/:id", h.deleteCamperHandler)
+	campers.POST("/:id/maintenance-windows", h.createMaintenanceWindowHandler)
+	campers.DELETE("/:id/maintenance-windows/:windowID", h.deleteMaintenanceWindowHandler)
+	campers.GET("/maintenance/due", h.findDueMaintenanceHandler)
+	campers.GET("/:id/services", h.findCamperServicesHandler)
+	campers.POST("/:id/services", h.scheduleCamperServiceHandler)
+	campers.POST("/:id/services/:serviceID/complete", h.completeCamperServiceHandler)
+	campers.GET("/:id/odometer", h.findCamperOdometerHandler)
+	campers.POST("/:id/odometer", h.recordCamperOdometerHandler)
+	campers.POST("/:id/images", h.uploadCamperImagesHandler)
+	campers.PUT("/:id/images/order", h.reorderCamperImagesHandler)
+	campers.POST("/:id/images/:imageID/primary", h.setPrimaryCamperImageHandler)
+	campers.DELETE("/:id/images/:imageID", h.deleteCamperImageHandler)
 
 	equipments := v1.Group("/equipments")
 	equipments.GET("", h.findAllEquipmentHandler)
 	equipments.GET("/:id", h.findEquipmentByIDHandler)
+	equipments.GET("/:id/stock", h.findEquipmentStockHandler)
+	equipments.GET("/:id/movements", h.findEquipmentMovementsHandler)
+	equipments.POST("/:id/movements", h.createEquipmentMovementHandler)
+	equipments.GET("/:id/units", h.findEquipmentUnitsHandler)
+	equipments.POST("/:id/units", h.createEquipmentUnitHandler)
+	equipments.GET("/:id/units/:unit", h.findEquipmentUnitByIDHandler)
+	equipments.PUT("/:id/units/:unit", h.updateEquipmentUnitHandler)
+	equipments.DELETE("/:id/units/:unit", h.deleteEquipmentUnitHandler)
+	equipments.GET("/:id/units/:unit/label.png", h.equipmentUnitLabelHandler)
+	equipments.POST("/:id/image", h.uploadEquipmentImageHandler)
 	equipments.POST("", h.createEquipmentHandler)
 	equipments.PUT("/:id", h.updateEquipmentHandler)
 	equipments.DELETE("/:id", h.deleteEquipmentHandler)
 
+	equipmentBundles := v1.Group("/equipment-bundles")
+	equipmentBundles.GET("", h.findAllEquipmentBundlesHandler)
+	equipmentBundles.GET("/:id", h.findEquipmentBundleByIDHandler)
+	equipmentBundles.POST("", h.createEquipmentBundleHandler)
+	equipmentBundles.PUT("/:id", h.updateEquipmentBundleHandler)
+	equipmentBundles.DELETE("/:id", h.deleteEquipmentBundleHandler)
+
 	drivers := v1.Group("/drivers")
 	drivers.GET("", h.findAllDriversHandler)
 	drivers.GET("/:id", h.findDriverByIDHandler)
 	drivers.POST("", h.createDriverHandler)
+	drivers.POST("/:id/photo", h.uploadDriverPhotoHandler)
 	drivers.PUT("/:id", h.updateDriverHandler)
 	drivers.DELETE("/:id", h.deleteDriverHandler)
 
+	cancellationPolicies := v1.Group("/cancellation-policies")
+	cancellationPolicies.GET("", h.findAllCancellationPoliciesHandler)
+	cancellationPolicies.GET("/:id", h.findCancellationPolicyByIDHandler)
+	cancellationPolicies.POST("", h.createCancellationPolicyHandler)
+	cancellationPolicies.PUT("/:id", h.updateCancellationPolicyHandler)
+	cancellationPolicies.DELETE("/:id", h.deleteCancellationPolicyHandler)
+
 	rentals := v1.Group("/rentals")
 	rentals.GET("", h.findAllRentalHandler)
 	rentals.GET("/:id", h.findRentalByIDHandler)
 	rentals.POST("", h.createRentalHandler)
+	rentals.POST("/quote", h.quoteRentalHandler)
 	rentals.PUT("/:id", h.updateRentalHandler)
+	rentals.POST("/:id/confirm", h.transitionRentalHandler(model.RentalActionConfirm))
+	rentals.POST("/:id/cancel", h.cancelRentalHandler)
+	rentals.POST("/:id/start", h.transitionRentalHandler(model.RentalActionStart))
+	rentals.POST("/:id/complete", h.transitionRentalHandler(model.RentalActionComplete))
+	rentals.POST("/:id/extend", h.changeRentalEndDateHandler(model.RentalDateChangeExtend))
+	rentals.POST("/:id/early-return", h.changeRentalEndDateHandler(model.RentalDateChangeEarlyReturn))
+	rentals.GET("/:id/payments", h.findRentalPaymentsHandler)
+	rentals.POST("/:id/payments", h.createRentalPaymentHandler)
+	rentals.GET("/:id/security-deposit", h.findSecurityDepositHandler)
+	rentals.GET("/:id/invoice.pdf", h.rentalInvoicePDFHandler)
+	rentals.GET("/:id/invoices", h.findRentalInvoicesHandler)
+	rentals.GET("/:id/invoices/:invoiceID/pdf", h.invoicePDFHandler)
+	rentals.GET("/:id/inspections", h.findRentalInspectionsHandler)
+	rentals.POST("/:id/inspections", h.createRentalInspectionHandler)
+	rentals.GET("/:id/damage-reports", h.findRentalDamageReportsHandler)
+	rentals.POST("/:id/damage-reports", h.createDamageReportHandler)
+	rentals.GET("/:id/equipment-units", h.findRentalEquipmentUnitsHandler)
+	rentals.POST("/:id/equipment-units", h.assignRentalEquipmentUnitsHandler)
+	rentals.POST("/:id/security-deposit/hold", h.securityDepositActionHandler(h.securityDepositRepo.Hold))
+	rentals.POST("/:id/security-deposit/capture", h.securityDepositActionHandler(h.securityDepositRepo.Capture))
+	rentals.POST("/:id/security-deposit/release", h.securityDepositActionHandler(h.securityDepositRepo.Release))
+
+	damageReports := v1.Group("/damage-reports")
+	damageReports.GET("/:id", h.findDamageReportByIDHandler)
+	damageReports.POST("/:id/assess", h.assessDamageReportHandler)
+	damageReports.POST("/:id/charge", h.chargeDamageReportHandler)
+	damageReports.POST("/:id/repair", h.repairDamageReportHandler)
 }
 
 func (h *httpService) ping(c echo.Context) error {
@@ -0,0 +1,118 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/notblessy/rms/model"
+	"github.com/notblessy/rms/utils"
+	"github.com/sirupsen/logrus"
+)
+
+func (h *httpService) findSecurityDepositHandler(c echo.Context) error {
+	logger := logrus.WithField("context", utils.Dump(c))
+
+	id := c.Param("id")
+
+	session, err := authSession(c)
+	if err != nil {
+		logger.Errorf("Error getting session: %v", err)
+		return c.JSON(http.StatusUnauthorized, response{
+			Success: false,
+			Message: "unauthorized",
+		})
+	}
+
+	rental, err := h.rentalRepo.FindByID(c.Request().Context(), id)
+	if err != nil {
+		logger.Errorf("Error querying rental: %v", err)
+		return rentalErrorResponse(c, err)
+	}
+
+	if session.IsCustomer() && rental.CustomerID != session.ID {
+		logger.Errorf("User is not authorized to access this resource")
+		return c.JSON(http.StatusForbidden, response{
+			Success: false,
+			Message: "forbidden",
+		})
+	}
+
+	deposit, err := h.securityDepositRepo.FindByRentalID(c.Request().Context(), id)
+	if err != nil {
+		logger.Errorf("Error querying security deposit: %v", err)
+		return rentalErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, response{
+		Success: true,
+		Data:    deposit,
+	})
+}
+
+// securityDepositActionHandler serves the staff endpoints that hold, capture
+// and release a rental's security deposit.
+func (h *httpService) securityDepositActionHandler(
+	action func(ctx context.Context, rentalID string, input model.SecurityDepositInput) (model.SecurityDeposit, error),
+) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		logger := logrus.WithField("context", utils.Dump(c))
+
+		id := c.Param("id")
+
+		var input model.SecurityDepositInput
+		if err := c.Bind(&input); err != nil {
+			logger.Errorf("Error parsing request: %v", err)
+			return c.JSON(http.StatusBadRequest, response{
+				Success: false,
+				Message: err.Error(),
+			})
+		}
+
+		session, err := authSession(c)
+		if err != nil {
+			logger.Errorf("Error getting session: %v", err)
+			return c.JSON(http.StatusUnauthorized, response{
+				Success: false,
+				Message: "unauthorized",
+			})
+		}
+
+		if session.IsCustomer() {
+			logger.Errorf("User is not authorized to access this resource")
+			return c.JSON(http.StatusForbidden, response{
+				Success: false,
+				Message: "forbidden",
+			})
+		}
+
+		input.ActorID = session.ID
+
+		deposit, err := action(c.Request().Context(), id, input)
+		if err != nil {
+			logger.Errorf("Error updating security deposit: %v", err)
+
+			switch {
+			case errors.Is(err, model.ErrInvalidSecurityDepositAmount),
+				errors.Is(err, model.ErrSecurityDepositReasonRequired):
+				return c.JSON(http.StatusBadRequest, response{
+					Success: false,
+					Message: err.Error(),
+				})
+			case errors.Is(err, model.ErrInvalidSecurityDepositAction):
+				return c.JSON(http.StatusConflict, response{
+					Success: false,
+					Message: err.Error(),
+				})
+			}
+
+			return rentalErrorResponse(c, err)
+		}
+
+		return c.JSON(http.StatusOK, response{
+			Success: true,
+			Data:    deposit,
+		})
+	}
+}
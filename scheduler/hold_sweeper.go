@@ -0,0 +1,47 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	"github.com/notblessy/rms/model"
+	"github.com/sirupsen/logrus"
+)
+
+// HoldSweeper periodically releases rental holds that were not confirmed
+// before they expired.
+type HoldSweeper struct {
+	rentalRepo model.RentalRepository
+	interval   time.Duration
+}
+
+// NewHoldSweeper :nodoc:
+func NewHoldSweeper(rentalRepo model.RentalRepository, interval time.Duration) *HoldSweeper {
+	return &HoldSweeper{
+		rentalRepo: rentalRepo,
+		interval:   interval,
+	}
+}
+
+// Start sweeps right away and then every interval until ctx is done.
+func (s *HoldSweeper) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			released, err := s.rentalRepo.ReleaseExpiredHolds(ctx, time.Now())
+			if err != nil {
+				logrus.Errorf("Error releasing expired holds: %v", err)
+			} else if released > 0 {
+				logrus.Infof("Released %d expired rental holds", released)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
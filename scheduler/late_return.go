@@ -0,0 +1,88 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/notblessy/rms/model"
+	"github.com/sirupsen/logrus"
+)
+
+// LateReturnScheduler periodically flags rentals that have not been returned
+// on time and tells staff about them and the bookings they put at risk.
+type LateReturnScheduler struct {
+	rentalRepo model.RentalRepository
+	notifier   model.Notifier
+	interval   time.Duration
+}
+
+// NewLateReturnScheduler :nodoc:
+func NewLateReturnScheduler(rentalRepo model.RentalRepository, notifier model.Notifier, interval time.Duration) *LateReturnScheduler {
+	return &LateReturnScheduler{
+		rentalRepo: rentalRepo,
+		notifier:   notifier,
+		interval:   interval,
+	}
+}
+
+// Start runs the check right away and then every interval until ctx is done.
+func (s *LateReturnScheduler) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			if err := s.Run(ctx, time.Now()); err != nil {
+				logrus.Errorf("Error checking late returns: %v", err)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Run flags the rentals overdue at now and notifies staff of newly overdue
+// ones.
+func (s *LateReturnScheduler) Run(ctx context.Context, now time.Time) error {
+	flagged, err := s.rentalRepo.FlagOverdue(ctx, now)
+	if err != nil {
+		return err
+	}
+
+	for _, overdue := range flagged {
+		err = s.notifier.NotifyStaff(ctx, overdueNotification(overdue))
+		if err != nil {
+			logrus.WithField("rental_id", overdue.Rental.ID).Errorf("Error notifying staff: %v", err)
+		}
+	}
+
+	return nil
+}
+
+func overdueNotification(overdue model.OverdueRental) model.Notification {
+	rental := overdue.Rental
+
+	var message strings.Builder
+	fmt.Fprintf(&message, "Rental %s of camper %s was due back at %s and has not been returned. Late fee so far: %s.",
+		rental.ID, rental.CamperID, rental.ReturnDue().Format(time.RFC3339), rental.LateFee.StringFixed(2))
+
+	if len(overdue.Impacted) > 0 {
+		ids := make([]string, len(overdue.Impacted))
+		for i, impacted := range overdue.Impacted {
+			ids[i] = impacted.ID
+		}
+
+		fmt.Fprintf(&message, " Impacted bookings: %s.", strings.Join(ids, ", "))
+	}
+
+	return model.Notification{
+		Subject: fmt.Sprintf("Rental %s is overdue", rental.ID),
+		Message: message.String(),
+	}
+}